@@ -1,8 +1,10 @@
 package cpu
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"os"
 	"runtime"
 	"strconv"
 	"strings"
@@ -41,6 +43,44 @@ type InfoStat struct {
 	Mhz        float64  `json:"mhz"`
 	CacheSize  int32    `json:"cacheSize"`
 	Flags      []string `json:"flags"`
+	Microcode  string   `json:"microcode"` // ex: 0xde, empty if not exposed
+}
+
+// EffectivePercentStat is the busy percentage of a CPU weighted by the
+// ratio of its current to its maximum frequency.
+type EffectivePercentStat struct {
+	CPU          int     `json:"cpu"`
+	Percent      float64 `json:"percent"`
+	Effective    float64 `json:"effective"`
+	CurMhz       float64 `json:"curMhz"`
+	MaxMhz       float64 `json:"maxMhz"`
+	FreqWeighted bool    `json:"freqWeighted"` // false if the frequency could not be read
+}
+
+// SchedulerTunablesStat holds the CFS scheduler tunables. Missing lists the
+// tunables which could not be read, their value is then 0.
+type SchedulerTunablesStat struct {
+	LatencyNs        uint64   `json:"latencyNs"`
+	MinGranularityNs uint64   `json:"minGranularityNs"`
+	MigrationCostNs  uint64   `json:"migrationCostNs"`
+	Missing          []string `json:"missing"`
+}
+
+// SystemRatesStat holds system wide scheduler activity, per second.
+type SystemRatesStat struct {
+	CtxSwitches float64 `json:"ctxSwitches"`
+	Interrupts  float64 `json:"interrupts"`
+	Forks       float64 `json:"forks"`
+}
+
+// TopologyStat is the number of sockets of the host, of physical cores per
+// socket and of hardware threads per core, 2 or more with hyperthreading.
+type TopologyStat struct {
+	Sockets        int `json:"sockets"`
+	CoresPerSocket int `json:"coresPerSocket"`
+	ThreadsPerCore int `json:"threadsPerCore"`
+	PhysicalCores  int `json:"physicalCores"`
+	LogicalCPUs    int `json:"logicalCpus"`
 }
 
 type lastPercent struct {
@@ -83,21 +123,52 @@ func (c TimesStat) String() string {
 	return `{` + strings.Join(v, ",") + `}`
 }
 
-// Total returns the total number of seconds in a CPUTimesStat
+// Total returns the total number of seconds in a CPUTimesStat.
+// Guest and GuestNice are not added, the kernel already accounts them in
+// User and Nice.
 func (c TimesStat) Total() float64 {
 	total := c.User + c.System + c.Nice + c.Iowait + c.Irq + c.Softirq + c.Steal +
-		c.Guest + c.GuestNice + c.Idle + c.Stolen
+		c.Idle + c.Stolen
 	return total
 }
 
+// TimesWithContext returns Times unless ctx is already done.
+func TimesWithContext(ctx context.Context, percpu bool) ([]TimesStat, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return Times(percpu)
+}
+
+func (c EffectivePercentStat) String() string {
+	s, _ := json.Marshal(c)
+	return string(s)
+}
+
+func (c SchedulerTunablesStat) String() string {
+	s, _ := json.Marshal(c)
+	return string(s)
+}
+
+func (c SystemRatesStat) String() string {
+	s, _ := json.Marshal(c)
+	return string(s)
+}
+
+func (c TopologyStat) String() string {
+	s, _ := json.Marshal(c)
+	return string(s)
+}
+
 func (c InfoStat) String() string {
 	s, _ := json.Marshal(c)
 	return string(s)
 }
 
 func getAllBusy(t TimesStat) (float64, float64) {
+	// Guest and GuestNice are already part of User and Nice
 	busy := t.User + t.System + t.Nice + t.Iowait + t.Irq +
-		t.Softirq + t.Steal + t.Guest + t.GuestNice + t.Stolen
+		t.Softirq + t.Steal + t.Stolen
 	return busy + t.Idle, busy
 }
 
@@ -130,8 +201,22 @@ func calculateAllBusy(t1, t2 []TimesStat) ([]float64, error) {
 	return ret, nil
 }
 
-//Percent calculates the percentage of cpu used either per CPU or combined.
-//If an interval of 0 is given it will compare the current cpu times against the last call.
+// PercentFromTimes returns the percentage of cpu used between two samples
+// of Times taken by the caller, t1 being the older one. It is 0 if no
+// time elapsed between the samples.
+func PercentFromTimes(t1, t2 TimesStat) float64 {
+	return calculateBusy(t1, t2)
+}
+
+// PercentFromTimesSlice returns the percentage of each cpu used between
+// two samples of Times(true) taken by the caller, t1 being the older one.
+// An error is returned if the samples have a different number of cpus.
+func PercentFromTimesSlice(t1, t2 []TimesStat) ([]float64, error) {
+	return calculateAllBusy(t1, t2)
+}
+
+// Percent calculates the percentage of cpu used either per CPU or combined.
+// If an interval of 0 is given it will compare the current cpu times against the last call.
 func Percent(interval time.Duration, percpu bool) ([]float64, error) {
 	if interval <= 0 {
 		return percentUsedFromLastCall(percpu)
@@ -175,3 +260,78 @@ func percentUsedFromLastCall(percpu bool) ([]float64, error) {
 	}
 	return calculateAllBusy(lastTimes, cpuTimes)
 }
+
+// EffectivePercent calculates the percentage of each CPU used over interval,
+// scaled by the current frequency of the CPU relative to its maximum
+// frequency. A CPU fully busy at half its maximum frequency has an
+// Effective percent of 50. If the frequency of a CPU can't be read,
+// Effective is the plain percentage and FreqWeighted is false.
+func EffectivePercent(interval time.Duration) ([]EffectivePercentStat, error) {
+	percents, err := Percent(interval, true)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]EffectivePercentStat, len(percents))
+	for i, percent := range percents {
+		ret[i] = EffectivePercentStat{
+			CPU:       i,
+			Percent:   percent,
+			Effective: percent,
+		}
+		cur, max, err := frequency(i)
+		if err != nil || cur <= 0 || max <= 0 {
+			continue
+		}
+		ret[i].CurMhz = cur
+		ret[i].MaxMhz = max
+		ret[i].Effective = percent * cur / max
+		ret[i].FreqWeighted = true
+	}
+	return ret, nil
+}
+
+// TimesSampler is a common.Sampler of the CPU usage percentage, either per
+// CPU or combined. Its Rate returns a []float64 like Percent.
+type TimesSampler struct {
+	PerCPU bool
+}
+
+var _ common.Sampler = TimesSampler{}
+
+// Snapshot returns the current []TimesStat.
+func (s TimesSampler) Snapshot() (interface{}, error) {
+	return Times(s.PerCPU)
+}
+
+// Rate returns the percentage of CPU used between two snapshots.
+func (s TimesSampler) Rate(prev, cur interface{}, interval time.Duration) (interface{}, error) {
+	t1, ok := prev.([]TimesStat)
+	if !ok {
+		return nil, fmt.Errorf("wrong snapshot type %T", prev)
+	}
+	t2, ok := cur.([]TimesStat)
+	if !ok {
+		return nil, fmt.Errorf("wrong snapshot type %T", cur)
+	}
+	return calculateAllBusy(t1, t2)
+}
+
+// Sampler reads the per CPU times, like Times(true), keeping the stat file
+// open and reusing its read buffer between calls, which suits agents
+// sampling at a high frequency. It is not safe for concurrent use.
+type Sampler struct {
+	file  *os.File
+	buf   []byte
+	names []string
+}
+
+// Close releases the file held by the sampler.
+func (s *Sampler) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
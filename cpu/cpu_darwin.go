@@ -3,9 +3,11 @@
 package cpu
 
 import (
+	"github.com/DataDog/gopsutil/internal/common"
 	"os/exec"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // sys/resource.h
@@ -104,3 +106,31 @@ func Info() ([]InfoStat, error) {
 
 	return append(ret, c), nil
 }
+
+func frequency(cpu int) (float64, float64, error) {
+	return 0, 0, common.ErrNotImplementedError
+}
+
+func SchedulerTunables() (*SchedulerTunablesStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func SystemRates(interval time.Duration) (*SystemRatesStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func PerCoreFrequency() ([]float64, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func NewSampler() (*Sampler, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func (s *Sampler) Sample() ([]TimesStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func Topology() (*TopologyStat, error) {
+	return nil, common.ErrNotImplementedError
+}
@@ -19,3 +19,31 @@ func Info() ([]InfoStat, error) {
 func Percent(interval time.Duration, percpu bool) ([]float64, error) {
 	return []float64{}, common.ErrNotImplementedError
 }
+
+func frequency(cpu int) (float64, float64, error) {
+	return 0, 0, common.ErrNotImplementedError
+}
+
+func SchedulerTunables() (*SchedulerTunablesStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func SystemRates(interval time.Duration) (*SystemRatesStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func PerCoreFrequency() ([]float64, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func NewSampler() (*Sampler, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func (s *Sampler) Sample() ([]TimesStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func Topology() (*TopologyStat, error) {
+	return nil, common.ErrNotImplementedError
+}
@@ -3,11 +3,16 @@
 package cpu
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/DataDog/gopsutil/internal/common"
 )
@@ -76,6 +81,13 @@ func finishCPUInfo(c *InfoStat) error {
 		}
 	}
 
+	if len(c.Microcode) == 0 {
+		lines, err = common.ReadLines(sysCPUPath(c.CPU, "microcode/version"))
+		if err == nil && len(lines) > 0 {
+			c.Microcode = normalizeMicrocode(lines[0])
+		}
+	}
+
 	// override the value of c.Mhz with cpufreq/cpuinfo_max_freq regardless
 	// of the value from /proc/cpuinfo because we want to report the maximum
 	// clock-speed of the CPU for c.Mhz, matching the behaviour of Windows
@@ -96,6 +108,140 @@ func finishCPUInfo(c *InfoStat) error {
 	return nil
 }
 
+// frequency returns the current and maximum frequency in Mhz of a cpu,
+// read from cpufreq.
+func frequency(cpu int) (float64, float64, error) {
+	cur, err := readCPUFreq(int32(cpu), "cpufreq/scaling_cur_freq")
+	if err != nil {
+		return 0, 0, err
+	}
+	max, err := readCPUFreq(int32(cpu), "cpufreq/cpuinfo_max_freq")
+	if err != nil {
+		return 0, 0, err
+	}
+	return cur, max, nil
+}
+
+// PerCoreFrequency returns the current frequency in Mhz of each logical
+// cpu, indexed by cpu number, read from cpufreq/scaling_cur_freq. Unlike
+// the Mhz of Info, it follows frequency scaling. A cpu without cpufreq,
+// such as an offline one, is reported as 0. An error is returned if no
+// cpu has cpufreq, as on most virtual machines.
+func PerCoreFrequency() ([]float64, error) {
+	dirs, err := filepath.Glob(common.HostSys("devices/system/cpu/cpu[0-9]*"))
+	if err != nil {
+		return nil, err
+	}
+	max := -1
+	for _, dir := range dirs {
+		n, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(dir), "cpu"))
+		if err == nil && n > max {
+			max = n
+		}
+	}
+
+	ret := make([]float64, max+1)
+	found := false
+	for i := range ret {
+		freq, err := readCPUFreq(int32(i), "cpufreq/scaling_cur_freq")
+		if err != nil {
+			continue
+		}
+		ret[i] = freq
+		found = true
+	}
+	if !found {
+		return nil, fmt.Errorf("cpufreq not available in %s", common.HostSys("devices/system/cpu"))
+	}
+	return ret, nil
+}
+
+func readCPUFreq(cpu int32, relPath string) (float64, error) {
+	lines, err := common.ReadLines(sysCPUPath(cpu, relPath))
+	if err != nil {
+		return 0, err
+	}
+	if len(lines) == 0 {
+		return 0, fmt.Errorf("%s is empty", relPath)
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(lines[0]), 64)
+	if err != nil {
+		return 0, err
+	}
+	return value / 1000.0, nil // value is in kHz
+}
+
+// normalizeMicrocode formats a microcode revision as lowercase hex with a
+// 0x prefix, sysfs reports it with the prefix and cpuinfo may not.
+func normalizeMicrocode(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	v, err := strconv.ParseUint(strings.TrimPrefix(value, "0x"), 16, 64)
+	if err != nil {
+		return value
+	}
+	return fmt.Sprintf("0x%x", v)
+}
+
+// Topology returns the number of sockets, cores and threads of the host,
+// counting the distinct physical id and core id pairs of /proc/cpuinfo.
+func Topology() (*TopologyStat, error) {
+	lines, err := common.ReadLines(common.HostProc("cpuinfo"))
+	if err != nil {
+		return nil, err
+	}
+	return parseTopology(lines)
+}
+
+// parseTopology counts the processors of cpuinfo. A processor without a
+// physical id, as on most ARM hosts, is on the single socket, and one
+// without a core id is a core of its own.
+func parseTopology(lines []string) (*TopologyStat, error) {
+	sockets := make(map[string]bool)
+	cores := make(map[[2]string]bool)
+	processors := 0
+	physicalID, coreID, processor := "0", "", ""
+	finish := func() {
+		if processor == "" {
+			return
+		}
+		processors++
+		sockets[physicalID] = true
+		if coreID == "" {
+			coreID = "processor " + processor
+		}
+		cores[[2]string{physicalID, coreID}] = true
+		physicalID, coreID, processor = "0", "", ""
+	}
+	for _, line := range lines {
+		fields := strings.SplitN(line, ":", 2)
+		if len(fields) < 2 {
+			continue
+		}
+		value := strings.TrimSpace(fields[1])
+		switch strings.TrimSpace(fields[0]) {
+		case "processor":
+			finish()
+			processor = value
+		case "physical id":
+			physicalID = value
+		case "core id":
+			coreID = value
+		}
+	}
+	finish()
+	if processors == 0 {
+		return nil, errors.New("no processor found in cpuinfo")
+	}
+
+	return &TopologyStat{
+		Sockets:        len(sockets),
+		CoresPerSocket: len(cores) / len(sockets),
+		ThreadsPerCore: processors / len(cores),
+		PhysicalCores:  len(cores),
+		LogicalCPUs:    processors,
+	}, nil
+}
+
 // CPUInfo on linux will return 1 item per physical thread.
 //
 // CPUs have three levels of counting: sockets, cores, threads.
@@ -159,6 +305,8 @@ func Info() ([]InfoStat, error) {
 				return ret, err
 			}
 			c.Stepping = int32(t)
+		case "microcode":
+			c.Microcode = normalizeMicrocode(value)
 		case "cpu MHz", "clock":
 			// treat this as the fallback value, thus we ignore error
 			if t, err := strconv.ParseFloat(strings.Replace(value, "MHz", "", 1), 64); err == nil {
@@ -269,3 +417,230 @@ func parseStatLine(line string) (*TimesStat, error) {
 
 	return ct, nil
 }
+
+// SchedulerTunables returns the CFS scheduler tunables, read from
+// /proc/sys/kernel/sched_*_ns, or from /sys/kernel/debug/sched/ on kernels
+// 5.13 and later where they were moved to debugfs. Tunables which are not
+// available, such as on kernels using EEVDF or without debugfs access,
+// are listed in Missing instead of failing the call.
+func SchedulerTunables() (*SchedulerTunablesStat, error) {
+	ret := &SchedulerTunablesStat{Missing: []string{}}
+	for _, t := range []struct {
+		name  string
+		value *uint64
+	}{
+		{"latency_ns", &ret.LatencyNs},
+		{"min_granularity_ns", &ret.MinGranularityNs},
+		{"migration_cost_ns", &ret.MigrationCostNs},
+	} {
+		v, err := readSchedTunable(t.name)
+		if err != nil {
+			ret.Missing = append(ret.Missing, t.name)
+			continue
+		}
+		*t.value = v
+	}
+	return ret, nil
+}
+
+func readSchedTunable(name string) (uint64, error) {
+	lines, err := common.ReadLines(common.HostProc("sys/kernel", "sched_"+name))
+	if err != nil {
+		lines, err = common.ReadLines(common.HostSys("kernel/debug/sched", name))
+	}
+	if err != nil {
+		return 0, err
+	}
+	if len(lines) == 0 {
+		return 0, fmt.Errorf("sched tunable %s is empty", name)
+	}
+	return strconv.ParseUint(strings.TrimSpace(lines[0]), 10, 64)
+}
+
+// systemCounters holds the ctxt, intr and processes counters of /proc/stat.
+type systemCounters struct {
+	ctxt, intr, forks uint64
+}
+
+// SystemRates returns the number of context switches, interrupts and forks
+// per second over interval, sampled from /proc/stat.
+func SystemRates(interval time.Duration) (*SystemRatesStat, error) {
+	c1, err := readSystemCounters()
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	time.Sleep(interval)
+	c2, err := readSystemCounters()
+	if err != nil {
+		return nil, err
+	}
+	return calculateSystemRates(c1, c2, time.Since(start)), nil
+}
+
+func readSystemCounters() (systemCounters, error) {
+	var ret systemCounters
+	lines, err := common.ReadLines(common.HostProc("stat"))
+	if err != nil {
+		return ret, err
+	}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "ctxt":
+			ret.ctxt = v
+		case "intr":
+			// the total is followed by the count of each interrupt
+			ret.intr = v
+		case "processes":
+			ret.forks = v
+		}
+	}
+	return ret, nil
+}
+
+// calculateSystemRates returns the rates between two samples. A counter
+// which went backwards, because it was reset, has a rate of 0.
+func calculateSystemRates(c1, c2 systemCounters, elapsed time.Duration) *SystemRatesStat {
+	rate := func(prev, cur uint64) float64 {
+		if cur < prev || elapsed <= 0 {
+			return 0
+		}
+		return float64(cur-prev) / elapsed.Seconds()
+	}
+	return &SystemRatesStat{
+		CtxSwitches: rate(c1.ctxt, c2.ctxt),
+		Interrupts:  rate(c1.intr, c2.intr),
+		Forks:       rate(c1.forks, c2.forks),
+	}
+}
+
+// NewSampler opens /proc/stat for repeated reads by Sample. The caller
+// must Close the sampler when done.
+func NewSampler() (*Sampler, error) {
+	f, err := os.Open(common.HostProc("stat"))
+	if err != nil {
+		return nil, err
+	}
+	return &Sampler{file: f, buf: make([]byte, 4096)}, nil
+}
+
+// Sample returns the current times of each CPU. The CPU lines are parsed
+// in place from the read buffer and the CPU names are kept from the
+// previous sample, so a sample only allocates the returned slice.
+func (s *Sampler) Sample() ([]TimesStat, error) {
+	if s.file == nil {
+		return nil, errors.New("sampler is closed")
+	}
+	n, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]TimesStat, 0, len(s.names))
+	data := s.buf[:n]
+	for len(data) > 0 {
+		line := data
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			line, data = data[:i], data[i+1:]
+		} else {
+			data = nil
+		}
+		// the cpu lines come first, the combined one without a number
+		if !bytes.HasPrefix(line, []byte("cpu")) {
+			break
+		}
+		if len(line) > 3 && line[3] == ' ' {
+			continue
+		}
+		ct, err := s.parseLine(line, len(ret))
+		if err != nil {
+			continue
+		}
+		ret = append(ret, ct)
+	}
+	s.names = s.names[:len(ret)]
+	return ret, nil
+}
+
+// read reads the whole stat file into the buffer, growing it if needed.
+func (s *Sampler) read() (int, error) {
+	for {
+		n, err := s.file.ReadAt(s.buf, 0)
+		if err == io.EOF || (err == nil && n < len(s.buf)) {
+			return n, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		s.buf = make([]byte, 2*len(s.buf))
+	}
+}
+
+// parseLine parses the idx-th cpu line like parseStatLine, without
+// converting it to a string.
+func (s *Sampler) parseLine(line []byte, idx int) (TimesStat, error) {
+	var values [10]float64
+	var name []byte
+	field := 0
+	for len(line) > 0 {
+		i := bytes.IndexByte(line, ' ')
+		f := line
+		if i >= 0 {
+			f, line = line[:i], line[i+1:]
+		} else {
+			line = nil
+		}
+		if len(f) == 0 {
+			continue
+		}
+		if field == 0 {
+			name = f
+		} else if field <= len(values) {
+			v, err := parseStatUint(f)
+			if err != nil {
+				return TimesStat{}, err
+			}
+			values[field-1] = float64(v) / cpu_tick
+		}
+		field++
+	}
+	if field < 8 {
+		return TimesStat{}, errors.New("no stats found")
+	}
+	if idx == len(s.names) {
+		s.names = append(s.names, string(name))
+	} else if s.names[idx] != string(name) {
+		s.names[idx] = string(name)
+	}
+	return TimesStat{
+		CPU:       s.names[idx],
+		User:      values[0],
+		Nice:      values[1],
+		System:    values[2],
+		Idle:      values[3],
+		Iowait:    values[4],
+		Irq:       values[5],
+		Softirq:   values[6],
+		Steal:     values[7],
+		Guest:     values[8],
+		GuestNice: values[9],
+	}, nil
+}
+
+func parseStatUint(b []byte) (uint64, error) {
+	var v uint64
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("invalid number %q", b)
+		}
+		v = v*10 + uint64(c-'0')
+	}
+	return v, nil
+}
@@ -0,0 +1,247 @@
+// +build linux
+
+package cpu
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DataDog/gopsutil/internal/common"
+)
+
+func TestSchedulerTunables(t *testing.T) {
+	// latency_ns in procfs, migration_cost_ns in debugfs, no min_granularity_ns
+	dir, err := common.FakeTree(map[string]string{
+		"proc/sys/kernel/sched_latency_ns":         "24000000\n",
+		"sys/kernel/debug/sched/migration_cost_ns": "500000\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("HOST_PROC", filepath.Join(dir, "proc"))
+	defer os.Unsetenv("HOST_PROC")
+	os.Setenv("HOST_SYS", filepath.Join(dir, "sys"))
+	defer os.Unsetenv("HOST_SYS")
+
+	v, err := SchedulerTunables()
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if v.LatencyNs != 24000000 || v.MigrationCostNs != 500000 || v.MinGranularityNs != 0 {
+		t.Errorf("wrong scheduler tunables: %v", v)
+	}
+	if !reflect.DeepEqual(v.Missing, []string{"min_granularity_ns"}) {
+		t.Errorf("wrong missing tunables: %v", v.Missing)
+	}
+}
+
+func TestCalculateSystemRates(t *testing.T) {
+	c1 := systemCounters{ctxt: 1000, intr: 500, forks: 100}
+	c2 := systemCounters{ctxt: 3000, intr: 400, forks: 110}
+	v := calculateSystemRates(c1, c2, 2*time.Second)
+	expected := SystemRatesStat{CtxSwitches: 1000, Interrupts: 0, Forks: 5}
+	if *v != expected {
+		t.Errorf("wrong system rates %v, expected %v", v, expected)
+	}
+}
+
+func TestSystemRates(t *testing.T) {
+	v, err := SystemRates(10 * time.Millisecond)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if v.CtxSwitches <= 0 {
+		t.Errorf("no context switches %v", v)
+	}
+}
+
+func TestNormalizeMicrocode(t *testing.T) {
+	for value, expected := range map[string]string{
+		"0xDE":       "0xde",
+		"0x000000f0": "0xf0",
+		"b4":         "0xb4",
+		"unknown":    "unknown",
+	} {
+		if v := normalizeMicrocode(value); v != expected {
+			t.Errorf("normalizeMicrocode(%q) = %q, expected %q", value, v, expected)
+		}
+	}
+}
+
+func TestPerCoreFrequency(t *testing.T) {
+	dir, err := common.FakeTree(map[string]string{
+		"devices/system/cpu/cpu0/":    "",
+		"devices/system/cpu/cpu1/":    "",
+		"devices/system/cpu/cpu10/":   "",
+		"devices/system/cpu/cpu2/":    "",
+		"devices/system/cpu/cpufreq/": "",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("HOST_SYS", dir)
+	defer os.Unsetenv("HOST_SYS")
+
+	if _, err := PerCoreFrequency(); err == nil {
+		t.Error("expected error without cpufreq")
+	}
+
+	if err := common.WriteTree(dir, map[string]string{
+		"devices/system/cpu/cpu0/cpufreq/scaling_cur_freq":  "2400000\n",
+		"devices/system/cpu/cpu1/cpufreq/scaling_cur_freq":  "800000\n",
+		"devices/system/cpu/cpu10/cpufreq/scaling_cur_freq": "3600000\n",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	v, err := PerCoreFrequency()
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	expected := []float64{2400, 800, 0, 0, 0, 0, 0, 0, 0, 0, 3600}
+	if !reflect.DeepEqual(v, expected) {
+		t.Errorf("wrong frequencies %v", v)
+	}
+}
+
+func TestTimesGuest(t *testing.T) {
+	stat := `cpu  4000 200 1000 20000 100 10 20 30 1500 150
+cpu0 4000 200 1000 20000 100 10 20 30 1500 150
+intr 114930548 113199788 3 0 5 263 0 4
+`
+	dir, err := common.FakeTree(map[string]string{"stat": stat})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("HOST_PROC", dir)
+	defer os.Unsetenv("HOST_PROC")
+
+	for _, percpu := range []bool{false, true} {
+		v, err := TimesWithContext(context.Background(), percpu)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		if len(v) != 1 {
+			t.Fatalf("expected 1 cpu, got %d", len(v))
+		}
+		if v[0].Guest != 1500/cpu_tick || v[0].GuestNice != 150/cpu_tick {
+			t.Errorf("wrong guest times %v", v[0])
+		}
+		// guest time is part of user and nice, and not counted twice
+		if total := v[0].Total(); total != 25360/cpu_tick {
+			t.Errorf("wrong total %v", total)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := TimesWithContext(ctx, false); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestSampler(t *testing.T) {
+	stat := `cpu  2255 34 2290 22625563 6290 127 456 0 0 0
+cpu0 1132 34 1441 11311718 3675 127 438 0 0 0
+cpu1 1123 0 849 11313845 2614 0 18 0 0 0
+intr 114930548 113199788 3 0 5 263 0 4 [... lots more numbers ...]
+ctxt 1990473
+`
+	dir, err := common.FakeTree(map[string]string{"stat": stat})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("HOST_PROC", dir)
+	defer os.Unsetenv("HOST_PROC")
+
+	s, err := NewSampler()
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	defer s.Close()
+	expected, err := Times(true)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		v, err := s.Sample()
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		if !reflect.DeepEqual(v, expected) {
+			t.Errorf("wrong sample %v, expected %v", v, expected)
+		}
+	}
+
+	s.Close()
+	if _, err := s.Sample(); err == nil {
+		t.Error("expected an error sampling a closed sampler")
+	}
+}
+
+func BenchmarkTimes(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Times(true); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSampler(b *testing.B) {
+	s, err := NewSampler()
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer s.Close()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.Sample(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestParseTopology(t *testing.T) {
+	cpuinfo := func(cpus [][2]int, ids bool) []string {
+		var lines []string
+		for i, c := range cpus {
+			lines = append(lines, fmt.Sprintf("processor\t: %d", i), "model name\t: Test CPU")
+			if ids {
+				lines = append(lines, fmt.Sprintf("physical id\t: %d", c[0]), fmt.Sprintf("core id\t\t: %d", c[1]))
+			}
+			lines = append(lines, "")
+		}
+		return lines
+	}
+	for _, tt := range []struct {
+		name     string
+		lines    []string
+		expected TopologyStat
+	}{
+		{"hyperthreaded laptop", cpuinfo([][2]int{{0, 0}, {0, 1}, {0, 0}, {0, 1}}, true), TopologyStat{1, 2, 2, 2, 4}},
+		{"two sockets without hyperthreading", cpuinfo([][2]int{{0, 0}, {0, 1}, {1, 0}, {1, 1}}, true), TopologyStat{2, 2, 1, 4, 4}},
+		{"arm", cpuinfo([][2]int{{}, {}, {}}, false), TopologyStat{1, 3, 1, 3, 3}},
+	} {
+		v, err := parseTopology(tt.lines)
+		if err != nil {
+			t.Fatalf("%s: error %v", tt.name, err)
+		}
+		if *v != tt.expected {
+			t.Errorf("%s: wrong topology %v, expected %v", tt.name, v, tt.expected)
+		}
+	}
+
+	if _, err := parseTopology(nil); err == nil {
+		t.Error("expected an error without processors")
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/DataDog/gopsutil/internal/common"
 )
@@ -108,3 +109,31 @@ func Info() ([]InfoStat, error) {
 
 	return append(ret, c), nil
 }
+
+func frequency(cpu int) (float64, float64, error) {
+	return 0, 0, common.ErrNotImplementedError
+}
+
+func SchedulerTunables() (*SchedulerTunablesStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func SystemRates(interval time.Duration) (*SystemRatesStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func PerCoreFrequency() ([]float64, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func NewSampler() (*Sampler, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func (s *Sampler) Sample() ([]TimesStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func Topology() (*TopologyStat, error) {
+	return nil, common.ErrNotImplementedError
+}
@@ -143,3 +143,60 @@ func TestCPUPercentIntervalZero(t *testing.T) {
 func TestCPUPercentIntervalZeroPerCPU(t *testing.T) {
 	testCPUPercentLastUsed(t, true)
 }
+
+func TestCPUEffectivePercent(t *testing.T) {
+	v, err := EffectivePercent(10 * time.Millisecond)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	numcpu := runtime.NumCPU()
+	if len(v) != numcpu {
+		t.Fatalf("wrong number of entries, expected %d got %d", numcpu, len(v))
+	}
+	for _, e := range v {
+		if e.Effective < 0 || e.Effective > e.Percent+0.0001 {
+			t.Errorf("effective percent out of range: %v", e)
+		}
+		if !e.FreqWeighted && e.Effective != e.Percent {
+			t.Errorf("unweighted effective percent should equal percent: %v", e)
+		}
+	}
+}
+
+func TestCPUTimesSampler(t *testing.T) {
+	s := TimesSampler{PerCPU: true}
+	prev := []TimesStat{{CPU: "cpu0", User: 10, Idle: 10}}
+	cur := []TimesStat{{CPU: "cpu0", User: 15, Idle: 15}}
+	v, err := s.Rate(prev, cur, time.Second)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if p := v.([]float64); len(p) != 1 || p[0] != 50 {
+		t.Errorf("wrong cpu percent %v", p)
+	}
+	if _, err := s.Rate(nil, cur, time.Second); err == nil {
+		t.Error("expected error for wrong snapshot type")
+	}
+}
+
+func TestCPUPercentFromTimes(t *testing.T) {
+	t1 := TimesStat{CPU: "cpu-total", User: 100, System: 50, Idle: 850}
+	t2 := TimesStat{CPU: "cpu-total", User: 130, System: 70, Idle: 900}
+	if p := PercentFromTimes(t1, t2); p != 50 {
+		t.Errorf("wrong cpu percent %v", p)
+	}
+	if p := PercentFromTimes(t1, t1); p != 0 {
+		t.Errorf("expected 0 for identical samples, got %v", p)
+	}
+
+	p, err := PercentFromTimesSlice([]TimesStat{t1, t1}, []TimesStat{t2, t1})
+	if err != nil {
+		t.Errorf("error %v", err)
+	}
+	if len(p) != 2 || p[0] != 50 || p[1] != 0 {
+		t.Errorf("wrong per cpu percent %v", p)
+	}
+	if _, err := PercentFromTimesSlice([]TimesStat{t1}, []TimesStat{t1, t2}); err == nil {
+		t.Error("expected error for different cpu counts")
+	}
+}
@@ -5,6 +5,7 @@ package cpu
 import (
 	"fmt"
 	"syscall"
+	"time"
 	"unsafe"
 
 	"github.com/StackExchange/wmi"
@@ -84,3 +85,31 @@ func Info() ([]InfoStat, error) {
 
 	return ret, nil
 }
+
+func frequency(cpu int) (float64, float64, error) {
+	return 0, 0, common.ErrNotImplementedError
+}
+
+func SchedulerTunables() (*SchedulerTunablesStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func SystemRates(interval time.Duration) (*SystemRatesStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func PerCoreFrequency() ([]float64, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func NewSampler() (*Sampler, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func (s *Sampler) Sample() ([]TimesStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func Topology() (*TopologyStat, error) {
+	return nil, common.ErrNotImplementedError
+}
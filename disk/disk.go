@@ -1,13 +1,23 @@
 package disk
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sync"
+	"time"
 
 	"github.com/DataDog/gopsutil/internal/common"
 )
 
 var invoke common.Invoker
 
+// ErrSmartctlNotFound is returned by SmartAttributes when smartctl, from
+// smartmontools, is not installed.
+var ErrSmartctlNotFound = errors.New("smartctl not found")
+
 func init() {
 	invoke = common.Invoke{}
 }
@@ -23,6 +33,9 @@ type UsageStat struct {
 	InodesUsed        uint64  `json:"inodesUsed"`
 	InodesFree        uint64  `json:"inodesFree"`
 	InodesUsedPercent float64 `json:"inodesUsedPercent"`
+	// InodesSupported is false when the platform or the filesystem, such as
+	// btrfs or vfat, does not report inodes, the Inodes fields are then 0.
+	InodesSupported bool `json:"inodesSupported"`
 }
 
 type PartitionStat struct {
@@ -32,6 +45,14 @@ type PartitionStat struct {
 	Opts       string `json:"opts"`
 }
 
+// PartitionOptions selects the mounts returned by PartitionsWithOptions in
+// addition to the ones of block device filesystems.
+type PartitionOptions struct {
+	IncludePseudo  bool // pseudo filesystems such as tmpfs, proc or cgroup
+	IncludeOverlay bool // overlay and aufs filesystems, such as a container root
+	IncludeBind    bool // bind mounts of a directory of another mount
+}
+
 type IOCountersStat struct {
 	ReadCount        uint64 `json:"readCount"`
 	MergedReadCount  uint64 `json:"mergedReadCount"`
@@ -44,8 +65,40 @@ type IOCountersStat struct {
 	IopsInProgress   uint64 `json:"iopsInProgress"`
 	IoTime           uint64 `json:"ioTime"`
 	WeightedIO       uint64 `json:"weightedIO"`
+	DiscardCount     uint64 `json:"discardCount"`
+	DiscardBytes     uint64 `json:"discardBytes"`
+	FlushCount       uint64 `json:"flushCount"`
 	Name             string `json:"name"`
 	SerialNumber     string `json:"serialNumber"`
+	Label            string `json:"label"` // device-mapper name, ex: vg0-data for dm-0
+}
+
+// UsageResult is the outcome of gathering the usage of a single mountpoint.
+type UsageResult struct {
+	Usage *UsageStat `json:"usage"`
+	Err   error      `json:"-"`
+}
+
+// DiscardInfoStat describes the discard (TRIM) support and the I/O
+// alignment of a block device, all sizes are in bytes.
+type DiscardInfoStat struct {
+	Name               string `json:"name"`
+	DiscardSupported   bool   `json:"discardSupported"`
+	DiscardMaxBytes    uint64 `json:"discardMaxBytes"`
+	DiscardGranularity uint64 `json:"discardGranularity"`
+	LogicalBlockSize   uint64 `json:"logicalBlockSize"`
+	PhysicalBlockSize  uint64 `json:"physicalBlockSize"`
+	MinimumIOSize      uint64 `json:"minimumIOSize"`
+	OptimalIOSize      uint64 `json:"optimalIOSize"` // 0 if the device does not report one
+}
+
+// IORateStat is the per second rate of the IO counters of a disk.
+type IORateStat struct {
+	Name       string  `json:"name"`
+	ReadCount  float64 `json:"readCount"`
+	WriteCount float64 `json:"writeCount"`
+	ReadBytes  float64 `json:"readBytes"`
+	WriteBytes float64 `json:"writeBytes"`
 }
 
 func (d UsageStat) String() string {
@@ -62,3 +115,265 @@ func (d IOCountersStat) String() string {
 	s, _ := json.Marshal(d)
 	return string(s)
 }
+
+// WriteCacheStat is the write cache mode of a block device. A write back
+// cache acknowledges writes before they reach stable storage, and loses
+// them on power failure unless the cache is battery backed or flushed.
+type WriteCacheStat struct {
+	Name      string `json:"name"`
+	Mode      string `json:"mode"` // "write back" or "write through"
+	WriteBack bool   `json:"writeBack"`
+	// FUA is true if the device supports Forced Unit Access writes, used
+	// by filesystems to write through the cache without a full flush.
+	FUA bool `json:"fua"`
+	// CacheType is the cache_type of a SCSI disk, ex: "write back, no
+	// read (daddy)". Empty for other devices.
+	CacheType string `json:"cacheType"`
+}
+
+// DeviceTopologyStat is a block device and the devices it is stacked on,
+// ex: a dm-crypt device on an LVM logical volume on a RAID1 of two
+// partitions.
+type DeviceTopologyStat struct {
+	Name string `json:"name"` // kernel name, ex: dm-0, md0, sda1
+	// Label is the name of a device mapper device, ex: vg0-root, or of an
+	// md array. Empty for other devices.
+	Label string `json:"label"`
+	// Type is the kind of the device: disk, partition, loop, crypt, lvm,
+	// multipath or dm for other device mapper targets, or the RAID level of
+	// an md array, ex: raid1.
+	Type    string               `json:"type"`
+	Size    uint64               `json:"size"`    // bytes
+	Holders []string             `json:"holders"` // devices stacked on top of the device
+	Slaves  []DeviceTopologyStat `json:"slaves"`  // devices the device is stacked on
+}
+
+// SmartStat is the SMART health of a disk. ReallocatedSectors is only
+// reported by ATA disks, Health is empty if smartctl could not read it.
+type SmartStat struct {
+	Device             string `json:"device"`
+	Health             string `json:"health"` // PASSED or FAILED
+	ReallocatedSectors uint64 `json:"reallocatedSectors"`
+	PowerOnHours       uint64 `json:"powerOnHours"`
+	Temperature        int64  `json:"temperature"` // degrees Celsius
+}
+
+func (d DiscardInfoStat) String() string {
+	s, _ := json.Marshal(d)
+	return string(s)
+}
+
+func (d SmartStat) String() string {
+	s, _ := json.Marshal(d)
+	return string(s)
+}
+
+func (d DeviceTopologyStat) String() string {
+	s, _ := json.Marshal(d)
+	return string(s)
+}
+
+func (d WriteCacheStat) String() string {
+	s, _ := json.Marshal(d)
+	return string(s)
+}
+
+func (d IORateStat) String() string {
+	s, _ := json.Marshal(d)
+	return string(s)
+}
+
+// UsageTimeout is the time UsageAll waits for the usage of a single
+// mountpoint before giving up on it.
+var UsageTimeout = common.Timeout
+
+// usage is replaced in tests to simulate hung mounts.
+var usage = Usage
+
+// UsageAll returns the usage of every partition, keyed by mountpoint.
+// Mountpoints are queried by at most concurrency workers at a time. A mount
+// that does not answer within UsageTimeout, or before ctx is done, has its
+// Err set instead of failing the whole call; the blocked statfs call can't
+// be interrupted, so it is left to finish in the background.
+func UsageAll(ctx context.Context, concurrency int) (map[string]UsageResult, error) {
+	partitions, err := Partitions(false)
+	if err != nil {
+		return nil, err
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		ret = make(map[string]UsageResult, len(partitions))
+	)
+	mounts := make(chan string)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for mount := range mounts {
+				r := usageTimeout(ctx, mount)
+				mu.Lock()
+				ret[mount] = r
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, p := range partitions {
+		mounts <- p.Mountpoint
+	}
+	close(mounts)
+	wg.Wait()
+
+	return ret, nil
+}
+
+func usageTimeout(ctx context.Context, path string) UsageResult {
+	if err := ctx.Err(); err != nil {
+		return UsageResult{Err: err}
+	}
+	done := make(chan UsageResult, 1)
+	go func() {
+		u, err := usage(path)
+		done <- UsageResult{Usage: u, Err: err}
+	}()
+
+	timer := time.NewTimer(UsageTimeout)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r
+	case <-timer.C:
+		return UsageResult{Err: common.ErrTimeout}
+	case <-ctx.Done():
+		return UsageResult{Err: ctx.Err()}
+	}
+}
+
+// IOCountersSampler is a common.Sampler of disk IO rates. Its Rate returns
+// a map[string]IORateStat keyed by device name.
+type IOCountersSampler struct{}
+
+var _ common.Sampler = IOCountersSampler{}
+
+// Snapshot returns the current map[string]IOCountersStat.
+func (s IOCountersSampler) Snapshot() (interface{}, error) {
+	return IOCounters()
+}
+
+// Rate returns the per second rates between two snapshots. Devices which
+// are missing from the previous snapshot are skipped.
+func (s IOCountersSampler) Rate(prev, cur interface{}, interval time.Duration) (interface{}, error) {
+	c1, ok := prev.(map[string]IOCountersStat)
+	if !ok {
+		return nil, fmt.Errorf("wrong snapshot type %T", prev)
+	}
+	c2, ok := cur.(map[string]IOCountersStat)
+	if !ok {
+		return nil, fmt.Errorf("wrong snapshot type %T", cur)
+	}
+	ret := make(map[string]IORateStat, len(c2))
+	for name, c := range c2 {
+		p, ok := c1[name]
+		if !ok {
+			continue
+		}
+		ret[name] = IORateStat{
+			Name:       name,
+			ReadCount:  common.CounterRate(p.ReadCount, c.ReadCount, interval),
+			WriteCount: common.CounterRate(p.WriteCount, c.WriteCount, interval),
+			ReadBytes:  common.CounterRate(p.ReadBytes, c.ReadBytes, interval),
+			WriteBytes: common.CounterRate(p.WriteBytes, c.WriteBytes, interval),
+		}
+	}
+	return ret, nil
+}
+
+// SmartAttributes returns the SMART health of device, such as /dev/sda,
+// read with smartctl -A -H -j, which usually requires root.
+func SmartAttributes(device string) (*SmartStat, error) {
+	return SmartAttributesWithContext(context.Background(), device)
+}
+
+// SmartAttributesWithContext is like SmartAttributes but kills smartctl
+// when ctx is done.
+func SmartAttributesWithContext(ctx context.Context, device string) (*SmartStat, error) {
+	smartctl, err := exec.LookPath("smartctl")
+	if err != nil {
+		return nil, ErrSmartctlNotFound
+	}
+	out, err := invoke.CommandWithContext(ctx, smartctl, "-A", "-H", "-j", device)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	// the exit status of smartctl is a bit mask which is also set for a
+	// failing disk, the output is checked instead
+	ret, perr := parseSmartctl(out)
+	if perr != nil {
+		if err != nil {
+			return nil, err
+		}
+		return nil, perr
+	}
+	ret.Device = device
+	return ret, nil
+}
+
+// parseSmartctl parses the JSON output of smartctl. Errors, such as a
+// missing device, are reported in its messages with the error severity.
+func parseSmartctl(out []byte) (*SmartStat, error) {
+	var v struct {
+		Smartctl struct {
+			Messages []struct {
+				String   string `json:"string"`
+				Severity string `json:"severity"`
+			} `json:"messages"`
+		} `json:"smartctl"`
+		SmartStatus *struct {
+			Passed bool `json:"passed"`
+		} `json:"smart_status"`
+		Temperature struct {
+			Current int64 `json:"current"`
+		} `json:"temperature"`
+		PowerOnTime struct {
+			Hours uint64 `json:"hours"`
+		} `json:"power_on_time"`
+		ATASmartAttributes struct {
+			Table []struct {
+				ID  int `json:"id"`
+				Raw struct {
+					Value uint64 `json:"value"`
+				} `json:"raw"`
+			} `json:"table"`
+		} `json:"ata_smart_attributes"`
+	}
+	if err := json.Unmarshal(out, &v); err != nil {
+		return nil, fmt.Errorf("could not parse smartctl output: %s", err)
+	}
+	for _, m := range v.Smartctl.Messages {
+		if m.Severity == "error" {
+			return nil, errors.New(m.String)
+		}
+	}
+
+	ret := &SmartStat{
+		PowerOnHours: v.PowerOnTime.Hours,
+		Temperature:  v.Temperature.Current,
+	}
+	if v.SmartStatus != nil {
+		ret.Health = "FAILED"
+		if v.SmartStatus.Passed {
+			ret.Health = "PASSED"
+		}
+	}
+	for _, a := range v.ATASmartAttributes.Table {
+		// Reallocated_Sector_Ct
+		if a.ID == 5 {
+			ret.ReallocatedSectors = a.Raw.Value
+		}
+	}
+	return ret, nil
+}
@@ -109,3 +109,23 @@ func Getfsstat(buf []Statfs_t, flags int) (n int, err error) {
 func getFsType(stat syscall.Statfs_t) string {
 	return common.IntToString(stat.Fstypename[:])
 }
+
+func DiscardInfo(name string) (*DiscardInfoStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func WriteCacheMode(name string) (*WriteCacheStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func DeviceTopology(name string) (*DeviceTopologyStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func IOCountersByGlob(pattern string) (map[string]IOCountersStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func PartitionsWithOptions(opts PartitionOptions) ([]PartitionStat, error) {
+	return Partitions(opts.IncludePseudo)
+}
@@ -15,3 +15,23 @@ func Partitions(all bool) ([]PartitionStat, error) {
 func Usage(path string) (*UsageStat, error) {
 	return nil, common.ErrNotImplementedError
 }
+
+func DiscardInfo(name string) (*DiscardInfoStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func WriteCacheMode(name string) (*WriteCacheStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func DeviceTopology(name string) (*DeviceTopologyStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func IOCountersByGlob(pattern string) (map[string]IOCountersStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func PartitionsWithOptions(opts PartitionOptions) ([]PartitionStat, error) {
+	return Partitions(opts.IncludePseudo)
+}
@@ -4,7 +4,10 @@ package disk
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"syscall"
@@ -217,6 +220,17 @@ var fsTypeMap = map[int64]string{
 //
 // should use setmntent(3) but this implement use /etc/mtab file
 func Partitions(all bool) ([]PartitionStat, error) {
+	return PartitionsWithOptions(PartitionOptions{
+		IncludePseudo:  all,
+		IncludeOverlay: all,
+		IncludeBind:    true,
+	})
+}
+
+// PartitionsWithOptions returns the mounts listed in /etc/mtab, filtered
+// according to opts. Bind mounts are recognized by their root in
+// /proc/self/mountinfo not being the root of the mounted filesystem.
+func PartitionsWithOptions(opts PartitionOptions) ([]PartitionStat, error) {
 	filename := common.HostEtc("mtab")
 	lines, err := common.ReadLines(filename)
 	if err != nil {
@@ -228,6 +242,14 @@ func Partitions(all bool) ([]PartitionStat, error) {
 		return nil, err
 	}
 
+	var binds map[string]bool
+	if !opts.IncludeBind {
+		binds, err = getBindMounts()
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	ret := make([]PartitionStat, 0, len(lines))
 
 	for _, line := range lines {
@@ -238,17 +260,51 @@ func Partitions(all bool) ([]PartitionStat, error) {
 			Fstype:     fields[2],
 			Opts:       fields[3],
 		}
-		if all == false {
-			if d.Device == "none" || !common.StringsHas(fs, d.Fstype) {
+		switch {
+		case d.Fstype == "overlay" || d.Fstype == "aufs":
+			if !opts.IncludeOverlay {
+				continue
+			}
+		case d.Device == "none" || !common.StringsHas(fs, d.Fstype):
+			if !opts.IncludePseudo {
 				continue
 			}
 		}
+		if binds[d.Mountpoint] {
+			continue
+		}
 		ret = append(ret, d)
 	}
 
 	return ret, nil
 }
 
+// getBindMounts returns the mount points of /proc/self/mountinfo whose root,
+// the fourth field, is a directory inside the mounted filesystem. btrfs
+// subvolumes are mounted the same way and are not reported.
+func getBindMounts() (map[string]bool, error) {
+	lines, err := common.ReadLines(common.HostProc("self/mountinfo"))
+	if err != nil {
+		return nil, err
+	}
+	ret := make(map[string]bool)
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 5 {
+			continue
+		}
+		fstype := ""
+		for i := 5; i < len(fields)-1; i++ {
+			if fields[i] == "-" {
+				fstype = fields[i+1]
+				break
+			}
+		}
+		ret[fields[4]] = fields[3] != "/" && fstype != "btrfs"
+	}
+	return ret, nil
+}
+
 // getFileSystems returns supported filesystems from /proc/filesystems
 func getFileSystems() ([]string, error) {
 	filename := common.HostProc("filesystems")
@@ -273,6 +329,25 @@ func getFileSystems() ([]string, error) {
 }
 
 func IOCounters() (map[string]IOCountersStat, error) {
+	return ioCounters("")
+}
+
+// IOCountersByGlob returns the IO counters of the disks whose name matches
+// pattern, with the syntax of filepath.Match, such as "sd*" or "nvme?n1".
+// Other disks are skipped before reading their sysfs attributes, which is
+// cheaper than filtering the result of IOCounters on hosts with many
+// loop or dm devices.
+func IOCountersByGlob(pattern string) (map[string]IOCountersStat, error) {
+	// validate the pattern even if no disk is listed
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	return ioCounters(pattern)
+}
+
+// ioCounters returns the IO counters of the disks matching pattern, or of
+// all of them if it is empty.
+func ioCounters(pattern string) (map[string]IOCountersStat, error) {
 	filename := common.HostProc("diskstats")
 	lines, err := common.ReadLines(filename)
 	if err != nil {
@@ -288,6 +363,11 @@ func IOCounters() (map[string]IOCountersStat, error) {
 			continue
 		}
 		name := fields[2]
+		if pattern != "" {
+			if ok, err := filepath.Match(pattern, name); err != nil || !ok {
+				continue
+			}
+		}
 		reads, err := strconv.ParseUint((fields[3]), 10, 64)
 		if err != nil {
 			return ret, err
@@ -350,12 +430,246 @@ func IOCounters() (map[string]IOCountersStat, error) {
 		}
 		d.Name = name
 
+		// discard and flush counters are only in the extended sysfs format,
+		// keep them zero when the kernel does not provide them
+		statfile := common.HostSys("class/block", name, "stat")
+		if lines, err := common.ReadLines(statfile); err == nil && len(lines) > 0 {
+			d.DiscardCount, d.DiscardBytes, d.FlushCount = parseSysBlockStat(lines[0])
+		}
+
 		d.SerialNumber = GetDiskSerialNumber(name)
+		d.Label = dmName(name)
 		ret[name] = d
 	}
 	return ret, nil
 }
 
+// dmName returns the device-mapper name of a dm-N device, such as the
+// vg-lv name of an LVM logical volume, or "" for other devices.
+func dmName(name string) string {
+	if !strings.HasPrefix(name, "dm-") {
+		return ""
+	}
+	lines, err := common.ReadLines(common.HostSys("block", name, "dm", "name"))
+	if err != nil || len(lines) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(lines[0])
+}
+
+// parseSysBlockStat parses the discard and flush counters out of a
+// /sys/block/<dev>/stat line. Kernels 4.18+ append four discard fields to
+// the 11 historical ones, and 5.5+ add two flush fields after them.
+func parseSysBlockStat(line string) (discardCount, discardBytes, flushCount uint64) {
+	fields := strings.Fields(line)
+	if len(fields) >= 15 {
+		discardCount, _ = strconv.ParseUint(fields[11], 10, 64)
+		sectors, _ := strconv.ParseUint(fields[13], 10, 64)
+		discardBytes = sectors * SectorSize
+	}
+	if len(fields) >= 17 {
+		flushCount, _ = strconv.ParseUint(fields[15], 10, 64)
+	}
+	return discardCount, discardBytes, flushCount
+}
+
+// DiscardInfo returns the discard support and the I/O alignment of a block
+// device, ex: sda or /dev/sda, read from /sys/class/block/(name)/queue/.
+// For a partition, the queue of its parent device is used. Values the
+// device does not expose are left to 0.
+func DiscardInfo(name string) (*DiscardInfoStat, error) {
+	name = filepath.Base(name)
+	queue, err := blockQueueDir(name)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &DiscardInfoStat{Name: name}
+	for file, value := range map[string]*uint64{
+		"discard_max_bytes":   &ret.DiscardMaxBytes,
+		"discard_granularity": &ret.DiscardGranularity,
+		"logical_block_size":  &ret.LogicalBlockSize,
+		"physical_block_size": &ret.PhysicalBlockSize,
+		"minimum_io_size":     &ret.MinimumIOSize,
+		"optimal_io_size":     &ret.OptimalIOSize,
+	} {
+		lines, err := common.ReadLines(filepath.Join(queue, file))
+		if err != nil || len(lines) == 0 {
+			continue
+		}
+		v, err := strconv.ParseUint(strings.TrimSpace(lines[0]), 10, 64)
+		if err != nil {
+			continue
+		}
+		*value = v
+	}
+	ret.DiscardSupported = ret.DiscardMaxBytes > 0
+
+	return ret, nil
+}
+
+// WriteCacheMode returns the write cache mode of a block device, ex: sda
+// or /dev/sda, read from /sys/class/block/(name)/queue/write_cache, and for
+// SCSI disks the cache_type of the disk. For a partition, the queue of its
+// parent device is used. ErrNotImplementedError is returned if the device
+// does not expose its write cache mode.
+func WriteCacheMode(name string) (*WriteCacheStat, error) {
+	name = filepath.Base(name)
+	queue, err := blockQueueDir(name)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := common.ReadLines(filepath.Join(queue, "write_cache"))
+	if os.IsNotExist(err) {
+		return nil, common.ErrNotImplementedError
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("write_cache of %s is empty", name)
+	}
+	ret := &WriteCacheStat{
+		Name: name,
+		Mode: strings.TrimSpace(lines[0]),
+	}
+	ret.WriteBack = ret.Mode == "write back"
+
+	fua, err := common.ReadInts(filepath.Join(queue, "fua"))
+	if err == nil && len(fua) > 0 {
+		ret.FUA = fua[0] == 1
+	}
+
+	// the disk of a partition is the parent of the queue
+	disk := filepath.Dir(queue)
+	types, _ := filepath.Glob(filepath.Join(disk, "device", "scsi_disk", "*", "cache_type"))
+	if len(types) > 0 {
+		lines, err := common.ReadLines(types[0])
+		if err == nil && len(lines) > 0 {
+			ret.CacheType = strings.TrimSpace(lines[0])
+		}
+	}
+	return ret, nil
+}
+
+// blockQueueDir returns the sysfs directory of the request queue of a
+// block device. Partitions are subdirectories of their parent device and
+// have no queue of their own, so the queue of the parent is returned.
+func blockQueueDir(name string) (string, error) {
+	dev, err := filepath.EvalSymlinks(common.HostSys("class/block", name))
+	if err != nil {
+		return "", err
+	}
+	queue := filepath.Join(dev, "queue")
+	if !common.PathExists(queue) {
+		queue = filepath.Join(filepath.Dir(dev), "queue")
+	}
+	return queue, nil
+}
+
+// maxTopologyDepth bounds the walk of the slaves of a block device.
+const maxTopologyDepth = 16
+
+// DeviceTopology returns the tree of the devices a block device, ex: dm-0
+// or /dev/mapper/data, is stacked on, walking down
+// /sys/class/block/(name)/slaves to the physical devices. A partition has
+// its disk as single slave.
+func DeviceTopology(name string) (*DeviceTopologyStat, error) {
+	if strings.HasPrefix(name, "/dev/") {
+		// /dev/mapper/(label) and /dev/(vg)/(lv) are links to /dev/dm-(n)
+		if target, err := filepath.EvalSymlinks(name); err == nil {
+			name = target
+		}
+	}
+	return deviceTopology(filepath.Base(name), 0)
+}
+
+func deviceTopology(name string, depth int) (*DeviceTopologyStat, error) {
+	if depth > maxTopologyDepth {
+		return nil, fmt.Errorf("block devices stacked too deep at %s", name)
+	}
+	dev, err := filepath.EvalSymlinks(common.HostSys("class/block", name))
+	if err != nil {
+		return nil, err
+	}
+	ret := &DeviceTopologyStat{
+		Name:    name,
+		Type:    "disk",
+		Holders: []string{},
+		Slaves:  []DeviceTopologyStat{},
+	}
+	if lines, err := common.ReadLines(filepath.Join(dev, "size")); err == nil && len(lines) > 0 {
+		sectors, err := strconv.ParseUint(strings.TrimSpace(lines[0]), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		// the size is always in 512 bytes sectors
+		ret.Size = sectors * 512
+	}
+	if holders, err := ioutil.ReadDir(filepath.Join(dev, "holders")); err == nil {
+		for _, h := range holders {
+			ret.Holders = append(ret.Holders, h.Name())
+		}
+	}
+
+	var slaves []string
+	switch {
+	case common.PathExists(filepath.Join(dev, "partition")):
+		ret.Type = "partition"
+		slaves = []string{filepath.Base(filepath.Dir(dev))}
+	case common.PathExists(filepath.Join(dev, "dm")):
+		ret.Type = "dm"
+		if lines, err := common.ReadLines(filepath.Join(dev, "dm", "name")); err == nil && len(lines) > 0 {
+			ret.Label = lines[0]
+		}
+		if lines, err := common.ReadLines(filepath.Join(dev, "dm", "uuid")); err == nil && len(lines) > 0 {
+			ret.Type = dmType(lines[0])
+		}
+	case common.PathExists(filepath.Join(dev, "md")):
+		ret.Label = name
+		if lines, err := common.ReadLines(filepath.Join(dev, "md", "level")); err == nil && len(lines) > 0 {
+			ret.Type = lines[0]
+		}
+	case common.PathExists(filepath.Join(dev, "loop")):
+		ret.Type = "loop"
+	}
+
+	if slaves == nil {
+		entries, err := ioutil.ReadDir(filepath.Join(dev, "slaves"))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		for _, e := range entries {
+			slaves = append(slaves, e.Name())
+		}
+	}
+	for _, slave := range slaves {
+		s, err := deviceTopology(slave, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		ret.Slaves = append(ret.Slaves, *s)
+	}
+	return ret, nil
+}
+
+// dmType returns the kind of a device mapper device from the prefix its
+// creator set on its uuid, ex: CRYPT-LUKS2-(uuid)-(name) or LVM-(uuid).
+func dmType(uuid string) string {
+	switch {
+	case strings.HasPrefix(uuid, "CRYPT-"):
+		return "crypt"
+	case strings.HasPrefix(uuid, "LVM-"):
+		return "lvm"
+	case strings.HasPrefix(uuid, "mpath-"):
+		return "multipath"
+	case strings.HasPrefix(uuid, "part"):
+		// kpartx partitions of a multipath device
+		return "partition"
+	}
+	return "dm"
+}
+
 // GetDiskSerialNumber returns Serial Number of given device or empty string
 // on error. Name of device is expected, eg. /dev/sda
 func GetDiskSerialNumber(name string) string {
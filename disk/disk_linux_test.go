@@ -0,0 +1,314 @@
+// +build linux
+
+package disk
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/DataDog/gopsutil/internal/common"
+)
+
+func TestParseSysBlockStat(t *testing.T) {
+	// kernels older than 4.18 only provide the 11 historical fields
+	short := "   46349    11823  2897138    24946   108117   100536  5765722    99040        0    76844   124053"
+	discardCount, discardBytes, flushCount := parseSysBlockStat(short)
+	if discardCount != 0 || discardBytes != 0 || flushCount != 0 {
+		t.Errorf("short format should not report discard/flush: %v %v %v", discardCount, discardBytes, flushCount)
+	}
+
+	discard := "   46349    11823  2897138    24946   108117   100536  5765722    99040        0    76844   124053      120        0   409600       35"
+	discardCount, discardBytes, flushCount = parseSysBlockStat(discard)
+	if discardCount != 120 || discardBytes != 409600*SectorSize || flushCount != 0 {
+		t.Errorf("could not parse discard format: %v %v %v", discardCount, discardBytes, flushCount)
+	}
+
+	extended := discard + "     5210     1234"
+	discardCount, discardBytes, flushCount = parseSysBlockStat(extended)
+	if discardCount != 120 || discardBytes != 409600*SectorSize || flushCount != 5210 {
+		t.Errorf("could not parse extended format: %v %v %v", discardCount, discardBytes, flushCount)
+	}
+}
+
+func TestDiscardInfo(t *testing.T) {
+	dev := "devices/pci0000:00/block/nvme0n1/"
+	dir, err := common.FakeTree(map[string]string{
+		dev + "queue/discard_max_bytes":   "2199023255040\n",
+		dev + "queue/discard_granularity": "4096\n",
+		dev + "queue/logical_block_size":  "512\n",
+		dev + "queue/physical_block_size": "4096\n",
+		dev + "queue/minimum_io_size":     "4096\n",
+		dev + "nvme0n1p1/":                "",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// /sys/class/block entries are links to the device directories
+	if err := common.SymlinkTree(dir, map[string]string{
+		"class/block/nvme0n1":   dev,
+		"class/block/nvme0n1p1": dev + "nvme0n1p1",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("HOST_SYS", dir)
+	defer os.Unsetenv("HOST_SYS")
+
+	expected := DiscardInfoStat{
+		DiscardSupported:   true,
+		DiscardMaxBytes:    2199023255040,
+		DiscardGranularity: 4096,
+		LogicalBlockSize:   512,
+		PhysicalBlockSize:  4096,
+		MinimumIOSize:      4096,
+	}
+	for _, name := range []string{"/dev/nvme0n1", "nvme0n1p1"} {
+		v, err := DiscardInfo(name)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		expected.Name = filepath.Base(name)
+		if *v != expected {
+			t.Errorf("wrong discard info for %s: %v", name, v)
+		}
+	}
+
+	if _, err := DiscardInfo("sdz"); err == nil {
+		t.Error("expected error for missing device")
+	}
+}
+
+func TestWriteCacheMode(t *testing.T) {
+	sda := "devices/pci0000:00/block/sda/"
+	loop := "devices/virtual/block/loop0/"
+	dir, err := common.FakeTree(map[string]string{
+		sda + "queue/write_cache":                   "write back\n",
+		sda + "queue/fua":                           "0\n",
+		sda + "device/scsi_disk/0:0:0:0/cache_type": "write back\n",
+		sda + "sda1/":                               "",
+		loop + "queue/":                             "",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := common.SymlinkTree(dir, map[string]string{
+		"class/block/sda":   sda,
+		"class/block/sda1":  sda + "sda1",
+		"class/block/loop0": loop,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("HOST_SYS", dir)
+	defer os.Unsetenv("HOST_SYS")
+
+	for _, name := range []string{"/dev/sda", "sda1"} {
+		v, err := WriteCacheMode(name)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		expected := WriteCacheStat{
+			Name:      filepath.Base(name),
+			Mode:      "write back",
+			WriteBack: true,
+			CacheType: "write back",
+		}
+		if *v != expected {
+			t.Errorf("wrong write cache mode for %s: %v", name, v)
+		}
+	}
+
+	if _, err := WriteCacheMode("loop0"); err != common.ErrNotImplementedError {
+		t.Errorf("expected ErrNotImplementedError, got %v", err)
+	}
+}
+
+func TestDeviceTopology(t *testing.T) {
+	dir, err := common.FakeTree(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	add := func(path string, files map[string]string, slaves ...string) {
+		dev := "devices/" + path + "/"
+		tree := map[string]string{dev + "holders/": "", dev + "slaves/": ""}
+		for file, value := range files {
+			tree[dev+file] = value + "\n"
+		}
+		for _, s := range slaves {
+			tree[dev+"slaves/"+s+"/"] = ""
+		}
+		if err := common.WriteTree(dir, tree); err != nil {
+			t.Fatal(err)
+		}
+		if err := common.SymlinkTree(dir, map[string]string{"class/block/" + filepath.Base(path): dev}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 2TB disks have more than 2^31 sectors
+	add("pci/block/sda", map[string]string{"size": "3907029168"})
+	add("pci/block/sda/sda1", map[string]string{"size": "1024", "partition": "1"})
+	add("pci/block/sdb", map[string]string{"size": "3907029168"})
+	add("pci/block/sdb/sdb1", map[string]string{"size": "1024", "partition": "1"})
+	add("virtual/block/md0", map[string]string{"size": "1000", "md/level": "raid1"}, "sda1", "sdb1")
+	add("virtual/block/dm-0", map[string]string{"size": "900", "dm/name": "vg0-data", "dm/uuid": "LVM-abc"}, "md0")
+	add("virtual/block/dm-1", map[string]string{"size": "896", "dm/name": "data", "dm/uuid": "CRYPT-LUKS2-abc-data"}, "dm-0")
+	if err := common.WriteTree(dir, map[string]string{"devices/virtual/block/dm-0/holders/dm-1/": ""}); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("HOST_SYS", dir)
+	defer os.Unsetenv("HOST_SYS")
+
+	v, err := DeviceTopology("dm-1")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if v.Type != "crypt" || v.Label != "data" || v.Size != 896*512 || len(v.Slaves) != 1 {
+		t.Fatalf("wrong crypt device %v", v)
+	}
+	lv := v.Slaves[0]
+	if lv.Type != "lvm" || lv.Label != "vg0-data" || !reflect.DeepEqual(lv.Holders, []string{"dm-1"}) || len(lv.Slaves) != 1 {
+		t.Fatalf("wrong logical volume %v", lv)
+	}
+	md := lv.Slaves[0]
+	if md.Type != "raid1" || len(md.Slaves) != 2 {
+		t.Fatalf("wrong raid %v", md)
+	}
+	for i, disk := range []string{"sda", "sdb"} {
+		part := md.Slaves[i]
+		if part.Type != "partition" || part.Name != disk+"1" || len(part.Slaves) != 1 {
+			t.Fatalf("wrong partition %v", part)
+		}
+		if part.Slaves[0].Name != disk || part.Slaves[0].Type != "disk" || part.Slaves[0].Size != 3907029168*512 || len(part.Slaves[0].Slaves) != 0 {
+			t.Errorf("wrong disk %v", part.Slaves[0])
+		}
+	}
+
+	if _, err := DeviceTopology("sdz"); err == nil {
+		t.Error("expected error for missing device")
+	}
+}
+
+func TestIOCountersByGlob(t *testing.T) {
+	dir, err := common.FakeTree(map[string]string{
+		"diskstats": "   8       0 sda 100 0 800 50 200 0 1600 70 0 120 120\n" +
+			"   8       1 sda1 90 0 720 45 190 0 1520 65 0 110 110\n" +
+			"   7       0 loop0 10 0 80 1 0 0 0 0 0 1 1\n" +
+			" 259       0 nvme0n1 300 0 2400 30 400 0 3200 40 0 60 70\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("HOST_PROC", dir)
+	defer os.Unsetenv("HOST_PROC")
+
+	v, err := IOCountersByGlob("sd?")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if len(v) != 1 || v["sda"].ReadCount != 100 || v["sda"].WriteBytes != 1600*SectorSize {
+		t.Errorf("wrong counters for sd? %v", v)
+	}
+
+	v, err = IOCountersByGlob("nvme?n1")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if _, ok := v["nvme0n1"]; len(v) != 1 || !ok {
+		t.Errorf("wrong counters for nvme?n1 %v", v)
+	}
+
+	if _, err := IOCountersByGlob("sd["); err != filepath.ErrBadPattern {
+		t.Errorf("expected filepath.ErrBadPattern, got %v", err)
+	}
+}
+
+func TestIOCountersLabel(t *testing.T) {
+	dir, err := common.FakeTree(map[string]string{
+		"diskstats": "   8       0 sda 100 0 800 50 200 0 1600 70 0 120 120\n" +
+			" 253       0 dm-0 80 0 640 40 180 0 1440 60 0 100 100\n",
+		"block/dm-0/dm/name": "vg0-data\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("HOST_PROC", dir)
+	defer os.Unsetenv("HOST_PROC")
+	os.Setenv("HOST_SYS", dir)
+	defer os.Unsetenv("HOST_SYS")
+
+	v, err := IOCounters()
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if v["dm-0"].Label != "vg0-data" {
+		t.Errorf("wrong label for dm-0 %v", v["dm-0"])
+	}
+	if _, ok := v["sda"]; !ok || v["sda"].Label != "" {
+		t.Errorf("expected empty label for sda %v", v["sda"])
+	}
+}
+
+func TestPartitionsWithOptions(t *testing.T) {
+	dir, err := common.FakeTree(map[string]string{
+		"filesystems": "nodev\tproc\nnodev\ttmpfs\nnodev\toverlay\n\text4\n",
+		"mtab": `overlay / overlay rw,relatime,lowerdir=/l,upperdir=/u,workdir=/w 0 0
+proc /proc proc rw,nosuid,nodev,noexec,relatime 0 0
+tmpfs /dev tmpfs rw,nosuid,size=65536k,mode=755 0 0
+/dev/sda1 /etc/hosts ext4 rw,relatime 0 0
+/dev/sda2 /data ext4 rw,relatime 0 0
+`,
+		"self/mountinfo": `600 500 0:50 / / rw,relatime - overlay overlay rw,lowerdir=/l,upperdir=/u,workdir=/w
+601 600 0:52 / /proc rw,nosuid,nodev,noexec,relatime - proc proc rw
+602 600 0:53 / /dev rw,nosuid - tmpfs tmpfs rw,size=65536k,mode=755
+603 600 8:1 /var/lib/docker/containers/abc/hosts /etc/hosts rw,relatime - ext4 /dev/sda1 rw
+604 600 8:2 / /data rw,relatime - ext4 /dev/sda2 rw
+`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("HOST_PROC", dir)
+	defer os.Unsetenv("HOST_PROC")
+	os.Setenv("HOST_ETC", dir)
+	defer os.Unsetenv("HOST_ETC")
+
+	mountpoints := func(opts PartitionOptions) []string {
+		v, err := PartitionsWithOptions(opts)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		ret := []string{}
+		for _, p := range v {
+			ret = append(ret, p.Mountpoint)
+		}
+		return ret
+	}
+	for _, tt := range []struct {
+		opts     PartitionOptions
+		expected []string
+	}{
+		{PartitionOptions{}, []string{"/data"}},
+		{PartitionOptions{IncludeOverlay: true, IncludeBind: true}, []string{"/", "/etc/hosts", "/data"}},
+		{PartitionOptions{IncludePseudo: true}, []string{"/proc", "/dev", "/data"}},
+		{PartitionOptions{IncludePseudo: true, IncludeOverlay: true, IncludeBind: true}, []string{"/", "/proc", "/dev", "/etc/hosts", "/data"}},
+	} {
+		if v := mountpoints(tt.opts); !reflect.DeepEqual(v, tt.expected) {
+			t.Errorf("wrong mountpoints for %+v: %v, expected %v", tt.opts, v, tt.expected)
+		}
+	}
+
+	v, err := Partitions(false)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if len(v) != 2 || v[0].Mountpoint != "/etc/hosts" || v[1].Mountpoint != "/data" {
+		t.Errorf("wrong partitions %v", v)
+	}
+}
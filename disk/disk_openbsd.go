@@ -145,10 +145,13 @@ func Usage(path string) (*UsageStat, error) {
 		InodesFree:  (uint64(stat.F_ffree)),
 	}
 
-	ret.InodesUsed = (ret.InodesTotal - ret.InodesFree)
-	ret.InodesUsedPercent = (float64(ret.InodesUsed) / float64(ret.InodesTotal)) * 100.0
 	ret.Used = (uint64(stat.F_blocks) - uint64(stat.F_bfree)) * uint64(bsize)
 	ret.UsedPercent = (float64(ret.Used) / float64(ret.Total)) * 100.0
+	if ret.InodesTotal > 0 && ret.InodesTotal >= ret.InodesFree {
+		ret.InodesSupported = true
+		ret.InodesUsed = (ret.InodesTotal - ret.InodesFree)
+		ret.InodesUsedPercent = (float64(ret.InodesUsed) / float64(ret.InodesTotal)) * 100.0
+	}
 
 	return ret, nil
 }
@@ -156,3 +159,23 @@ func Usage(path string) (*UsageStat, error) {
 func getFsType(stat syscall.Statfs_t) string {
 	return common.IntToString(stat.F_fstypename[:])
 }
+
+func DiscardInfo(name string) (*DiscardInfoStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func WriteCacheMode(name string) (*WriteCacheStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func DeviceTopology(name string) (*DeviceTopologyStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func IOCountersByGlob(pattern string) (map[string]IOCountersStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func PartitionsWithOptions(opts PartitionOptions) ([]PartitionStat, error) {
+	return Partitions(opts.IncludePseudo)
+}
@@ -1,9 +1,14 @@
 package disk
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"runtime"
 	"testing"
+	"time"
+
+	"github.com/DataDog/gopsutil/internal/common"
 )
 
 func TestDisk_usage(t *testing.T) {
@@ -18,6 +23,9 @@ func TestDisk_usage(t *testing.T) {
 	if v.Path != path {
 		t.Errorf("error %v", err)
 	}
+	if v.InodesSupported != (v.InodesTotal > 0) || (runtime.GOOS == "windows" && v.InodesSupported) {
+		t.Errorf("wrong inodes support %v", v)
+	}
 }
 
 func TestDisk_partitions(t *testing.T) {
@@ -65,7 +73,7 @@ func TestDiskUsageStat_String(t *testing.T) {
 		InodesUsedPercent: 49.1,
 		Fstype:            "ext4",
 	}
-	e := `{"path":"/","fstype":"ext4","total":1000,"free":2000,"used":3000,"usedPercent":50.1,"inodesTotal":4000,"inodesUsed":5000,"inodesFree":6000,"inodesUsedPercent":49.1}`
+	e := `{"path":"/","fstype":"ext4","total":1000,"free":2000,"used":3000,"usedPercent":50.1,"inodesTotal":4000,"inodesUsed":5000,"inodesFree":6000,"inodesUsedPercent":49.1,"inodesSupported":false}`
 	if e != fmt.Sprintf("%v", v) {
 		t.Errorf("DiskUsageStat string is invalid: %v", v)
 	}
@@ -93,8 +101,139 @@ func TestDiskIOCountersStat_String(t *testing.T) {
 		WriteBytes:   400,
 		SerialNumber: "SERIAL",
 	}
-	e := `{"readCount":100,"mergedReadCount":0,"writeCount":200,"mergedWriteCount":0,"readBytes":300,"writeBytes":400,"readTime":0,"writeTime":0,"iopsInProgress":0,"ioTime":0,"weightedIO":0,"name":"sd01","serialNumber":"SERIAL"}`
+	e := `{"readCount":100,"mergedReadCount":0,"writeCount":200,"mergedWriteCount":0,"readBytes":300,"writeBytes":400,"readTime":0,"writeTime":0,"iopsInProgress":0,"ioTime":0,"weightedIO":0,"discardCount":0,"discardBytes":0,"flushCount":0,"name":"sd01","serialNumber":"SERIAL","label":""}`
 	if e != fmt.Sprintf("%v", v) {
 		t.Errorf("DiskUsageStat string is invalid: %v", v)
 	}
 }
+
+func TestDisk_usage_all(t *testing.T) {
+	ret, err := UsageAll(context.Background(), 4)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if len(ret) == 0 {
+		t.Errorf("ret is empty")
+	}
+	for mount, r := range ret {
+		if r.Err == nil && r.Usage.Path != mount {
+			t.Errorf("wrong path for %s: %v", mount, r.Usage)
+		}
+	}
+}
+
+func TestDisk_usage_timeout(t *testing.T) {
+	orig, origTimeout := usage, UsageTimeout
+	defer func() { usage, UsageTimeout = orig, origTimeout }()
+
+	hang := make(chan struct{})
+	defer close(hang)
+	usage = func(path string) (*UsageStat, error) {
+		<-hang
+		return nil, nil
+	}
+	UsageTimeout = 10 * time.Millisecond
+
+	r := usageTimeout(context.Background(), "/")
+	if r.Err != common.ErrTimeout {
+		t.Errorf("expected timeout, got %v", r.Err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r = usageTimeout(ctx, "/")
+	if r.Err != context.Canceled {
+		t.Errorf("expected canceled, got %v", r.Err)
+	}
+}
+
+func TestDiskIOCountersSampler(t *testing.T) {
+	s := IOCountersSampler{}
+	prev := map[string]IOCountersStat{
+		// the write counter was reset
+		"sda": {ReadCount: 100, WriteCount: 30, ReadBytes: 1000, WriteBytes: 0},
+	}
+	cur := map[string]IOCountersStat{
+		"sda": {ReadCount: 300, WriteCount: 10, ReadBytes: 5000, WriteBytes: 400},
+		"sdb": {ReadCount: 1},
+	}
+	v, err := s.Rate(prev, cur, 2*time.Second)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	rates := v.(map[string]IORateStat)
+	if len(rates) != 1 {
+		t.Fatalf("wrong number of devices %v", rates)
+	}
+	expected := IORateStat{Name: "sda", ReadCount: 100, WriteCount: 5, ReadBytes: 2000, WriteBytes: 200}
+	if rates["sda"] != expected {
+		t.Errorf("wrong rate %v, expected %v", rates["sda"], expected)
+	}
+}
+
+func TestParseSmartctl(t *testing.T) {
+	// smartctl -A -H -j /dev/sda, trimmed
+	ata := `{
+  "json_format_version": [1, 0],
+  "smartctl": {"version": [7, 1], "exit_status": 0},
+  "device": {"name": "/dev/sda", "info_name": "/dev/sda [SAT]", "type": "sat", "protocol": "ATA"},
+  "smart_status": {"passed": true},
+  "ata_smart_attributes": {
+    "revision": 16,
+    "table": [
+      {"id": 5, "name": "Reallocated_Sector_Ct", "value": 100, "worst": 100, "thresh": 10, "raw": {"value": 8, "string": "8"}},
+      {"id": 9, "name": "Power_On_Hours", "value": 92, "worst": 92, "thresh": 0, "raw": {"value": 36061, "string": "36061"}},
+      {"id": 194, "name": "Temperature_Celsius", "value": 66, "worst": 45, "thresh": 0, "raw": {"value": 261993005090, "string": "34 (Min/Max 20/61)"}}
+    ]
+  },
+  "power_on_time": {"hours": 36061},
+  "power_cycle_count": 1062,
+  "temperature": {"current": 34}
+}`
+	v, err := parseSmartctl([]byte(ata))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	expected := SmartStat{Health: "PASSED", ReallocatedSectors: 8, PowerOnHours: 36061, Temperature: 34}
+	if *v != expected {
+		t.Errorf("wrong smart stat %v, expected %v", v, expected)
+	}
+
+	// smartctl -A -H -j /dev/nvme0, trimmed
+	nvme := `{
+  "smartctl": {"version": [7, 2], "exit_status": 8},
+  "smart_status": {"passed": false, "nvme": {"value": 4}},
+  "nvme_smart_health_information_log": {"critical_warning": 4, "temperature": 41, "media_errors": 0},
+  "temperature": {"current": 41},
+  "power_on_time": {"hours": 1204}
+}`
+	v, err = parseSmartctl([]byte(nvme))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	expected = SmartStat{Health: "FAILED", PowerOnHours: 1204, Temperature: 41}
+	if *v != expected {
+		t.Errorf("wrong smart stat %v, expected %v", v, expected)
+	}
+
+	missing := `{
+  "smartctl": {
+    "version": [7, 1],
+    "messages": [{"string": "Smartctl open device: /dev/sdz failed: No such device", "severity": "error"}],
+    "exit_status": 2
+  }
+}`
+	if _, err := parseSmartctl([]byte(missing)); err == nil {
+		t.Error("expected an error for a missing device")
+	}
+}
+
+func TestSmartAttributesNotFound(t *testing.T) {
+	path := os.Getenv("PATH")
+	defer os.Setenv("PATH", path)
+	os.Setenv("PATH", "")
+
+	if _, err := SmartAttributes("/dev/sda"); err != ErrSmartctlNotFound {
+		t.Errorf("expected ErrSmartctlNotFound, got %v", err)
+	}
+}
@@ -21,14 +21,17 @@ func Usage(path string) (*UsageStat, error) {
 		InodesFree:  (uint64(stat.Ffree)),
 	}
 
+	ret.Used = (uint64(stat.Blocks) - uint64(stat.Bfree)) * uint64(bsize)
+	ret.UsedPercent = (float64(ret.Used) / float64(ret.Total)) * 100.0
+
 	// if could not get InodesTotal, return empty
-	if ret.InodesTotal < ret.InodesFree {
+	if ret.InodesTotal == 0 || ret.InodesTotal < ret.InodesFree {
+		ret.InodesTotal, ret.InodesFree = 0, 0
 		return ret, nil
 	}
+	ret.InodesSupported = true
 	ret.InodesUsed = (ret.InodesTotal - ret.InodesFree)
 	ret.InodesUsedPercent = (float64(ret.InodesUsed) / float64(ret.InodesTotal)) * 100.0
-	ret.Used = (uint64(stat.Blocks) - uint64(stat.Bfree)) * uint64(bsize)
-	ret.UsedPercent = (float64(ret.Used) / float64(ret.Total)) * 100.0
 
 	return ret, nil
 }
@@ -56,10 +56,8 @@ func Usage(path string) (*UsageStat, error) {
 		Free:        uint64(lpTotalNumberOfFreeBytes),
 		Used:        uint64(lpTotalNumberOfBytes) - uint64(lpTotalNumberOfFreeBytes),
 		UsedPercent: (float64(lpTotalNumberOfBytes) - float64(lpTotalNumberOfFreeBytes)) / float64(lpTotalNumberOfBytes) * 100,
-		// InodesTotal: 0,
-		// InodesFree: 0,
-		// InodesUsed: 0,
-		// InodesUsedPercent: 0,
+		// Windows has no inodes
+		InodesSupported: false,
 	}
 	return ret, nil
 }
@@ -153,3 +151,23 @@ func IOCounters() (map[string]IOCountersStat, error) {
 	}
 	return ret, nil
 }
+
+func DiscardInfo(name string) (*DiscardInfoStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func WriteCacheMode(name string) (*WriteCacheStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func DeviceTopology(name string) (*DeviceTopologyStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func IOCountersByGlob(pattern string) (map[string]IOCountersStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func PartitionsWithOptions(opts PartitionOptions) ([]PartitionStat, error) {
+	return Partitions(opts.IncludePseudo)
+}
@@ -1,6 +1,7 @@
 package docker
 
 import (
+	"encoding/json"
 	"errors"
 
 	"github.com/DataDog/gopsutil/internal/common"
@@ -8,6 +9,12 @@ import (
 
 var ErrDockerNotAvailable = errors.New("docker not available")
 var ErrCgroupNotAvailable = errors.New("cgroup not available")
+var ErrNoContainerPIDs = errors.New("container has no running process")
+var ErrContainerNotFound = errors.New("container not found")
+
+// SocketPath is the unix socket the Docker Engine API is reached through.
+// It can be overridden for daemons listening on a custom socket.
+var SocketPath = "/var/run/docker.sock"
 
 var invoke common.Invoker
 
@@ -48,12 +55,200 @@ type CgroupMemStat struct {
 	MemMaxUsageInBytes      uint64 `json:"memMaxUsageInBytes"`
 	MemLimitInBytes         uint64 `json:"memoryLimitInBbytes"`
 	MemFailCnt              uint64 `json:"memoryFailcnt"`
+	// memory+swap usage and limit, 0 without swap accounting
+	MemSwapUsageInBytes uint64 `json:"memSwapUsageInBytes"`
+	MemSwapLimitInBytes uint64 `json:"memSwapLimitInBytes"`
 }
 
 type CgroupDockerStat struct {
+	ContainerID string            `json:"containerID"`
+	Name        string            `json:"name"`
+	Image       string            `json:"image"`
+	Status      string            `json:"status"`
+	Running     bool              `json:"running"`
+	Labels      map[string]string `json:"labels"`
+}
+
+// CgroupCPUSharesStat is the relative CPU weight of a container.
+// cgroup v1 cpu.shares defaults to 1024 (range 2-262144) while cgroup v2
+// cpu.weight defaults to 100 (range 1-10000). Normalized divides the raw
+// value by the default of its version, so 1 is the default weight on both
+// and a container with Normalized 0.5 gets half the CPU of a default one
+// under contention.
+type CgroupCPUSharesStat struct {
+	ContainerID   string  `json:"containerID"`
+	CgroupVersion int     `json:"cgroupVersion"`
+	Raw           uint64  `json:"raw"` // cpu.shares on v1, cpu.weight on v2
+	Normalized    float64 `json:"normalized"`
+}
+
+func (c CgroupCPUSharesStat) String() string {
+	s, _ := json.Marshal(c)
+	return string(s)
+}
+
+// CgroupIOLimitStat is the I/O throttling configured for a container on a
+// block device. 0 means unlimited.
+type CgroupIOLimitStat struct {
+	Device    string `json:"device"` // major:minor
+	ReadBps   uint64 `json:"readBps"`
+	WriteBps  uint64 `json:"writeBps"`
+	ReadIOPS  uint64 `json:"readIops"`
+	WriteIOPS uint64 `json:"writeIops"`
+}
+
+func (c CgroupIOLimitStat) String() string {
+	s, _ := json.Marshal(c)
+	return string(s)
+}
+
+// CgroupCPUStat is the CPU throttling of a container by the CFS
+// bandwidth controller. NrThrottled growing with NrPeriods means the
+// container regularly uses all of its CPU quota.
+type CgroupCPUStat struct {
+	ContainerID   string `json:"containerID"`
+	NrPeriods     uint64 `json:"nrPeriods"`     // enforcement periods the container was runnable in
+	NrThrottled   uint64 `json:"nrThrottled"`   // periods the container was throttled in
+	ThrottledTime uint64 `json:"throttledTime"` // nanoseconds
+}
+
+func (c CgroupCPUStat) String() string {
+	s, _ := json.Marshal(c)
+	return string(s)
+}
+
+// CgroupCPUSetStat is the CPUs and NUMA memory nodes a container is
+// allowed to use.
+type CgroupCPUSetStat struct {
+	ContainerID string `json:"containerID"`
+	CPUs        []int  `json:"cpus"`
+	Mems        []int  `json:"mems"`
+}
+
+func (c CgroupCPUSetStat) String() string {
+	s, _ := json.Marshal(c)
+	return string(s)
+}
+
+// CgroupBlkioStat is the disk I/O of a container, summed over all the
+// block devices.
+type CgroupBlkioStat struct {
+	ContainerID         string `json:"containerID"`
+	IoServiceBytesRead  uint64 `json:"ioServiceBytesRead"`
+	IoServiceBytesWrite uint64 `json:"ioServiceBytesWrite"`
+	IoServicedRead      uint64 `json:"ioServicedRead"`
+	IoServicedWrite     uint64 `json:"ioServicedWrite"`
+}
+
+func (c CgroupBlkioStat) String() string {
+	s, _ := json.Marshal(c)
+	return string(s)
+}
+
+// CgroupMemHighStat is the cgroup v2 memory.high soft limit of a container
+// and the number of times its usage went over it, causing the container to
+// be throttled and its memory reclaimed.
+type CgroupMemHighStat struct {
 	ContainerID string `json:"containerID"`
-	Name        string `json:"name"`
-	Image       string `json:"image"`
-	Status      string `json:"status"`
-	Running     bool   `json:"running"`
+	High        uint64 `json:"high"`      // bytes, 0 if Unlimited
+	Unlimited   bool   `json:"unlimited"` // memory.high is "max"
+	HighEvents  uint64 `json:"highEvents"`
+}
+
+func (c CgroupMemHighStat) String() string {
+	s, _ := json.Marshal(c)
+	return string(s)
+}
+
+// CgroupOOMControlStat is the OOM killer configuration of a container. A
+// container with the OOM killer disabled is paused rather than killed when
+// it runs out of memory, UnderOOM is then true.
+type CgroupOOMControlStat struct {
+	ContainerID    string `json:"containerID"`
+	CgroupVersion  int    `json:"cgroupVersion"`
+	OOMKillDisable bool   `json:"oomKillDisable"` // cgroup v1 only
+	UnderOOM       bool   `json:"underOOM"`       // cgroup v1 only
+	// OOMGroup is true if the OOM killer kills all the processes of the
+	// container together rather than a single one, cgroup v2 only.
+	OOMGroup bool   `json:"oomGroup"`
+	OOMKill  uint64 `json:"oomKill"` // number of processes killed, 0 on kernels before 4.13
+}
+
+func (c CgroupOOMControlStat) String() string {
+	s, _ := json.Marshal(c)
+	return string(s)
+}
+
+// ContainerStateStat is the state of a container as reported by the Docker
+// Engine API inspect endpoint.
+type ContainerStateStat struct {
+	ContainerID  string `json:"containerID"`
+	Status       string `json:"status"` // ex: running, exited, restarting
+	Running      bool   `json:"running"`
+	OOMKilled    bool   `json:"oomKilled"`
+	ExitCode     int    `json:"exitCode"`
+	RestartCount int    `json:"restartCount"`
+	StartedAt    int64  `json:"startedAt"`  // seconds since the epoch, 0 if never started
+	FinishedAt   int64  `json:"finishedAt"` // seconds since the epoch, 0 if never exited
+}
+
+func (c ContainerStateStat) String() string {
+	s, _ := json.Marshal(c)
+	return string(s)
+}
+
+// ContainerStatsStat is the resource usage of a container as reported by
+// the Docker Engine API stats endpoint, equivalent to "docker stats". It
+// does not depend on the cgroup version or layout of the host.
+type ContainerStatsStat struct {
+	ContainerID    string  `json:"containerID"`
+	Name           string  `json:"name"`
+	CPUUsage       uint64  `json:"cpuUsage"`       // nanoseconds
+	SystemCPUUsage uint64  `json:"systemCpuUsage"` // nanoseconds, of the host
+	OnlineCPUs     uint32  `json:"onlineCpus"`
+	CPUPercent     float64 `json:"cpuPercent"` // 0 if the API did not report a previous sample
+	MemUsage       uint64  `json:"memUsage"`   // bytes
+	MemLimit       uint64  `json:"memLimit"`   // bytes
+	NetRxBytes     uint64  `json:"netRxBytes"` // summed over the interfaces of the container
+	NetTxBytes     uint64  `json:"netTxBytes"`
+	NetRxPackets   uint64  `json:"netRxPackets"`
+	NetTxPackets   uint64  `json:"netTxPackets"`
+	BlkioRead      uint64  `json:"blkioRead"` // bytes, summed over the block devices
+	BlkioWrite     uint64  `json:"blkioWrite"`
+	PIDs           uint64  `json:"pids"`
+}
+
+func (c ContainerStatsStat) String() string {
+	s, _ := json.Marshal(c)
+	return string(s)
+}
+
+// ContainerDiskUsageStat is the disk usage of a container as reported by
+// the Docker Engine API, equivalent to "docker ps -s".
+type ContainerDiskUsageStat struct {
+	ContainerID string `json:"containerID"`
+	SizeRw      uint64 `json:"sizeRw"`     // size of the writable layer
+	SizeRootFs  uint64 `json:"sizeRootFs"` // size of all layers, including the image
+}
+
+func (c ContainerDiskUsageStat) String() string {
+	s, _ := json.Marshal(c)
+	return string(s)
+}
+
+// CgroupControllersStat is the cgroup hierarchy of the host and the
+// controllers mounted on it. Version is "v1", "v2" for the unified
+// hierarchy only, or "hybrid" for v1 controllers along with an empty v2
+// hierarchy mounted on /sys/fs/cgroup/unified.
+type CgroupControllersStat struct {
+	Version     string   `json:"version"`
+	Controllers []string `json:"controllers"` // ex: cpu, memory, pids
+	// SubtreeControl is the v2 controllers enabled for the children of the
+	// root cgroup, the only ones containers can be accounted by.
+	SubtreeControl []string `json:"subtreeControl"`
+}
+
+func (c CgroupControllersStat) String() string {
+	s, _ := json.Marshal(c)
+	return string(s)
 }
@@ -3,39 +3,82 @@
 package docker
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	cpu "github.com/DataDog/gopsutil/cpu"
 	"github.com/DataDog/gopsutil/internal/common"
+	psnet "github.com/DataDog/gopsutil/net"
 )
 
 // GetDockerStat returns a list of Docker basic stats.
 // This requires certain permission.
 func GetDockerStat() ([]CgroupDockerStat, error) {
+	return dockerStatFromCLI(context.Background())
+}
+
+// dockerStatFromCLI returns the basic stats of all the containers, read
+// from the docker CLI, which is killed when ctx is done.
+func dockerStatFromCLI(ctx context.Context) ([]CgroupDockerStat, error) {
 	path, err := exec.LookPath("docker")
 	if err != nil {
 		return nil, ErrDockerNotAvailable
 	}
 
-	out, err := invoke.Command(path, "ps", "-a", "--no-trunc", "--format", "{{.ID}}|{{.Image}}|{{.Names}}|{{.Status}}")
+	out, err := invoke.CommandWithContext(ctx, path, "ps", "-a", "--no-trunc", "--format", dockerPSFormat)
 	if err != nil {
 		return []CgroupDockerStat{}, err
 	}
-	lines := strings.Split(string(out), "\n")
-	ret := make([]CgroupDockerStat, 0, len(lines))
 
+	return parseDockerPS(string(out)), nil
+}
+
+// GetDockerStatByID returns the Docker basic stats of a single container,
+// listed by docker ps filtered on its ID, which may be a prefix of the
+// full ID. ErrContainerNotFound is returned if no container matches.
+func GetDockerStatByID(containerID string) (CgroupDockerStat, error) {
+	path, err := exec.LookPath("docker")
+	if err != nil {
+		return CgroupDockerStat{}, ErrDockerNotAvailable
+	}
+
+	out, err := invoke.Command(path, "ps", "-a", "--no-trunc", "--filter", "id="+containerID, "--format", dockerPSFormat)
+	if err != nil {
+		return CgroupDockerStat{}, err
+	}
+	stats := parseDockerPS(string(out))
+	if len(stats) == 0 {
+		return CgroupDockerStat{}, ErrContainerNotFound
+	}
+	return stats[0], nil
+}
+
+// dockerPSFormat separates the fields of docker ps with the ASCII unit
+// separator, which unlike "|" cannot appear in a container name or image.
+const dockerPSFormat = "{{.ID}}\x1f{{.Image}}\x1f{{.Names}}\x1f{{.Status}}\x1f{{.Labels}}"
+
+// parseDockerPS parses the output of docker ps formatted with
+// dockerPSFormat.
+func parseDockerPS(out string) []CgroupDockerStat {
+	lines := strings.Split(out, "\n")
+	ret := make([]CgroupDockerStat, 0, len(lines))
 	for _, l := range lines {
 		if l == "" {
 			continue
 		}
-		cols := strings.Split(l, "|")
-		if len(cols) != 4 {
+		cols := strings.Split(l, "\x1f")
+		if len(cols) != 5 {
 			continue
 		}
 		names := strings.Split(cols[2], ",")
@@ -45,10 +88,101 @@ func GetDockerStat() ([]CgroupDockerStat, error) {
 			Image:       cols[1],
 			Status:      cols[3],
 			Running:     strings.Contains(cols[3], "Up"),
+			Labels:      parseDockerLabels(cols[4]),
 		}
 		ret = append(ret, stat)
 	}
+	return ret
+}
+
+// parseDockerLabels parses the comma separated key=value labels formatted
+// by docker ps. Commas within a double quoted value are part of it, and so
+// is a part without "=", as the CLI does not escape commas in values.
+func parseDockerLabels(s string) map[string]string {
+	ret := map[string]string{}
+	var parts []string
+	start, quoted := 0, false
+	for i, c := range s {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == ',' && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	parts = append(parts, s[start:])
 
+	key := ""
+	for _, part := range parts {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			if key != "" {
+				ret[key] += "," + part
+			}
+			continue
+		}
+		key = strings.TrimSpace(kv[0])
+		if key == "" {
+			continue
+		}
+		value := kv[1]
+		if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+			value = value[1 : len(value)-1]
+		}
+		ret[key] = value
+	}
+	return ret
+}
+
+// GetDockerStatWithContext returns a list of Docker basic stats. They are
+// read from the Docker Engine API listening on SocketPath, which does not
+// require the docker CLI, and from the CLI as GetDockerStat does if the
+// socket is not available. ctx.Err() is returned as soon as ctx is done.
+func GetDockerStatWithContext(ctx context.Context) ([]CgroupDockerStat, error) {
+	ret, err := dockerStatFromAPI(ctx)
+	if err == nil {
+		return ret, nil
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return dockerStatFromCLI(ctx)
+}
+
+// dockerStatFromAPI returns the basic stats of all the containers, read
+// from the Docker Engine API.
+func dockerStatFromAPI(ctx context.Context) ([]CgroupDockerStat, error) {
+	var containers []struct {
+		ID     string            `json:"Id"`
+		Names  []string          `json:"Names"`
+		Image  string            `json:"Image"`
+		State  string            `json:"State"`
+		Status string            `json:"Status"`
+		Labels map[string]string `json:"Labels"`
+	}
+	err := dockerAPIGetWithContext(ctx, "/containers/json?all=1", &containers)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]CgroupDockerStat, 0, len(containers))
+	for _, c := range containers {
+		stat := CgroupDockerStat{
+			ContainerID: c.ID,
+			Image:       c.Image,
+			Status:      c.Status,
+			Running:     c.State == "running",
+			Labels:      c.Labels,
+		}
+		if len(c.Names) > 0 {
+			// names are reported with a leading slash, unlike the CLI
+			stat.Name = strings.TrimPrefix(c.Names[0], "/")
+		}
+		if stat.Labels == nil {
+			stat.Labels = map[string]string{}
+		}
+		ret = append(ret, stat)
+	}
 	return ret, nil
 }
 
@@ -60,12 +194,18 @@ func (c CgroupDockerStat) String() string {
 // GetDockerIDList returnes a list of DockerID.
 // This requires certain permission.
 func GetDockerIDList() ([]string, error) {
+	return GetDockerIDListWithContext(context.Background())
+}
+
+// GetDockerIDListWithContext returns a list of DockerID, killing the
+// docker CLI and returning ctx.Err() as soon as ctx is done.
+func GetDockerIDListWithContext(ctx context.Context) ([]string, error) {
 	path, err := exec.LookPath("docker")
 	if err != nil {
 		return nil, ErrDockerNotAvailable
 	}
 
-	out, err := invoke.Command(path, "ps", "-q", "--no-trunc")
+	out, err := invoke.CommandWithContext(ctx, path, "ps", "-q", "--no-trunc")
 	if err != nil {
 		return []string{}, err
 	}
@@ -82,6 +222,189 @@ func GetDockerIDList() ([]string, error) {
 	return ret, nil
 }
 
+// ContainerDiskUsage returns the size of the writable layer and of the root
+// filesystem of a container, read from the Docker Engine API size fields.
+func ContainerDiskUsage(containerID string) (*ContainerDiskUsageStat, error) {
+	var inspect struct {
+		ID         string `json:"Id"`
+		SizeRw     uint64 `json:"SizeRw"`
+		SizeRootFs uint64 `json:"SizeRootFs"`
+	}
+	err := dockerAPIGet("/containers/"+containerID+"/json?size=true", &inspect)
+	if err != nil {
+		return nil, err
+	}
+	return &ContainerDiskUsageStat{
+		ContainerID: inspect.ID,
+		SizeRw:      inspect.SizeRw,
+		SizeRootFs:  inspect.SizeRootFs,
+	}, nil
+}
+
+// GetContainerState returns the state, exit code and restart count of a
+// container, read from the Docker Engine API.
+func GetContainerState(containerID string) (*ContainerStateStat, error) {
+	var inspect struct {
+		ID           string `json:"Id"`
+		RestartCount int    `json:"RestartCount"`
+		State        struct {
+			Status     string `json:"Status"`
+			Running    bool   `json:"Running"`
+			OOMKilled  bool   `json:"OOMKilled"`
+			ExitCode   int    `json:"ExitCode"`
+			StartedAt  string `json:"StartedAt"`
+			FinishedAt string `json:"FinishedAt"`
+		} `json:"State"`
+	}
+	err := dockerAPIGet("/containers/"+containerID+"/json", &inspect)
+	if err != nil {
+		return nil, err
+	}
+	return &ContainerStateStat{
+		ContainerID:  inspect.ID,
+		Status:       inspect.State.Status,
+		Running:      inspect.State.Running,
+		OOMKilled:    inspect.State.OOMKilled,
+		ExitCode:     inspect.State.ExitCode,
+		RestartCount: inspect.RestartCount,
+		StartedAt:    parseDockerTime(inspect.State.StartedAt),
+		FinishedAt:   parseDockerTime(inspect.State.FinishedAt),
+	}, nil
+}
+
+// GetContainerStatsAPI returns the resource usage of a container read
+// from the Docker Engine API stats endpoint. With stream false, the daemon
+// samples the container twice before replying, which takes about a second
+// but fills CPUPercent. With stream true, the first sample of the stream
+// is returned.
+func GetContainerStatsAPI(containerID string, stream bool) (*ContainerStatsStat, error) {
+	if containerID == "" {
+		return nil, fmt.Errorf("empty container id")
+	}
+	type cpuStats struct {
+		CPUUsage struct {
+			TotalUsage uint64 `json:"total_usage"`
+		} `json:"cpu_usage"`
+		SystemCPUUsage uint64 `json:"system_cpu_usage"`
+		OnlineCPUs     uint32 `json:"online_cpus"`
+	}
+	var stats struct {
+		ID          string   `json:"id"`
+		Name        string   `json:"name"`
+		CPUStats    cpuStats `json:"cpu_stats"`
+		PreCPUStats cpuStats `json:"precpu_stats"`
+		MemoryStats struct {
+			Usage uint64 `json:"usage"`
+			Limit uint64 `json:"limit"`
+		} `json:"memory_stats"`
+		Networks map[string]struct {
+			RxBytes   uint64 `json:"rx_bytes"`
+			TxBytes   uint64 `json:"tx_bytes"`
+			RxPackets uint64 `json:"rx_packets"`
+			TxPackets uint64 `json:"tx_packets"`
+		} `json:"networks"`
+		BlkioStats struct {
+			IoServiceBytesRecursive []struct {
+				Op    string `json:"op"`
+				Value uint64 `json:"value"`
+			} `json:"io_service_bytes_recursive"`
+		} `json:"blkio_stats"`
+		PidsStats struct {
+			Current uint64 `json:"current"`
+		} `json:"pids_stats"`
+	}
+	// the stream is closed once the first sample is decoded
+	err := dockerAPIGet("/containers/"+url.PathEscape(containerID)+"/stats?stream="+strconv.FormatBool(stream), &stats)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &ContainerStatsStat{
+		ContainerID:    stats.ID,
+		Name:           strings.TrimPrefix(stats.Name, "/"),
+		CPUUsage:       stats.CPUStats.CPUUsage.TotalUsage,
+		SystemCPUUsage: stats.CPUStats.SystemCPUUsage,
+		OnlineCPUs:     stats.CPUStats.OnlineCPUs,
+		MemUsage:       stats.MemoryStats.Usage,
+		MemLimit:       stats.MemoryStats.Limit,
+		PIDs:           stats.PidsStats.Current,
+	}
+	// computed as docker stats does
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemCPUUsage) - float64(stats.PreCPUStats.SystemCPUUsage)
+	if stats.PreCPUStats.SystemCPUUsage > 0 && cpuDelta > 0 && systemDelta > 0 {
+		ret.CPUPercent = cpuDelta / systemDelta * float64(stats.CPUStats.OnlineCPUs) * 100
+	}
+	for _, n := range stats.Networks {
+		ret.NetRxBytes += n.RxBytes
+		ret.NetTxBytes += n.TxBytes
+		ret.NetRxPackets += n.RxPackets
+		ret.NetTxPackets += n.TxPackets
+	}
+	for _, b := range stats.BlkioStats.IoServiceBytesRecursive {
+		// "Read" on cgroup v1, "read" on cgroup v2
+		switch strings.ToLower(b.Op) {
+		case "read":
+			ret.BlkioRead += b.Value
+		case "write":
+			ret.BlkioWrite += b.Value
+		}
+	}
+	return ret, nil
+}
+
+// parseDockerTime converts an API timestamp to seconds since the epoch.
+// Docker reports "0001-01-01T00:00:00Z" for events which never happened,
+// which is returned as 0.
+func parseDockerTime(s string) int64 {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil || t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// dockerAPITransport is shared by all the Docker Engine API requests. Keep
+// alives are disabled so no idle connection is left open between calls,
+// which may be minutes apart, and a change of SocketPath takes effect on
+// the next request.
+var dockerAPITransport = &http.Transport{
+	DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "unix", SocketPath)
+	},
+	DisableKeepAlives: true,
+}
+
+// dockerAPIGet requests path from the Docker Engine API listening on
+// SocketPath and decodes the JSON response into v.
+func dockerAPIGet(path string, v interface{}) error {
+	return dockerAPIGetWithContext(context.Background(), path, v)
+}
+
+func dockerAPIGetWithContext(ctx context.Context, path string, v interface{}) error {
+	if !common.PathExists(SocketPath) {
+		return ErrDockerNotAvailable
+	}
+	client := &http.Client{
+		Timeout:   common.Timeout,
+		Transport: dockerAPITransport,
+	}
+	req, err := http.NewRequest("GET", "http://docker"+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("docker API %s returned %s", path, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
 // CgroupCPU returnes specified cgroup id CPU status.
 // containerID is same as docker id if you use docker.
 // If you use container via systemd.slice, you could use
@@ -120,6 +443,503 @@ func CgroupCPUDocker(containerid string) (*cpu.TimesStat, error) {
 	return CgroupCPU(containerid, common.HostSys("fs/cgroup/cpuacct/docker"))
 }
 
+// CgroupCPUUsage returns the CPU throttling of a container, read from the
+// cpu.stat file of the cpu controller. The stat is zero if the file does
+// not exist, which is the case when no CPU quota was ever configured.
+func CgroupCPUUsage(containerID string, base string) (*CgroupCPUStat, error) {
+	ret := &CgroupCPUStat{ContainerID: containerID}
+	statfile := getCgroupFilePath(containerID, base, "cpu", "cpu.stat")
+	if !common.PathExists(statfile) {
+		statfile = getCgroupV2FilePath(containerID, base, "cpu.stat")
+	}
+	if !common.PathExists(statfile) {
+		return ret, nil
+	}
+	stat, err := common.ReadCgroupStat(statfile)
+	if err != nil {
+		return nil, err
+	}
+	ret.NrPeriods = stat["nr_periods"]
+	ret.NrThrottled = stat["nr_throttled"]
+	ret.ThrottledTime = stat["throttled_time"]
+	if v, ok := stat["throttled_usec"]; ok {
+		// cgroup v2
+		ret.ThrottledTime = v * 1000
+	}
+	return ret, nil
+}
+
+func CgroupCPUUsageDocker(containerID string) (*CgroupCPUStat, error) {
+	return CgroupCPUUsage(containerID, common.HostSys("fs/cgroup/cpu/docker"))
+}
+
+const (
+	defaultCPUShares = 1024 // cgroup v1 cpu.shares
+	defaultCPUWeight = 100  // cgroup v2 cpu.weight
+)
+
+// CgroupPIDs returns the pids of the processes of a container, read from
+// cgroup.procs.
+func CgroupPIDs(containerID string, base string) ([]int32, error) {
+	statfile := getCgroupFilePath(containerID, base, "cpuacct", "cgroup.procs")
+	if !common.PathExists(statfile) {
+		statfile = getCgroupV2FilePath(containerID, base, "cgroup.procs")
+	}
+	lines, err := common.ReadLines(statfile)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]int32, 0, len(lines))
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		pid, err := strconv.ParseInt(line, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, int32(pid))
+	}
+	return ret, nil
+}
+
+func CgroupPIDsDocker(containerID string) ([]int32, error) {
+	return CgroupPIDs(containerID, common.HostSys("fs/cgroup/cpuacct/docker"))
+}
+
+// CgroupNetDocker returns the network I/O counters of each interface of a
+// container, read from /proc/(pid)/net/dev of one of its processes, which
+// shows the interfaces of the network namespace of the container.
+// ErrNoContainerPIDs is returned with an empty slice if the container has
+// no running process.
+func CgroupNetDocker(containerID string) ([]psnet.IOCountersStat, error) {
+	pids, err := CgroupPIDsDocker(containerID)
+	if err != nil {
+		return nil, err
+	}
+	if len(pids) == 0 {
+		return []psnet.IOCountersStat{}, ErrNoContainerPIDs
+	}
+	return psnet.IOCountersByFile(true, common.HostProc(strconv.Itoa(int(pids[0])), "net/dev"))
+}
+
+// AvailableControllers returns the cgroup hierarchy version of the host
+// and the controllers which are mounted and enabled, read from
+// /proc/cgroups on v1 and from cgroup.controllers on v2.
+func AvailableControllers() (*CgroupControllersStat, error) {
+	ret := &CgroupControllersStat{
+		Controllers:    []string{},
+		SubtreeControl: []string{},
+	}
+	unified := common.HostSys("fs/cgroup")
+	switch {
+	case common.PathExists(common.HostSys("fs/cgroup/cgroup.controllers")):
+		ret.Version = "v2"
+	case common.PathExists(common.HostSys("fs/cgroup/unified/cgroup.controllers")):
+		ret.Version = "hybrid"
+		unified = common.HostSys("fs/cgroup/unified")
+	default:
+		ret.Version = "v1"
+	}
+
+	if ret.Version != "v2" {
+		lines, err := common.ReadLines(common.HostProc("cgroups"))
+		if err != nil {
+			return nil, ErrCgroupNotAvailable
+		}
+		for _, line := range lines {
+			// subsys_name hierarchy num_cgroups enabled
+			fields := strings.Fields(line)
+			if len(fields) != 4 || strings.HasPrefix(fields[0], "#") {
+				continue
+			}
+			// controllers of hierarchy 0 are not mounted on v1
+			if fields[1] != "0" && fields[3] == "1" {
+				ret.Controllers = append(ret.Controllers, fields[0])
+			}
+		}
+	}
+	if ret.Version != "v1" {
+		controllers, err := readCgroupList(path.Join(unified, "cgroup.controllers"))
+		if err != nil {
+			return nil, err
+		}
+		ret.Controllers = append(ret.Controllers, controllers...)
+		ret.SubtreeControl, err = readCgroupList(path.Join(unified, "cgroup.subtree_control"))
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(ret.Controllers)
+	return ret, nil
+}
+
+// readCgroupList reads a space separated list of controllers, such as
+// cgroup.controllers.
+func readCgroupList(filename string) ([]string, error) {
+	lines, err := common.ReadLines(filename)
+	if err != nil {
+		return nil, err
+	}
+	ret := []string{}
+	for _, line := range lines {
+		ret = append(ret, strings.Fields(line)...)
+	}
+	sort.Strings(ret)
+	return ret, nil
+}
+
+// CgroupCPUShares returns the CPU weight of a container, read from
+// cpu.shares on cgroup v1 or cpu.weight on cgroup v2.
+func CgroupCPUShares(containerID string, base string) (*CgroupCPUSharesStat, error) {
+	ret := &CgroupCPUSharesStat{ContainerID: containerID}
+
+	statfile := getCgroupFilePath(containerID, base, "cpu", "cpu.shares")
+	if common.PathExists(statfile) {
+		v, err := readCgroupUint(statfile)
+		if err != nil {
+			return nil, err
+		}
+		ret.CgroupVersion = 1
+		ret.Raw = v
+		ret.Normalized = float64(v) / defaultCPUShares
+		return ret, nil
+	}
+
+	v, err := readCgroupUint(getCgroupV2FilePath(containerID, base, "cpu.weight"))
+	if err != nil {
+		return nil, err
+	}
+	ret.CgroupVersion = 2
+	ret.Raw = v
+	ret.Normalized = float64(v) / defaultCPUWeight
+	return ret, nil
+}
+
+// CgroupIOLimits returns the I/O limits of a container keyed by device,
+// read from the blkio.throttle.*_device files on cgroup v1 or io.max on
+// cgroup v2. An empty map is returned if no limit is set.
+func CgroupIOLimits(containerID string, base string) (map[string]CgroupIOLimitStat, error) {
+	ret := make(map[string]CgroupIOLimitStat)
+
+	if common.PathExists(getCgroupFilePath(containerID, base, "blkio", "blkio.throttle.read_bps_device")) {
+		for _, limit := range []string{"read_bps", "write_bps", "read_iops", "write_iops"} {
+			lines, err := common.ReadLines(getCgroupFilePath(containerID, base, "blkio", "blkio.throttle."+limit+"_device"))
+			if err != nil {
+				return nil, err
+			}
+			for _, line := range lines {
+				fields := strings.Fields(line)
+				if len(fields) != 2 {
+					continue
+				}
+				v, err := strconv.ParseUint(fields[1], 10, 64)
+				if err != nil {
+					return nil, err
+				}
+				l := ret[fields[0]]
+				l.Device = fields[0]
+				switch limit {
+				case "read_bps":
+					l.ReadBps = v
+				case "write_bps":
+					l.WriteBps = v
+				case "read_iops":
+					l.ReadIOPS = v
+				case "write_iops":
+					l.WriteIOPS = v
+				}
+				ret[fields[0]] = l
+			}
+		}
+		return ret, nil
+	}
+
+	statfile := getCgroupV2FilePath(containerID, base, "io.max")
+	if !common.PathExists(statfile) && common.PathExists(getCgroupV2FilePath(containerID, base, "")) {
+		// io.max only exists when the io controller is enabled for the
+		// cgroup, without it no limit is set
+		return ret, nil
+	}
+	lines, err := common.ReadLines(statfile)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range lines {
+		l, err := parseIOMax(line)
+		if err != nil {
+			return nil, err
+		}
+		if l != nil {
+			ret[l.Device] = *l
+		}
+	}
+	return ret, nil
+}
+
+// CgroupCPUSet returns the CPUs and NUMA memory nodes a container is
+// allowed to use, read from cpuset.cpus and cpuset.mems on cgroup v1, or
+// cpuset.cpus.effective and cpuset.mems.effective on cgroup v2. On cgroup
+// v2 an empty cpuset means the set of the parent is inherited, so the
+// effective files hold the actual set.
+func CgroupCPUSet(containerID string, base string) (*CgroupCPUSetStat, error) {
+	ret := &CgroupCPUSetStat{ContainerID: containerID}
+
+	// cgroup v2 has cpuset.cpus too, but cpuset.cpus.effective only exists
+	// there, v1 names it cpuset.effective_cpus
+	statfile := getCgroupFilePath(containerID, base, "cpuset", "cpuset.cpus")
+	if common.PathExists(statfile) && !common.PathExists(getCgroupV2FilePath(containerID, base, "cpuset.cpus.effective")) {
+		var err error
+		ret.CPUs, err = readCgroupCPUList(statfile)
+		if err != nil {
+			return nil, err
+		}
+		ret.Mems, err = readCgroupCPUList(getCgroupFilePath(containerID, base, "cpuset", "cpuset.mems"))
+		if err != nil {
+			return nil, err
+		}
+		return ret, nil
+	}
+
+	for _, f := range []struct {
+		name  string
+		value *[]int
+	}{
+		{"cpuset.cpus.effective", &ret.CPUs},
+		{"cpuset.mems.effective", &ret.Mems},
+	} {
+		statfile := getCgroupV2FilePath(containerID, base, f.name)
+		if !common.PathExists(statfile) {
+			// the cpuset controller is not enabled for the container, it
+			// may use every CPU and node of the root cgroup
+			statfile = common.HostSys("fs/cgroup", f.name)
+		}
+		v, err := readCgroupCPUList(statfile)
+		if err != nil {
+			return nil, err
+		}
+		*f.value = v
+	}
+	return ret, nil
+}
+
+// readCgroupCPUList reads a cgroup file in the cpulist format, ex: 0-3,8.
+func readCgroupCPUList(statfile string) ([]int, error) {
+	lines, err := common.ReadLines(statfile)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return []int{}, nil
+	}
+	return common.ParseCPUList(lines[0])
+}
+
+// CgroupBlkio returns the bytes and operations read and written by a
+// container, read from blkio.throttle.io_service_bytes and
+// blkio.throttle.io_serviced of the cgroup v1 blkio controller.
+// ErrCgroupNotAvailable is returned if the blkio controller is not
+// mounted.
+func CgroupBlkio(containerID string, base string) (*CgroupBlkioStat, error) {
+	statfile := getCgroupFilePath(containerID, base, "blkio", "blkio.throttle.io_service_bytes")
+	if !common.PathExists(statfile) {
+		return nil, ErrCgroupNotAvailable
+	}
+	ret := &CgroupBlkioStat{ContainerID: containerID}
+	read, write, err := readBlkioStat(statfile)
+	if err != nil {
+		return nil, err
+	}
+	ret.IoServiceBytesRead, ret.IoServiceBytesWrite = read, write
+
+	read, write, err = readBlkioStat(getCgroupFilePath(containerID, base, "blkio", "blkio.throttle.io_serviced"))
+	if err != nil {
+		return nil, err
+	}
+	ret.IoServicedRead, ret.IoServicedWrite = read, write
+	return ret, nil
+}
+
+func CgroupBlkioDocker(containerID string) (*CgroupBlkioStat, error) {
+	return CgroupBlkio(containerID, common.HostSys("fs/cgroup/blkio/docker"))
+}
+
+// readBlkioStat sums the Read and Write lines of a blkio stats file
+// over all the devices, ex: "8:0 Read 1024". The per device and the
+// overall Total lines are skipped as they would be counted twice.
+func readBlkioStat(statfile string) (read uint64, write uint64, err error) {
+	lines, err := common.ReadLines(statfile)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		switch fields[1] {
+		case "Read":
+			v, err := strconv.ParseUint(fields[2], 10, 64)
+			if err != nil {
+				return 0, 0, err
+			}
+			read += v
+		case "Write":
+			v, err := strconv.ParseUint(fields[2], 10, 64)
+			if err != nil {
+				return 0, 0, err
+			}
+			write += v
+		}
+	}
+	return read, write, nil
+}
+
+// CgroupMemHigh returns the memory.high soft limit of a container and the
+// "high" count of its memory.events. ErrNotImplementedError is returned on
+// cgroup v1, which has no memory.high.
+func CgroupMemHigh(containerID string, base string) (*CgroupMemHighStat, error) {
+	if common.PathExists(getCgroupFilePath(containerID, base, "memory", "memory.usage_in_bytes")) {
+		return nil, common.ErrNotImplementedError
+	}
+	ret := &CgroupMemHighStat{ContainerID: containerID}
+
+	lines, err := common.ReadLines(getCgroupV2FilePath(containerID, base, "memory.high"))
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) != 1 {
+		return nil, fmt.Errorf("wrong format file: memory.high")
+	}
+	if lines[0] == "max" {
+		ret.Unlimited = true
+	} else {
+		ret.High, err = strconv.ParseUint(lines[0], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	lines, err = common.ReadLines(getCgroupV2FilePath(containerID, base, "memory.events"))
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "high" {
+			ret.HighEvents, err = strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return ret, nil
+}
+
+// CgroupMemHighDocker returns the memory.high soft limit of a Docker
+// container. memory.high only exists in the cgroup v2 hierarchy, which the
+// default base, /sys/fs/cgroup/docker, points to.
+func CgroupMemHighDocker(containerID string) (*CgroupMemHighStat, error) {
+	return CgroupMemHigh(containerID, "")
+}
+
+// CgroupOOMControl returns the OOM killer configuration of a container,
+// read from memory.oom_control on cgroup v1, or memory.oom.group and
+// memory.events on cgroup v2.
+func CgroupOOMControl(containerID string, base string) (*CgroupOOMControlStat, error) {
+	ret := &CgroupOOMControlStat{ContainerID: containerID}
+
+	statfile := getCgroupFilePath(containerID, base, "memory", "memory.oom_control")
+	if common.PathExists(statfile) {
+		lines, err := common.ReadLines(statfile)
+		if err != nil {
+			return nil, err
+		}
+		ret.CgroupVersion = 1
+		for _, line := range lines {
+			fields := strings.Fields(line)
+			if len(fields) != 2 {
+				continue
+			}
+			v, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			switch fields[0] {
+			case "oom_kill_disable":
+				ret.OOMKillDisable = v == 1
+			case "under_oom":
+				ret.UnderOOM = v == 1
+			case "oom_kill":
+				ret.OOMKill = v
+			}
+		}
+		return ret, nil
+	}
+
+	group, err := readCgroupUint(getCgroupV2FilePath(containerID, base, "memory.oom.group"))
+	if err != nil {
+		return nil, err
+	}
+	ret.CgroupVersion = 2
+	ret.OOMGroup = group == 1
+
+	lines, err := common.ReadLines(getCgroupV2FilePath(containerID, base, "memory.events"))
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "oom_kill" {
+			ret.OOMKill, err = strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return ret, nil
+}
+
+// CgroupOOMControlDocker returns the OOM killer configuration of a Docker
+// container, looked up in the default cgroup v1 and v2 hierarchies.
+func CgroupOOMControlDocker(containerID string) (*CgroupOOMControlStat, error) {
+	return CgroupOOMControl(containerID, "")
+}
+
+// parseIOMax parses a cgroup v2 io.max line, ex:
+// "8:16 rbps=2097152 wbps=max riops=max wiops=120".
+func parseIOMax(line string) (*CgroupIOLimitStat, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	ret := &CgroupIOLimitStat{Device: fields[0]}
+	for _, field := range fields[1:] {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("wrong io.max format: %s", line)
+		}
+		if kv[1] == "max" {
+			continue
+		}
+		v, err := strconv.ParseUint(kv[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		switch kv[0] {
+		case "rbps":
+			ret.ReadBps = v
+		case "wbps":
+			ret.WriteBps = v
+		case "riops":
+			ret.ReadIOPS = v
+		case "wiops":
+			ret.WriteIOPS = v
+		}
+	}
+	return ret, nil
+}
+
 func CgroupMem(containerID string, base string) (*CgroupMemStat, error) {
 	statfile := getCgroupFilePath(containerID, base, "memory", "memory.stat")
 
@@ -204,14 +1024,23 @@ func CgroupMem(containerID string, base string) (*CgroupMemStat, error) {
 	if err == nil {
 		ret.MemMaxUsageInBytes = r
 	}
-	r, err = getCgroupMemFile(containerID, base, "memoryLimitInBbytes")
+	r, err = getCgroupMemFile(containerID, base, "memory.limit_in_bytes")
 	if err == nil {
 		ret.MemLimitInBytes = r
 	}
-	r, err = getCgroupMemFile(containerID, base, "memoryFailcnt")
+	r, err = getCgroupMemFile(containerID, base, "memory.failcnt")
 	if err == nil {
 		ret.MemFailCnt = r
 	}
+	// the memsw files only exist with swap accounting enabled
+	r, err = getCgroupMemFile(containerID, base, "memory.memsw.usage_in_bytes")
+	if err == nil {
+		ret.MemSwapUsageInBytes = r
+	}
+	r, err = getCgroupMemFile(containerID, base, "memory.memsw.limit_in_bytes")
+	if err == nil {
+		ret.MemSwapLimitInBytes = r
+	}
 
 	return ret, nil
 }
@@ -240,9 +1069,29 @@ func getCgroupFilePath(containerID, base, target, file string) string {
 	return statfile
 }
 
+// getCgroupV2FilePath constructs the path of a stats file in the cgroup v2
+// unified hierarchy, for both the systemd and the cgroupfs cgroup drivers.
+func getCgroupV2FilePath(containerID, base, file string) string {
+	if len(base) == 0 {
+		base = common.HostSys("fs/cgroup/docker")
+	}
+	statfile := path.Join(base, containerID, file)
+
+	if _, err := os.Stat(statfile); os.IsNotExist(err) {
+		statfile = path.Join(
+			common.HostSys("fs/cgroup/system.slice"), "docker-"+containerID+".scope", file)
+	}
+
+	return statfile
+}
+
 // getCgroupMemFile reads a cgroup file and return the contents as uint64.
 func getCgroupMemFile(containerID, base, file string) (uint64, error) {
-	statfile := getCgroupFilePath(containerID, base, "memory", file)
+	return readCgroupUint(getCgroupFilePath(containerID, base, "memory", file))
+}
+
+// readCgroupUint reads a single value cgroup file.
+func readCgroupUint(statfile string) (uint64, error) {
 	lines, err := common.ReadLines(statfile)
 	if err != nil {
 		return 0, err
@@ -2,7 +2,19 @@
 
 package docker
 
-import "testing"
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/DataDog/gopsutil/internal/common"
+)
 
 func TestGetDockerIDList(t *testing.T) {
 	// If there is not docker environment, this test always fail.
@@ -29,7 +41,7 @@ func TestGetDockerStat(t *testing.T) {
 		}
 		empty := CgroupDockerStat{}
 		for _, v := range ret {
-			if empty == v {
+			if reflect.DeepEqual(empty, v) {
 				t.Errorf("empty CgroupDockerStat")
 			}
 			if v.ContainerID == "" {
@@ -74,9 +86,694 @@ func TestCgroupMem(t *testing.T) {
 	}
 }
 
+func TestCgroupMemLimit(t *testing.T) {
+	dir, err := common.FakeTree(map[string]string{
+		"abc/memory.stat":           "cache 4096\nrss 8192\n",
+		"abc/memory.limit_in_bytes": "536870912\n",
+		"abc/memory.failcnt":        "3\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	v, err := CgroupMem("abc", dir)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if v.MemLimitInBytes != 536870912 || v.MemFailCnt != 3 {
+		t.Errorf("wrong memory limit and failcnt %v", v)
+	}
+}
+
+func TestCgroupMemSwap(t *testing.T) {
+	dir, err := common.FakeTree(map[string]string{
+		"abc/memory.stat":           "cache 4096\nrss 8192\n",
+		"abc/memory.usage_in_bytes": "12288\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	v, err := CgroupMem("abc", dir)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if v.MemUsageInBytes != 12288 || v.MemSwapUsageInBytes != 0 || v.MemSwapLimitInBytes != 0 {
+		t.Errorf("wrong memory without swap accounting %v", v)
+	}
+
+	if err := common.WriteTree(dir, map[string]string{
+		"abc/memory.memsw.usage_in_bytes": "16384\n",
+		"abc/memory.memsw.limit_in_bytes": "1073741824\n",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	v, err = CgroupMem("abc", dir)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if v.MemSwapUsageInBytes != 16384 || v.MemSwapLimitInBytes != 1073741824 {
+		t.Errorf("wrong memory+swap %v", v)
+	}
+}
+
 func TestCgroupMemInvalidId(t *testing.T) {
 	_, err := CgroupMemDocker("bad id")
 	if err == nil {
 		t.Error("Expected path does not exist error")
 	}
 }
+
+// newFakeDockerAPI serves handler on a temporary unix socket and points
+// SocketPath to it. The returned function restores SocketPath.
+func newFakeDockerAPI(t *testing.T, handler http.HandlerFunc) func() {
+	dir, err := ioutil.TempDir("", "gopsutil-docker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sock := filepath.Join(dir, "docker.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go http.Serve(l, handler)
+
+	orig := SocketPath
+	SocketPath = sock
+	return func() {
+		SocketPath = orig
+		l.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestContainerDiskUsage(t *testing.T) {
+	defer newFakeDockerAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/containers/abc/json" || r.URL.Query().Get("size") != "true" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"Id":"abc123","SizeRw":1024,"SizeRootFs":4096}`)
+	})()
+
+	v, err := ContainerDiskUsage("abc")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if v.ContainerID != "abc123" || v.SizeRw != 1024 || v.SizeRootFs != 4096 {
+		t.Errorf("wrong container disk usage %v", v)
+	}
+
+	if _, err := ContainerDiskUsage("missing"); err == nil {
+		t.Error("Expected error for unknown container")
+	}
+}
+
+func TestCgroupCPUShares(t *testing.T) {
+	dir, err := common.FakeTree(map[string]string{"v1/abc/cpu.shares": "512\n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	v, err := CgroupCPUShares("abc", filepath.Join(dir, "v1"))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if v.CgroupVersion != 1 || v.Raw != 512 || v.Normalized != 0.5 {
+		t.Errorf("wrong cgroup v1 cpu shares %v", v)
+	}
+
+	if err := common.WriteTree(dir, map[string]string{"fs/cgroup/system.slice/docker-abc.scope/cpu.weight": "200\n"}); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("HOST_SYS", dir)
+	defer os.Unsetenv("HOST_SYS")
+	v, err = CgroupCPUShares("abc", "")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if v.CgroupVersion != 2 || v.Raw != 200 || v.Normalized != 2 {
+		t.Errorf("wrong cgroup v2 cpu weight %v", v)
+	}
+}
+
+func TestGetContainerState(t *testing.T) {
+	defer newFakeDockerAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/containers/oom/json":
+			fmt.Fprint(w, `{"Id":"oom123","RestartCount":7,"State":{"Status":"restarting","Running":false,"OOMKilled":true,"ExitCode":137,"StartedAt":"2020-11-16T10:00:00.123456789Z","FinishedAt":"2020-11-16T10:05:00Z"}}`)
+		case "/containers/up/json":
+			fmt.Fprint(w, `{"Id":"up123","RestartCount":0,"State":{"Status":"running","Running":true,"OOMKilled":false,"ExitCode":0,"StartedAt":"2020-11-16T10:00:00Z","FinishedAt":"0001-01-01T00:00:00Z"}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	})()
+
+	v, err := GetContainerState("oom")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if !v.OOMKilled || v.ExitCode != 137 || v.RestartCount != 7 || v.Status != "restarting" {
+		t.Errorf("wrong container state %v", v)
+	}
+	if v.StartedAt != 1605520800 || v.FinishedAt != 1605521100 {
+		t.Errorf("wrong container timestamps %v", v)
+	}
+
+	v, err = GetContainerState("up")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if !v.Running || v.FinishedAt != 0 {
+		t.Errorf("wrong running container state %v", v)
+	}
+}
+
+func TestGetContainerStatsAPI(t *testing.T) {
+	defer newFakeDockerAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		if (r.URL.Path != "/containers/web/stats" && r.URL.Path != "/containers/web?all/stats") || r.URL.Query().Get("stream") != "false" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"id":"abc123","name":"/web",`+
+			`"cpu_stats":{"cpu_usage":{"total_usage":3000000000},"system_cpu_usage":120000000000,"online_cpus":4},`+
+			`"precpu_stats":{"cpu_usage":{"total_usage":2000000000},"system_cpu_usage":116000000000,"online_cpus":4},`+
+			`"memory_stats":{"usage":104857600,"limit":536870912},`+
+			`"networks":{"eth0":{"rx_bytes":1000,"tx_bytes":2000,"rx_packets":10,"tx_packets":20},"eth1":{"rx_bytes":500,"tx_bytes":0,"rx_packets":5,"tx_packets":0}},`+
+			`"blkio_stats":{"io_service_bytes_recursive":[{"major":8,"minor":0,"op":"read","value":4096},{"major":8,"minor":0,"op":"write","value":8192}]},`+
+			`"pids_stats":{"current":12}}`)
+	})()
+
+	v, err := GetContainerStatsAPI("web", false)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	expected := ContainerStatsStat{
+		ContainerID:    "abc123",
+		Name:           "web",
+		CPUUsage:       3000000000,
+		SystemCPUUsage: 120000000000,
+		OnlineCPUs:     4,
+		CPUPercent:     100,
+		MemUsage:       104857600,
+		MemLimit:       536870912,
+		NetRxBytes:     1500,
+		NetTxBytes:     2000,
+		NetRxPackets:   15,
+		NetTxPackets:   20,
+		BlkioRead:      4096,
+		BlkioWrite:     8192,
+		PIDs:           12,
+	}
+	if *v != expected {
+		t.Errorf("wrong container stats %v", v)
+	}
+
+	// the id is escaped in the path
+	if _, err := GetContainerStatsAPI("web?all", false); err != nil {
+		t.Errorf("error %v", err)
+	}
+	if _, err := GetContainerStatsAPI("", false); err == nil {
+		t.Error("expected error for an empty id")
+	}
+	if _, err := GetContainerStatsAPI("missing", false); err == nil {
+		t.Error("expected error for a missing container")
+	}
+}
+
+func TestParseDockerPS(t *testing.T) {
+	out := "abc\x1fnginx:1.19\x1fweb|frontend,alias\x1fUp 2 hours\x1fapp=cli\n" +
+		"def\x1fregistry|mirror/postgres\x1fdb\x1fExited (0) 1 hour ago\x1f\n" +
+		"ghi\x1fbusybox\x1fpod\x1fUp 1 minute\x1fio.kubernetes.pod.name=web-0,io.kubernetes.pod.namespace=default\n" +
+		"malformed|line\n"
+	v := parseDockerPS(out)
+	expected := []CgroupDockerStat{
+		{ContainerID: "abc", Name: "web|frontend", Image: "nginx:1.19", Status: "Up 2 hours", Running: true, Labels: map[string]string{"app": "cli"}},
+		{ContainerID: "def", Name: "db", Image: "registry|mirror/postgres", Status: "Exited (0) 1 hour ago", Labels: map[string]string{}},
+		{ContainerID: "ghi", Name: "pod", Image: "busybox", Status: "Up 1 minute", Running: true, Labels: map[string]string{
+			"io.kubernetes.pod.name":      "web-0",
+			"io.kubernetes.pod.namespace": "default",
+		}},
+	}
+	if !reflect.DeepEqual(v, expected) {
+		t.Errorf("wrong docker ps stats %v", v)
+	}
+}
+
+func TestGetDockerStatByID(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gopsutil-docker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// a fake docker CLI only listing the container matching the id filter
+	script := "#!/bin/sh\ncase \"$*\" in\n*\"--filter id=abc \"*) printf 'abc123\\037nginx\\037web\\037Up 2 hours\\037app=cli\\n' ;;\nesac\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "docker"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	origPath := os.Getenv("PATH")
+	os.Setenv("PATH", dir)
+	defer os.Setenv("PATH", origPath)
+
+	v, err := GetDockerStatByID("abc")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	expected := CgroupDockerStat{ContainerID: "abc123", Name: "web", Image: "nginx", Status: "Up 2 hours", Running: true, Labels: map[string]string{"app": "cli"}}
+	if !reflect.DeepEqual(v, expected) {
+		t.Errorf("wrong docker stat %v", v)
+	}
+
+	if _, err := GetDockerStatByID("def"); err != ErrContainerNotFound {
+		t.Errorf("expected ErrContainerNotFound, got %v", err)
+	}
+}
+
+func TestParseDockerLabels(t *testing.T) {
+	cases := map[string]map[string]string{
+		"":                             {},
+		"a=1,b=2":                      {"a": "1", "b": "2"},
+		`desc="x, y",env=prod`:         {"desc": "x, y", "env": "prod"},
+		"hosts=a,b,c,owner=ops":        {"hosts": "a,b,c", "owner": "ops"},
+		"empty=,url=http://x/?a=1&b=2": {"empty": "", "url": "http://x/?a=1&b=2"},
+	}
+	for s, expected := range cases {
+		v := parseDockerLabels(s)
+		if !reflect.DeepEqual(v, expected) {
+			t.Errorf("wrong labels for %q: %v", s, v)
+		}
+	}
+}
+
+func TestGetDockerStatWithContext(t *testing.T) {
+	defer newFakeDockerAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/containers/json" || r.URL.Query().Get("all") != "1" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `[{"Id":"abc","Names":["/web"],"Image":"nginx","State":"running","Status":"Up 2 hours","Labels":{"app":"web"}},`+
+			`{"Id":"def","Names":["/db"],"Image":"postgres","State":"exited","Status":"Exited (0) 1 hour ago","Labels":null}]`)
+	})()
+
+	v, err := GetDockerStatWithContext(context.Background())
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	expected := []CgroupDockerStat{
+		{ContainerID: "abc", Name: "web", Image: "nginx", Status: "Up 2 hours", Running: true, Labels: map[string]string{"app": "web"}},
+		{ContainerID: "def", Name: "db", Image: "postgres", Status: "Exited (0) 1 hour ago", Labels: map[string]string{}},
+	}
+	if !reflect.DeepEqual(v, expected) {
+		t.Errorf("wrong docker stats %v", v)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := GetDockerStatWithContext(ctx); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestCgroupIOLimits(t *testing.T) {
+	dir, err := common.FakeTree(map[string]string{
+		"v1/abc/blkio.throttle.read_bps_device":   "8:0 1048576\n",
+		"v1/abc/blkio.throttle.write_bps_device":  "",
+		"v1/abc/blkio.throttle.read_iops_device":  "",
+		"v1/abc/blkio.throttle.write_iops_device": "8:0 100\n8:16 200\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	v, err := CgroupIOLimits("abc", filepath.Join(dir, "v1"))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	expected := map[string]CgroupIOLimitStat{
+		"8:0":  {Device: "8:0", ReadBps: 1048576, WriteIOPS: 100},
+		"8:16": {Device: "8:16", WriteIOPS: 200},
+	}
+	if !reflect.DeepEqual(v, expected) {
+		t.Errorf("wrong cgroup v1 io limits %v", v)
+	}
+
+	if err := common.WriteTree(dir, map[string]string{"v2/abc/io.max": ""}); err != nil {
+		t.Fatal(err)
+	}
+	v, err = CgroupIOLimits("abc", filepath.Join(dir, "v2"))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if v == nil || len(v) != 0 {
+		t.Errorf("expected no io limits, got %v", v)
+	}
+
+	// without the io controller
+	if err := common.WriteTree(dir, map[string]string{"v2/def/": ""}); err != nil {
+		t.Fatal(err)
+	}
+	v, err = CgroupIOLimits("def", filepath.Join(dir, "v2"))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if v == nil || len(v) != 0 {
+		t.Errorf("expected no io limits without io.max, got %v", v)
+	}
+
+	if _, err := CgroupIOLimits("ghi", filepath.Join(dir, "v2")); err == nil {
+		t.Error("expected error for a missing cgroup")
+	}
+}
+
+func TestParseIOMax(t *testing.T) {
+	v, err := parseIOMax("8:16 rbps=2097152 wbps=max riops=max wiops=120")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	expected := CgroupIOLimitStat{Device: "8:16", ReadBps: 2097152, WriteIOPS: 120}
+	if *v != expected {
+		t.Errorf("wrong io.max limits %v", v)
+	}
+	if _, err := parseIOMax("8:16 rbps"); err == nil {
+		t.Error("expected error for malformed io.max")
+	}
+}
+
+func TestCgroupMemHigh(t *testing.T) {
+	dir, err := common.FakeTree(map[string]string{
+		"v2/abc/memory.high":   "268435456\n",
+		"v2/abc/memory.events": "low 0\nhigh 42\nmax 3\noom 0\noom_kill 0\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	v, err := CgroupMemHigh("abc", filepath.Join(dir, "v2"))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	expected := CgroupMemHighStat{ContainerID: "abc", High: 268435456, HighEvents: 42}
+	if *v != expected {
+		t.Errorf("wrong memory.high %v", v)
+	}
+
+	if err := common.WriteTree(dir, map[string]string{"v2/abc/memory.high": "max\n"}); err != nil {
+		t.Fatal(err)
+	}
+	v, err = CgroupMemHigh("abc", filepath.Join(dir, "v2"))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if !v.Unlimited || v.High != 0 {
+		t.Errorf("expected no memory.high, got %v", v)
+	}
+
+	if err := common.WriteTree(dir, map[string]string{"v1/abc/memory.usage_in_bytes": "1024\n"}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = CgroupMemHigh("abc", filepath.Join(dir, "v1"))
+	if err != common.ErrNotImplementedError {
+		t.Errorf("expected ErrNotImplementedError on cgroup v1, got %v", err)
+	}
+}
+
+func TestCgroupMemHighDocker(t *testing.T) {
+	dir, err := common.FakeTree(map[string]string{
+		"fs/cgroup/docker/abc/memory.high":   "268435456\n",
+		"fs/cgroup/docker/abc/memory.events": "low 0\nhigh 42\nmax 3\noom 0\noom_kill 0\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("HOST_SYS", dir)
+	defer os.Unsetenv("HOST_SYS")
+
+	v, err := CgroupMemHighDocker("abc")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	expected := CgroupMemHighStat{ContainerID: "abc", High: 268435456, HighEvents: 42}
+	if *v != expected {
+		t.Errorf("wrong memory.high %v", v)
+	}
+}
+
+func TestCgroupOOMControl(t *testing.T) {
+	dir, err := common.FakeTree(map[string]string{"v1/abc/memory.oom_control": "oom_kill_disable 1\nunder_oom 1\noom_kill 0\n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	v, err := CgroupOOMControl("abc", filepath.Join(dir, "v1"))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	expected := CgroupOOMControlStat{ContainerID: "abc", CgroupVersion: 1, OOMKillDisable: true, UnderOOM: true}
+	if *v != expected {
+		t.Errorf("wrong cgroup v1 oom control %v", v)
+	}
+
+	if err := common.WriteTree(dir, map[string]string{
+		"v2/abc/memory.oom.group": "1\n",
+		"v2/abc/memory.events":    "low 0\nhigh 0\nmax 12\noom 3\noom_kill 2\n",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	v, err = CgroupOOMControl("abc", filepath.Join(dir, "v2"))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	expected = CgroupOOMControlStat{ContainerID: "abc", CgroupVersion: 2, OOMGroup: true, OOMKill: 2}
+	if *v != expected {
+		t.Errorf("wrong cgroup v2 oom control %v", v)
+	}
+}
+
+func TestCgroupOOMControlDocker(t *testing.T) {
+	dir, err := common.FakeTree(map[string]string{
+		"fs/cgroup/memory/docker/abc/memory.oom_control": "oom_kill_disable 0\nunder_oom 0\noom_kill 4\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("HOST_SYS", dir)
+	defer os.Unsetenv("HOST_SYS")
+
+	v, err := CgroupOOMControlDocker("abc")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	expected := CgroupOOMControlStat{ContainerID: "abc", CgroupVersion: 1, OOMKill: 4}
+	if *v != expected {
+		t.Errorf("wrong cgroup v1 oom control %v", v)
+	}
+}
+
+func TestCgroupCPUSet(t *testing.T) {
+	dir, err := common.FakeTree(map[string]string{
+		"v1/abc/cpuset.cpus": "0-3,8\n",
+		"v1/abc/cpuset.mems": "0\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	v, err := CgroupCPUSet("abc", filepath.Join(dir, "v1"))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if !reflect.DeepEqual(v.CPUs, []int{0, 1, 2, 3, 8}) || !reflect.DeepEqual(v.Mems, []int{0}) {
+		t.Errorf("wrong cgroup v1 cpuset %v", v)
+	}
+
+	// an empty cpuset.cpus inherits the cpus of the parent
+	if err := common.WriteTree(dir, map[string]string{
+		"v2/abc/cpuset.cpus":           "\n",
+		"v2/abc/cpuset.cpus.effective": "0-1\n",
+		"v2/abc/cpuset.mems.effective": "0-1\n",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	v, err = CgroupCPUSet("abc", filepath.Join(dir, "v2"))
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if !reflect.DeepEqual(v.CPUs, []int{0, 1}) || !reflect.DeepEqual(v.Mems, []int{0, 1}) {
+		t.Errorf("wrong cgroup v2 cpuset %v", v)
+	}
+}
+
+func TestCgroupBlkio(t *testing.T) {
+	dir, err := common.FakeTree(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := CgroupBlkio("abc", dir); err != ErrCgroupNotAvailable {
+		t.Errorf("expected ErrCgroupNotAvailable, got %v", err)
+	}
+
+	if err := common.WriteTree(dir, map[string]string{
+		"abc/blkio.throttle.io_service_bytes": "8:0 Read 4096\n8:0 Write 8192\n8:0 Sync 8192\n8:0 Async 4096\n8:0 Total 12288\n" +
+			"8:16 Read 1024\n8:16 Write 0\n8:16 Sync 0\n8:16 Async 1024\n8:16 Total 1024\nTotal 13312\n",
+		"abc/blkio.throttle.io_serviced": "8:0 Read 1\n8:0 Write 2\n8:0 Total 3\n8:16 Read 1\n8:16 Write 0\n8:16 Total 1\nTotal 4\n",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	v, err := CgroupBlkio("abc", dir)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	expected := CgroupBlkioStat{
+		ContainerID:         "abc",
+		IoServiceBytesRead:  5120,
+		IoServiceBytesWrite: 8192,
+		IoServicedRead:      2,
+		IoServicedWrite:     2,
+	}
+	if *v != expected {
+		t.Errorf("wrong blkio stats %v", v)
+	}
+}
+
+func TestCgroupNetDocker(t *testing.T) {
+	procs := "sys/fs/cgroup/cpuacct/docker/abc/cgroup.procs"
+	dir, err := common.FakeTree(map[string]string{procs: ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("HOST_SYS", filepath.Join(dir, "sys"))
+	defer os.Unsetenv("HOST_SYS")
+	os.Setenv("HOST_PROC", filepath.Join(dir, "proc"))
+	defer os.Unsetenv("HOST_PROC")
+
+	v, err := CgroupNetDocker("abc")
+	if err != ErrNoContainerPIDs {
+		t.Errorf("expected ErrNoContainerPIDs, got %v", err)
+	}
+	if v == nil || len(v) != 0 {
+		t.Errorf("expected an empty slice, got %v", v)
+	}
+
+	if err := common.WriteTree(dir, map[string]string{
+		procs: "1234\n1240\n",
+		"proc/1234/net/dev": "Inter-|   Receive                                                |  Transmit\n" +
+			" face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed\n" +
+			"    lo:     100       2    0    0    0     0          0         0      100       2    0    0    0     0       0          0\n" +
+			"  eth0:    4096      10    1    2    0     0          0         0     2048       5    0    3    0     0       0          0\n",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	v, err = CgroupNetDocker("abc")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if len(v) != 2 {
+		t.Fatalf("expected 2 interfaces, got %v", v)
+	}
+	eth0 := v[1]
+	if eth0.Name != "eth0" || eth0.BytesRecv != 4096 || eth0.PacketsRecv != 10 ||
+		eth0.Errin != 1 || eth0.Dropin != 2 || eth0.BytesSent != 2048 || eth0.PacketsSent != 5 || eth0.Dropout != 3 {
+		t.Errorf("wrong eth0 counters %v", eth0)
+	}
+}
+
+func TestAvailableControllers(t *testing.T) {
+	dir, err := common.FakeTree(map[string]string{
+		"proc/cgroups": "#subsys_name\thierarchy\tnum_cgroups\tenabled\n" +
+			"cpuset\t5\t4\t1\ncpu\t3\t90\t1\nmemory\t7\t120\t1\nhugetlb\t0\t1\t1\nrdma\t9\t1\t0\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("HOST_SYS", filepath.Join(dir, "sys"))
+	defer os.Unsetenv("HOST_SYS")
+	os.Setenv("HOST_PROC", filepath.Join(dir, "proc"))
+	defer os.Unsetenv("HOST_PROC")
+
+	v, err := AvailableControllers()
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if v.Version != "v1" || !reflect.DeepEqual(v.Controllers, []string{"cpu", "cpuset", "memory"}) || len(v.SubtreeControl) != 0 {
+		t.Errorf("wrong v1 controllers %v", v)
+	}
+
+	if err := common.WriteTree(dir, map[string]string{
+		"sys/fs/cgroup/unified/cgroup.controllers":     "\n",
+		"sys/fs/cgroup/unified/cgroup.subtree_control": "\n",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	v, err = AvailableControllers()
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if v.Version != "hybrid" || !reflect.DeepEqual(v.Controllers, []string{"cpu", "cpuset", "memory"}) {
+		t.Errorf("wrong hybrid controllers %v", v)
+	}
+
+	if err := common.WriteTree(dir, map[string]string{
+		"sys/fs/cgroup/cgroup.controllers":     "cpuset cpu io memory pids\n",
+		"sys/fs/cgroup/cgroup.subtree_control": "memory pids\n",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	v, err = AvailableControllers()
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	expected := CgroupControllersStat{
+		Version:        "v2",
+		Controllers:    []string{"cpu", "cpuset", "io", "memory", "pids"},
+		SubtreeControl: []string{"memory", "pids"},
+	}
+	if !reflect.DeepEqual(*v, expected) {
+		t.Errorf("wrong v2 controllers %v", v)
+	}
+}
+
+func TestCgroupCPUUsage(t *testing.T) {
+	dir, err := common.FakeTree(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	v, err := CgroupCPUUsage("abc", dir)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if *v != (CgroupCPUStat{ContainerID: "abc"}) {
+		t.Errorf("expected a zero stat without cpu.stat, got %v", v)
+	}
+
+	if err := common.WriteTree(dir, map[string]string{"abc/cpu.stat": "nr_periods 1200\nnr_throttled 300\nthrottled_time 45000000000\n"}); err != nil {
+		t.Fatal(err)
+	}
+	v, err = CgroupCPUUsage("abc", dir)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if *v != (CgroupCPUStat{ContainerID: "abc", NrPeriods: 1200, NrThrottled: 300, ThrottledTime: 45000000000}) {
+		t.Errorf("wrong cgroup v1 cpu stat %v", v)
+	}
+
+	if err := common.WriteTree(dir, map[string]string{
+		"abc/cpu.stat": "usage_usec 8000000\nuser_usec 6000000\nsystem_usec 2000000\nnr_periods 50\nnr_throttled 5\nthrottled_usec 250000\n",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	v, err = CgroupCPUUsage("abc", dir)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if *v != (CgroupCPUStat{ContainerID: "abc", NrPeriods: 50, NrThrottled: 5, ThrottledTime: 250000000}) {
+		t.Errorf("wrong cgroup v2 cpu stat %v", v)
+	}
+}
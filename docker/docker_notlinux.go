@@ -3,10 +3,12 @@
 package docker
 
 import (
+	"context"
 	"encoding/json"
 
 	"github.com/DataDog/gopsutil/cpu"
 	"github.com/DataDog/gopsutil/internal/common"
+	"github.com/DataDog/gopsutil/net"
 )
 
 // GetDockerStat returns a list of Docker basic stats.
@@ -15,12 +17,54 @@ func GetDockerStat() ([]CgroupDockerStat, error) {
 	return nil, ErrDockerNotAvailable
 }
 
+// GetDockerStatWithContext returns a list of Docker basic stats. They are
+// read from the Docker Engine API listening on SocketPath, which does not
+// require the docker CLI, and from the CLI as GetDockerStat does if the
+// socket is not available. ctx.Err() is returned as soon as ctx is done.
+func GetDockerStatWithContext(ctx context.Context) ([]CgroupDockerStat, error) {
+	return nil, ErrDockerNotAvailable
+}
+
+// GetDockerStatByID returns the Docker basic stats of a single container,
+// listed by docker ps filtered on its ID, which may be a prefix of the
+// full ID. ErrContainerNotFound is returned if no container matches.
+func GetDockerStatByID(containerID string) (CgroupDockerStat, error) {
+	return CgroupDockerStat{}, ErrDockerNotAvailable
+}
+
 // GetDockerIDList returnes a list of DockerID.
 // This requires certain permission.
 func GetDockerIDList() ([]string, error) {
 	return nil, ErrDockerNotAvailable
 }
 
+// GetDockerIDListWithContext returns a list of DockerID, killing the
+// docker CLI and returning ctx.Err() as soon as ctx is done.
+func GetDockerIDListWithContext(ctx context.Context) ([]string, error) {
+	return nil, ErrDockerNotAvailable
+}
+
+// ContainerDiskUsage returns the size of the writable layer and of the root
+// filesystem of a container.
+func ContainerDiskUsage(containerID string) (*ContainerDiskUsageStat, error) {
+	return nil, ErrDockerNotAvailable
+}
+
+// GetContainerStatsAPI returns the resource usage of a container read
+// from the Docker Engine API stats endpoint. With stream false, the daemon
+// samples the container twice before replying, which takes about a second
+// but fills CPUPercent. With stream true, the first sample of the stream
+// is returned.
+func GetContainerStatsAPI(containerID string, stream bool) (*ContainerStatsStat, error) {
+	return nil, ErrDockerNotAvailable
+}
+
+// GetContainerState returns the state, exit code and restart count of a
+// container, read from the Docker Engine API.
+func GetContainerState(containerID string) (*ContainerStateStat, error) {
+	return nil, ErrDockerNotAvailable
+}
+
 // CgroupCPU returnes specified cgroup id CPU status.
 // containerid is same as docker id if you use docker.
 // If you use container via systemd.slice, you could use
@@ -33,6 +77,30 @@ func CgroupCPUDocker(containerid string) (*cpu.TimesStat, error) {
 	return CgroupCPU(containerid, common.HostSys("fs/cgroup/cpuacct/docker"))
 }
 
+// CgroupCPUUsage returns the CPU throttling of a container, read from the
+// cpu.stat file of the cpu controller. The stat is zero if the file does
+// not exist, which is the case when no CPU quota was ever configured.
+func CgroupCPUUsage(containerID string, base string) (*CgroupCPUStat, error) {
+	return nil, ErrCgroupNotAvailable
+}
+
+func CgroupCPUUsageDocker(containerID string) (*CgroupCPUStat, error) {
+	return CgroupCPUUsage(containerID, common.HostSys("fs/cgroup/cpu/docker"))
+}
+
+// CgroupCPUShares returns the CPU weight of a container, read from
+// cpu.shares on cgroup v1 or cpu.weight on cgroup v2.
+func CgroupCPUShares(containerID string, base string) (*CgroupCPUSharesStat, error) {
+	return nil, ErrCgroupNotAvailable
+}
+
+// CgroupIOLimits returns the I/O limits of a container keyed by device,
+// read from the blkio.throttle.*_device files on cgroup v1 or io.max on
+// cgroup v2. An empty map is returned if no limit is set.
+func CgroupIOLimits(containerID string, base string) (map[string]CgroupIOLimitStat, error) {
+	return nil, ErrCgroupNotAvailable
+}
+
 func CgroupMem(containerid string, base string) (*CgroupMemStat, error) {
 	return nil, ErrCgroupNotAvailable
 }
@@ -45,3 +113,73 @@ func (m CgroupMemStat) String() string {
 	s, _ := json.Marshal(m)
 	return string(s)
 }
+
+// CgroupMemHigh returns the memory.high soft limit of a container and the
+// "high" count of its memory.events. ErrNotImplementedError is returned on
+// cgroup v1, which has no memory.high.
+func CgroupMemHigh(containerID string, base string) (*CgroupMemHighStat, error) {
+	return nil, ErrCgroupNotAvailable
+}
+
+func CgroupMemHighDocker(containerID string) (*CgroupMemHighStat, error) {
+	return CgroupMemHigh(containerID, "")
+}
+
+// CgroupOOMControl returns the OOM killer configuration of a container,
+// read from memory.oom_control on cgroup v1, or memory.oom.group and
+// memory.events on cgroup v2.
+func CgroupOOMControl(containerID string, base string) (*CgroupOOMControlStat, error) {
+	return nil, ErrCgroupNotAvailable
+}
+
+func CgroupOOMControlDocker(containerID string) (*CgroupOOMControlStat, error) {
+	return CgroupOOMControl(containerID, "")
+}
+
+// CgroupCPUSet returns the CPUs and NUMA memory nodes a container is
+// allowed to use, read from cpuset.cpus and cpuset.mems on cgroup v1, or
+// cpuset.cpus.effective and cpuset.mems.effective on cgroup v2. On cgroup
+// v2 an empty cpuset means the set of the parent is inherited, so the
+// effective files hold the actual set.
+func CgroupCPUSet(containerID string, base string) (*CgroupCPUSetStat, error) {
+	return nil, ErrCgroupNotAvailable
+}
+
+// CgroupBlkio returns the bytes and operations read and written by a
+// container, read from blkio.throttle.io_service_bytes and
+// blkio.throttle.io_serviced of the cgroup v1 blkio controller.
+// ErrCgroupNotAvailable is returned if the blkio controller is not
+// mounted.
+func CgroupBlkio(containerID string, base string) (*CgroupBlkioStat, error) {
+	return nil, ErrCgroupNotAvailable
+}
+
+func CgroupBlkioDocker(containerID string) (*CgroupBlkioStat, error) {
+	return CgroupBlkio(containerID, common.HostSys("fs/cgroup/blkio/docker"))
+}
+
+// CgroupPIDs returns the pids of the processes of a container, read from
+// cgroup.procs.
+func CgroupPIDs(containerID string, base string) ([]int32, error) {
+	return nil, ErrCgroupNotAvailable
+}
+
+func CgroupPIDsDocker(containerID string) ([]int32, error) {
+	return CgroupPIDs(containerID, common.HostSys("fs/cgroup/cpuacct/docker"))
+}
+
+// CgroupNetDocker returns the network I/O counters of each interface of a
+// container, read from /proc/(pid)/net/dev of one of its processes, which
+// shows the interfaces of the network namespace of the container.
+// ErrNoContainerPIDs is returned with an empty slice if the container has
+// no running process.
+func CgroupNetDocker(containerID string) ([]net.IOCountersStat, error) {
+	return nil, ErrCgroupNotAvailable
+}
+
+// AvailableControllers returns the cgroup hierarchy version of the host
+// and the controllers which are mounted and enabled, read from
+// /proc/cgroups on v1 and from cgroup.controllers on v2.
+func AvailableControllers() (*CgroupControllersStat, error) {
+	return nil, ErrCgroupNotAvailable
+}
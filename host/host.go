@@ -1,20 +1,104 @@
 package host
 
 import (
+	"context"
 	"encoding/json"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/DataDog/gopsutil/internal/common"
 )
 
 var (
 	invoke         common.Invoker
-	cachedBootTime = uint64(0)
+	cachedBootTime bootTimeCache
 )
 
 func init() {
 	invoke = common.Invoke{}
 }
 
+// bootTimeMaxDrift is how far the wall clock may move away from the
+// monotonic clock before a cached boot time is read again.
+const bootTimeMaxDrift = 5 * time.Second
+
+// bootTimeCache holds the boot time returned by BootTime, which is called
+// for every process by the process package, and the time it was read at.
+// Its methods are safe for concurrent use.
+type bootTimeCache struct {
+	mu   sync.Mutex
+	boot uint64
+	at   time.Time
+}
+
+// get returns the cached boot time, or the one returned by read if it is
+// not cached yet or went stale.
+func (c *bootTimeCache) get(read func() (uint64, error)) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.boot != 0 && !c.drifted(time.Now()) {
+		return c.boot, nil
+	}
+	boot, err := read()
+	if err != nil {
+		return 0, err
+	}
+	c.boot = boot
+	c.at = time.Now()
+	return boot, nil
+}
+
+// drifted reports whether the wall clock moved away from the monotonic
+// clock since the boot time was read, after the clock was set or the host
+// was suspended, as the boot time derived from the uptime changes then.
+func (c *bootTimeCache) drifted(now time.Time) bool {
+	d := now.Round(0).Sub(c.at.Round(0)) - now.Sub(c.at)
+	return d > bootTimeMaxDrift || d < -bootTimeMaxDrift
+}
+
+func (c *bootTimeCache) reset() {
+	c.mu.Lock()
+	c.boot = 0
+	c.mu.Unlock()
+}
+
+// RefreshBootTime clears the cached boot time, the next call to BootTime
+// reads it again. The boot time is also read again when the wall clock
+// was changed or the host suspended since it was cached.
+func RefreshBootTime() {
+	cachedBootTime.reset()
+}
+
+// UsersWithContext returns Users unless ctx is already done.
+func UsersWithContext(ctx context.Context) ([]UserStat, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return Users()
+}
+
+// VirtualizationWithContext returns Virtualization unless ctx is already
+// done.
+func VirtualizationWithContext(ctx context.Context) (string, string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", "", err
+	}
+	return Virtualization()
+}
+
+// BootTimeWithContext reads the boot time again, bypassing and updating the
+// cache of BootTime.
+func BootTimeWithContext(ctx context.Context) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	RefreshBootTime()
+	return BootTime()
+}
+
 // A HostInfoStat describes the host status.
 // This is not in the psutil but it useful.
 type InfoStat struct {
@@ -33,6 +117,23 @@ type InfoStat struct {
 	HostID               string `json:"hostid"`             // ex: uuid
 }
 
+// FirmwareStat describes the BIOS and the system of the host, from the
+// SMBIOS tables. Fields which cannot be read are left empty.
+type FirmwareStat struct {
+	BIOSVendor    string `json:"biosVendor"`
+	BIOSVersion   string `json:"biosVersion"`
+	BIOSDate      string `json:"biosDate"` // ex: 04/01/2014
+	SysVendor     string `json:"sysVendor"`
+	ProductName   string `json:"productName"`
+	ProductSerial string `json:"productSerial"` // only readable by root on Linux
+}
+
+// TemperatureStat is the temperature of a sensor, in degrees Celsius.
+type TemperatureStat struct {
+	SensorKey   string  `json:"sensorKey"` // ex: coretemp_core_0, nvme0_composite
+	Temperature float64 `json:"sensorTemperature"`
+}
+
 type UserStat struct {
 	User     string `json:"user"`
 	Terminal string `json:"terminal"`
@@ -40,12 +141,231 @@ type UserStat struct {
 	Started  int    `json:"started"`
 }
 
+// OOMKillStat describes a process killed by the kernel OOM killer.
+type OOMKillStat struct {
+	Pid       int32  `json:"pid"`
+	Name      string `json:"name"`
+	Timestamp uint64 `json:"timestamp"` // approximate, seconds since the epoch
+}
+
+// ClockSourceStat describes the kernel clocksource and the TSC of the host.
+type ClockSourceStat struct {
+	Current     string   `json:"current"`     // ex: tsc, kvm-clock, hpet
+	Available   []string `json:"available"`   // ex: [kvm-clock tsc acpi_pm]
+	TSCReliable bool     `json:"tscReliable"` // constant_tsc and nonstop_tsc, or tsc_reliable
+}
+
+// DNSConfigStat describes the resolver configuration of the host.
+type DNSConfigStat struct {
+	Nameservers []string `json:"nameservers"`
+	Search      []string `json:"search"`
+	Options     []string `json:"options"`
+	// Stub is true if the nameserver is a local stub resolver such as
+	// systemd-resolved, in which case Upstreams holds the servers it forwards to.
+	Stub      bool     `json:"stub"`
+	Upstreams []string `json:"upstreams"`
+}
+
+// ThreadCountStat is the number of threads of the system and its limit.
+type ThreadCountStat struct {
+	Threads    uint64 `json:"threads"`
+	ThreadsMax uint64 `json:"threadsMax"` // kernel.threads-max
+	// States counts the threads by the state letter of their stat, such
+	// as R (running), S (sleeping), D (disk sleep), T (stopped) or Z.
+	States map[string]uint64 `json:"states"`
+}
+
+// OpenFileSummaryStat is the number of file handles of the system along
+// with the files held open by processes after being deleted. The space of
+// a deleted file is only freed once it is closed, so these files are
+// counted by df but not by du.
+type OpenFileSummaryStat struct {
+	Allocated    uint64 `json:"allocated"` // allocated file handles, from file-nr
+	Max          uint64 `json:"max"`
+	ProcessFDs   uint64 `json:"processFds"`   // fds of all the processes
+	Deleted      uint64 `json:"deleted"`      // fds pointing to deleted files
+	DeletedBytes uint64 `json:"deletedBytes"` // size of the deleted files, counted once per file
+}
+
+// InotifyLimitsStat is the per user limits of inotify, from
+// /proc/sys/fs/inotify.
+type InotifyLimitsStat struct {
+	MaxUserInstances uint64 `json:"maxUserInstances"`
+	MaxUserWatches   uint64 `json:"maxUserWatches"`
+	MaxQueuedEvents  uint64 `json:"maxQueuedEvents"`
+}
+
+// NUMANodeStat is a NUMA node and its logical CPUs.
+type NUMANodeStat struct {
+	Node int   `json:"node"`
+	CPUs []int `json:"cpus"`
+}
+
+// NICAffinityStat is the NUMA node a network interface is attached to and
+// the CPUs and NUMA nodes its interrupts are pinned to. Interrupts served
+// by another node than the device one cross the socket interconnect.
+type NICAffinityStat struct {
+	Name       string            `json:"name"`
+	DeviceNode int               `json:"deviceNode"` // -1 if unknown
+	IRQs       []IRQAffinityStat `json:"irqs"`
+}
+
+// IRQAffinityStat is the CPUs an interrupt may be served by, and the NUMA
+// nodes of these CPUs.
+type IRQAffinityStat struct {
+	IRQ   int   `json:"irq"`
+	CPUs  []int `json:"cpus"`
+	Nodes []int `json:"nodes"`
+}
+
+// BootTimingStat is the time spent in each stage of the last boot, in
+// seconds, as reported by systemd-analyze.
+type BootTimingStat struct {
+	BootTime  uint64  `json:"bootTime"`
+	Systemd   bool    `json:"systemd"`  // false if only BootTime is known
+	Firmware  float64 `json:"firmware"` // 0 if not reported by the boot loader
+	Loader    float64 `json:"loader"`   // 0 if not reported by the boot loader
+	Kernel    float64 `json:"kernel"`
+	InitRD    float64 `json:"initrd"` // 0 without an initrd
+	Userspace float64 `json:"userspace"`
+}
+
 func (h InfoStat) String() string {
 	s, _ := json.Marshal(h)
 	return string(s)
 }
 
+func (f FirmwareStat) String() string {
+	s, _ := json.Marshal(f)
+	return string(s)
+}
+
+func (t TemperatureStat) String() string {
+	s, _ := json.Marshal(t)
+	return string(s)
+}
+
 func (u UserStat) String() string {
 	s, _ := json.Marshal(u)
 	return string(s)
 }
+
+func (o OOMKillStat) String() string {
+	s, _ := json.Marshal(o)
+	return string(s)
+}
+
+func (c ClockSourceStat) String() string {
+	s, _ := json.Marshal(c)
+	return string(s)
+}
+
+func (d DNSConfigStat) String() string {
+	s, _ := json.Marshal(d)
+	return string(s)
+}
+
+func (t ThreadCountStat) String() string {
+	s, _ := json.Marshal(t)
+	return string(s)
+}
+
+func (o OpenFileSummaryStat) String() string {
+	s, _ := json.Marshal(o)
+	return string(s)
+}
+
+func (i InotifyLimitsStat) String() string {
+	s, _ := json.Marshal(i)
+	return string(s)
+}
+
+func (n NUMANodeStat) String() string {
+	s, _ := json.Marshal(n)
+	return string(s)
+}
+
+func (n NICAffinityStat) String() string {
+	s, _ := json.Marshal(n)
+	return string(s)
+}
+
+func (i IRQAffinityStat) String() string {
+	s, _ := json.Marshal(i)
+	return string(s)
+}
+
+func (b BootTimingStat) String() string {
+	s, _ := json.Marshal(b)
+	return string(s)
+}
+
+// FQDN returns the fully-qualified domain name of the host. gethostname(2)
+// only returns the short name on many distributions, so /etc/hostname and
+// a reverse lookup of the host addresses are tried as well. If no qualified
+// name can be determined, the short hostname is returned and qualified is
+// false.
+func FQDN() (name string, qualified bool, err error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", false, err
+	}
+	if strings.Contains(hostname, ".") {
+		return strings.TrimSuffix(hostname, "."), true, nil
+	}
+
+	lines, err := common.ReadLines(common.HostEtc("hostname"))
+	if err == nil && len(lines) > 0 {
+		if n := strings.TrimSpace(lines[0]); strings.HasPrefix(n, hostname+".") {
+			return strings.TrimSuffix(n, "."), true, nil
+		}
+	}
+
+	addrs, err := net.LookupHost(hostname)
+	if err == nil {
+		for _, addr := range addrs {
+			names, err := net.LookupAddr(addr)
+			if err != nil {
+				continue
+			}
+			for _, n := range names {
+				if strings.HasPrefix(n, hostname+".") {
+					return strings.TrimSuffix(n, "."), true, nil
+				}
+			}
+		}
+	}
+
+	return hostname, false, nil
+}
+
+// readResolvConf parses the nameserver, search/domain and options
+// directives of a resolv.conf(5) file.
+func readResolvConf(filename string) (*DNSConfigStat, error) {
+	lines, err := common.ReadLines(filename)
+	if err != nil {
+		return nil, err
+	}
+	ret := &DNSConfigStat{
+		Nameservers: []string{},
+		Search:      []string{},
+		Options:     []string{},
+		Upstreams:   []string{},
+	}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], ";") {
+			continue
+		}
+		switch fields[0] {
+		case "nameserver":
+			ret.Nameservers = append(ret.Nameservers, fields[1])
+		case "domain", "search":
+			// the last domain or search directive wins
+			ret.Search = fields[1:]
+		case "options":
+			ret.Options = append(ret.Options, fields[1:]...)
+		}
+	}
+	return ret, nil
+}
@@ -66,10 +66,15 @@ func Info() (*InfoStat, error) {
 	return ret, nil
 }
 
+// BootTime returns the system boot time expressed in seconds since the epoch.
+// It is read from the kern.boottime sysctl and cached until RefreshBootTime
+// is called or the wall clock drifts from the monotonic clock by more than
+// bootTimeMaxDrift, after the clock was set or the host suspended.
 func BootTime() (uint64, error) {
-	if cachedBootTime != 0 {
-		return cachedBootTime, nil
-	}
+	return cachedBootTime.get(readBootTime)
+}
+
+func readBootTime() (uint64, error) {
 	values, err := common.DoSysctrl("kern.boottime")
 	if err != nil {
 		return 0, err
@@ -80,9 +85,8 @@ func BootTime() (uint64, error) {
 	if err != nil {
 		return 0, err
 	}
-	cachedBootTime = uint64(boottime)
 
-	return cachedBootTime, nil
+	return uint64(boottime), nil
 }
 
 func uptime(boot uint64) uint64 {
@@ -179,3 +183,52 @@ func Virtualization() (string, string, error) {
 
 	return system, role, nil
 }
+
+func RecentOOMKills() ([]OOMKillStat, error) {
+	return []OOMKillStat{}, common.ErrNotImplementedError
+}
+
+func ClockSource() (*ClockSourceStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+// DNSConfig returns the resolver configuration read from /etc/resolv.conf.
+func DNSConfig() (*DNSConfigStat, error) {
+	return readResolvConf(common.HostEtc("resolv.conf"))
+}
+
+func ThreadCount() (*ThreadCountStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func BootTiming() (*BootTimingStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func InotifyLimits() (*InotifyLimitsStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func PtraceScope() (int, error) {
+	return 0, common.ErrNotImplementedError
+}
+
+func NUMANodeCPUs() ([]NUMANodeStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func NICIRQAffinity(iface string) (*NICAffinityStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func OpenFileSummary() (*OpenFileSummaryStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func Firmware() (*FirmwareStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func SensorsTemperatures() ([]TemperatureStat, error) {
+	return []TemperatureStat{}, common.ErrNotImplementedError
+}
@@ -16,6 +16,58 @@ func Uptime() (uint64, error) {
 	return 0, common.ErrNotImplementedError
 }
 
+func Virtualization() (string, string, error) {
+	return "", "", common.ErrNotImplementedError
+}
+
 func Users() ([]UserStat, error) {
 	return []UserStat{}, common.ErrNotImplementedError
 }
+
+func RecentOOMKills() ([]OOMKillStat, error) {
+	return []OOMKillStat{}, common.ErrNotImplementedError
+}
+
+func ClockSource() (*ClockSourceStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func DNSConfig() (*DNSConfigStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func ThreadCount() (*ThreadCountStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func BootTiming() (*BootTimingStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func InotifyLimits() (*InotifyLimitsStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func PtraceScope() (int, error) {
+	return 0, common.ErrNotImplementedError
+}
+
+func NUMANodeCPUs() ([]NUMANodeStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func NICIRQAffinity(iface string) (*NICAffinityStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func OpenFileSummary() (*OpenFileSummaryStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func Firmware() (*FirmwareStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func SensorsTemperatures() ([]TemperatureStat, error) {
+	return []TemperatureStat{}, common.ErrNotImplementedError
+}
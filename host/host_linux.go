@@ -9,10 +9,14 @@ import (
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/DataDog/gopsutil/internal/common"
@@ -86,10 +90,14 @@ func Info() (*InfoStat, error) {
 }
 
 // BootTime returns the system boot time expressed in seconds since the epoch.
+// It is read from the btime line of /proc/stat and cached until RefreshBootTime
+// is called or the wall clock drifts from the monotonic clock by more than
+// bootTimeMaxDrift, after the clock was set or the host suspended.
 func BootTime() (uint64, error) {
-	if cachedBootTime != 0 {
-		return cachedBootTime, nil
-	}
+	return cachedBootTime.get(readBootTime)
+}
+
+func readBootTime() (uint64, error) {
 	filename := common.HostProc("stat")
 	lines, err := common.ReadLines(filename)
 	if err != nil {
@@ -105,8 +113,7 @@ func BootTime() (uint64, error) {
 			if err != nil {
 				return 0, err
 			}
-			cachedBootTime = uint64(b)
-			return cachedBootTime, nil
+			return uint64(b), nil
 		}
 	}
 
@@ -125,46 +132,82 @@ func Uptime() (uint64, error) {
 	return uptime(boot), nil
 }
 
+// Users returns the users logged in, read from the USER_PROCESS records of
+// /var/run/utmp. Records left behind by a session whose process exited
+// without clearing them are skipped.
 func Users() ([]UserStat, error) {
 	utmpfile := "/var/run/utmp"
 
-	file, err := os.Open(utmpfile)
-	if err != nil {
-		return nil, err
-	}
-
-	buf, err := ioutil.ReadAll(file)
+	buf, err := ioutil.ReadFile(utmpfile)
 	if err != nil {
 		return nil, err
 	}
+	return parseUtmp(buf), nil
+}
 
-	count := len(buf) / sizeOfUtmp
-
-	ret := make([]UserStat, 0, count)
-
-	for i := 0; i < count; i++ {
-		b := buf[i*sizeOfUtmp : (i+1)*sizeOfUtmp]
+// Sizes of the glibc utmp record, with a 32 bit ut_session and ut_tv, as on
+// 32 bit architectures and x86_64, or with 64 bit ones.
+const (
+	utmpSize32 = 384
+	utmpSize64 = 400
+)
 
-		var u utmp
-		br := bytes.NewReader(b)
-		err := binary.Read(br, binary.LittleEndian, &u)
-		if err != nil {
+// parseUtmp parses utmp records, whose size is detected, at their glibc
+// offsets rather than with the utmp struct of the build architecture.
+func parseUtmp(buf []byte) []UserStat {
+	size := utmpRecordSize(buf)
+	ret := make([]UserStat, 0, len(buf)/size)
+	for off := 0; off+size <= len(buf); off += size {
+		b := buf[off : off+size]
+		if int16(binary.LittleEndian.Uint16(b[0:])) != USER_PROCESS {
 			continue
 		}
-		if u.Type != USER_PROCESS {
+		pid := int32(binary.LittleEndian.Uint32(b[4:]))
+		if pid > 0 && !common.PathExists(common.HostProc(strconv.Itoa(int(pid)))) {
 			continue
 		}
-		user := UserStat{
-			User:     common.IntToString(u.User[:]),
-			Terminal: common.IntToString(u.Line[:]),
-			Host:     common.IntToString(u.Host[:]),
-			Started:  int(u.Tv.Sec),
+		var started int
+		if size == utmpSize32 {
+			started = int(int32(binary.LittleEndian.Uint32(b[340:])))
+		} else {
+			started = int(int64(binary.LittleEndian.Uint64(b[344:])))
 		}
-		ret = append(ret, user)
+		ret = append(ret, UserStat{
+			User:     utmpString(b[44:76]),
+			Terminal: utmpString(b[8:40]),
+			Host:     utmpString(b[76:332]),
+			Started:  started,
+		})
 	}
+	return ret
+}
 
-	return ret, nil
+// utmpRecordSize returns the record size for which the file holds whole
+// records with valid types, from EMPTY to ACCOUNTING.
+func utmpRecordSize(buf []byte) int {
+	for _, size := range []int{utmpSize32, utmpSize64} {
+		if len(buf)%size != 0 {
+			continue
+		}
+		valid := true
+		for off := 0; off < len(buf); off += size {
+			if t := int16(binary.LittleEndian.Uint16(buf[off:])); t < 0 || t > 9 {
+				valid = false
+				break
+			}
+		}
+		if valid {
+			return size
+		}
+	}
+	return utmpSize32
+}
 
+func utmpString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i != -1 {
+		b = b[:i]
+	}
+	return string(b)
 }
 
 func getOSRelease() (platform string, version string, err error) {
@@ -422,7 +465,28 @@ func getSusePlatform(contents []string) string {
 	return "suse"
 }
 
+// cachedVirtualization holds the result of the first Virtualization call,
+// as it does not change while the host is up.
+var cachedVirtualization struct {
+	sync.Mutex
+	done         bool
+	system, role string
+}
+
+// Virtualization returns the virtualization or container system the host
+// runs in, or hosts, and its role, "guest" or "host". Container systems
+// take precedence over the hypervisor they run on. The result is cached.
 func Virtualization() (string, string, error) {
+	cachedVirtualization.Lock()
+	defer cachedVirtualization.Unlock()
+	if !cachedVirtualization.done {
+		cachedVirtualization.system, cachedVirtualization.role = detectVirtualization()
+		cachedVirtualization.done = true
+	}
+	return cachedVirtualization.system, cachedVirtualization.role, nil
+}
+
+func detectVirtualization() (string, string) {
 	var system string
 	var role string
 
@@ -471,6 +535,28 @@ func Virtualization() (string, string, error) {
 		}
 	}
 
+	// Firecracker microVMs only identify themselves through DMI
+	for _, name := range []string{"sys_vendor", "product_name", "bios_vendor"} {
+		contents, err := common.ReadLines(common.HostSys("class/dmi/id/" + name))
+		if err == nil && common.StringsContains(contents, "Firecracker") {
+			system = "firecracker"
+			role = "guest"
+			break
+		}
+	}
+
+	// WSL2 kernels are named like 5.15.90.1-microsoft-standard-WSL2, WSL1
+	// reports the Windows build as 4.4.0-19041-Microsoft
+	if contents, err := common.ReadLines(common.HostProc("sys/kernel/osrelease")); err == nil && len(contents) > 0 {
+		if strings.Contains(contents[0], "microsoft") {
+			system = "wsl2"
+			role = "guest"
+		} else if strings.Contains(contents[0], "Microsoft") {
+			system = "wsl"
+			role = "guest"
+		}
+	}
+
 	filename = common.HostProc()
 	if common.PathExists(filename + "/bc/0") {
 		system = "openvz"
@@ -512,6 +598,24 @@ func Virtualization() (string, string, error) {
 		}
 	}
 
+	// the container manager sets container= in the environment of init,
+	// which is only readable by root
+	if environ, err := ioutil.ReadFile(filename + "/1/environ"); err == nil {
+		for _, kv := range bytes.Split(environ, []byte{0}) {
+			if !bytes.HasPrefix(kv, []byte("container=")) {
+				continue
+			}
+			switch c := string(kv[len("container="):]); c {
+			case "lxc", "lxc-libvirt":
+				system = "lxc"
+				role = "guest"
+			case "docker", "podman", "systemd-nspawn":
+				system = c
+				role = "guest"
+			}
+		}
+	}
+
 	if common.PathExists(common.HostEtc("os-release")) {
 		p, _, err := getOSRelease()
 		if err == nil && p == "coreos" {
@@ -519,5 +623,619 @@ func Virtualization() (string, string, error) {
 			role = "host"
 		}
 	}
-	return system, role, nil
+	return system, role
+}
+
+// maxOOMKills bounds the number of events RecentOOMKills keeps while
+// scanning the kernel log, the most recent ones are returned.
+const maxOOMKills = 128
+
+// maxKernelLogBytes bounds how far back RecentOOMKills reads the kernel
+// log, only its last 1MiB is scanned.
+const maxKernelLogBytes = 1 << 20
+
+// syslog(2) actions
+const (
+	syslogActionReadAll    = 3
+	syslogActionSizeBuffer = 10
+)
+
+// The name is matched up to the last ")" before the "total-vm:..., " list
+// of newer kernels, as the command name may itself contain ")".
+var oomKillRegexp = regexp.MustCompile(`[Oo]ut of memory: Kill(?:ed)? process (\d+) \(([^,]*)\)`)
+
+// RecentOOMKills returns the most recent OOM killer events still present in
+// the kernel log buffer. The buffer is read with syslog(2), which returns its
+// last records, and at most maxKernelLogBytes of them are scanned; at most
+// maxOOMKills events are kept. Timestamps are derived from the boot time and
+// are approximate.
+func RecentOOMKills() ([]OOMKillStat, error) {
+	size, err := syscall.Klogctl(syslogActionSizeBuffer, nil)
+	if err != nil {
+		return nil, err
+	}
+	if size <= 0 || size > maxKernelLogBytes {
+		size = maxKernelLogBytes
+	}
+	buf := make([]byte, size)
+	n, err := syscall.Klogctl(syslogActionReadAll, buf)
+	if err != nil {
+		return nil, err
+	}
+
+	boot, _ := BootTime()
+
+	return parseKernelLogOOMKills(string(buf[:n]), boot), nil
+}
+
+// parseKernelLogOOMKills returns the last maxOOMKills OOM kill events of the
+// kernel log.
+func parseKernelLogOOMKills(log string, boot uint64) []OOMKillStat {
+	ret := []OOMKillStat{}
+	for _, line := range strings.Split(log, "\n") {
+		oom, ok := parseKernelLogOOMKill(line, boot)
+		if !ok {
+			continue
+		}
+		if len(ret) == maxOOMKills {
+			ret = append(ret[:0], ret[1:]...)
+		}
+		ret = append(ret, oom)
+	}
+	return ret
+}
+
+// parseKernelLogOOMKill parses a kernel log line formatted as
+// "<priority>[seconds.microseconds] message", the timestamp being only
+// present when printk.time is set.
+func parseKernelLogOOMKill(line string, boot uint64) (OOMKillStat, bool) {
+	if strings.HasPrefix(line, "<") {
+		if i := strings.Index(line, ">"); i > 0 {
+			line = line[i+1:]
+		}
+	}
+	matches := oomKillRegexp.FindStringSubmatch(line)
+	if matches == nil {
+		return OOMKillStat{}, false
+	}
+	pid, err := strconv.ParseInt(matches[1], 10, 32)
+	if err != nil {
+		return OOMKillStat{}, false
+	}
+	ret := OOMKillStat{
+		Pid:  int32(pid),
+		Name: matches[2],
+	}
+
+	if strings.HasPrefix(line, "[") {
+		if i := strings.Index(line, "]"); i > 0 {
+			sec, err := strconv.ParseFloat(strings.TrimSpace(line[1:i]), 64)
+			if err == nil {
+				ret.Timestamp = boot + uint64(sec)
+			}
+		}
+	}
+
+	return ret, true
+}
+
+// ClockSource returns the current and the available clocksources, read from
+// /sys/devices/system/clocksource/clocksource0, and whether the TSC is
+// reliable according to the cpu flags of /proc/cpuinfo.
+func ClockSource() (*ClockSourceStat, error) {
+	dir := common.HostSys("devices/system/clocksource/clocksource0")
+	lines, err := common.ReadLines(filepath.Join(dir, "current_clocksource"))
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("%s is empty", filepath.Join(dir, "current_clocksource"))
+	}
+	ret := &ClockSourceStat{
+		Current:   strings.TrimSpace(lines[0]),
+		Available: []string{},
+	}
+
+	lines, err = common.ReadLines(filepath.Join(dir, "available_clocksource"))
+	if err == nil && len(lines) > 0 {
+		ret.Available = strings.Fields(lines[0])
+	}
+
+	lines, err = common.ReadLines(common.HostProc("cpuinfo"))
+	if err == nil {
+		ret.TSCReliable = tscReliable(lines)
+	}
+
+	return ret, nil
+}
+
+// tscReliable reports whether the cpu flags of /proc/cpuinfo mark the TSC
+// as invariant or reliable. Only the first processor is looked at.
+func tscReliable(cpuinfo []string) bool {
+	for _, line := range cpuinfo {
+		fields := strings.SplitN(line, ":", 2)
+		if len(fields) != 2 || strings.TrimSpace(fields[0]) != "flags" {
+			continue
+		}
+		flags := make(map[string]bool)
+		for _, f := range strings.Fields(fields[1]) {
+			flags[f] = true
+		}
+		return flags["tsc_reliable"] || (flags["constant_tsc"] && flags["nonstop_tsc"])
+	}
+	return false
+}
+
+// resolvedStubAddress is the address systemd-resolved listens on.
+const resolvedStubAddress = "127.0.0.53"
+
+// DNSConfig returns the resolver configuration read from /etc/resolv.conf.
+// When it points to the systemd-resolved stub, the actual upstream
+// servers are read from /run/systemd/resolve/resolv.conf, which
+// systemd-resolved writes with the servers it forwards queries to.
+func DNSConfig() (*DNSConfigStat, error) {
+	ret, err := readResolvConf(common.HostEtc("resolv.conf"))
+	if err != nil {
+		return nil, err
+	}
+	for _, ns := range ret.Nameservers {
+		if ns == resolvedStubAddress {
+			ret.Stub = true
+		}
+	}
+	if ret.Stub {
+		upstream, err := readResolvConf(common.HostRun("systemd/resolve/resolv.conf"))
+		if err == nil {
+			ret.Upstreams = upstream.Nameservers
+		}
+	}
+	return ret, nil
+}
+
+// ThreadCount returns the number of threads of all the processes, counted
+// from the entries of each /proc/(pid)/task along with the state read from
+// their stat, and the system wide limit read from
+// /proc/sys/kernel/threads-max.
+func ThreadCount() (*ThreadCountStat, error) {
+	lines, err := common.ReadLines(common.HostProc("sys/kernel/threads-max"))
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("threads-max is empty")
+	}
+	max, err := strconv.ParseUint(strings.TrimSpace(lines[0]), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	ret := &ThreadCountStat{ThreadsMax: max, States: make(map[string]uint64)}
+
+	d, err := os.Open(common.HostProc())
+	if err != nil {
+		return nil, err
+	}
+	defer d.Close()
+	fnames, err := d.Readdirnames(-1)
+	if err != nil {
+		return nil, err
+	}
+	for _, fname := range fnames {
+		if _, err := strconv.ParseInt(fname, 10, 32); err != nil {
+			continue
+		}
+		// the process or its threads may be gone, just skip them
+		tids, err := common.ListDirectory(common.HostProc(fname, "task"))
+		if err != nil {
+			continue
+		}
+		for _, tid := range tids {
+			state, err := threadState(common.HostProc(fname, "task", tid, "stat"))
+			if err != nil {
+				continue
+			}
+			ret.Threads++
+			ret.States[state]++
+		}
+	}
+
+	return ret, nil
+}
+
+// threadState returns the state letter of a /proc/(pid)/task/(tid)/stat,
+// the field following the command name, which may contain spaces and
+// parentheses.
+func threadState(path string) (string, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	i := bytes.LastIndexByte(contents, ')')
+	if i < 0 {
+		return "", fmt.Errorf("malformed stat %s", path)
+	}
+	fields := strings.Fields(string(contents[i+1:]))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("malformed stat %s", path)
+	}
+	return fields[0], nil
+}
+
+// BootTiming returns the duration of the firmware, boot loader, kernel,
+// initrd and userspace stages of the last boot, from the timestamps systemd
+// exposes on D-Bus, like systemd-analyze. Without systemd, or before the
+// boot is finished, only BootTime is filled.
+func BootTiming() (*BootTimingStat, error) {
+	boot, err := BootTime()
+	if err != nil {
+		return nil, err
+	}
+	ret := &BootTimingStat{BootTime: boot}
+
+	systemctl, err := exec.LookPath("systemctl")
+	if err != nil {
+		return ret, nil
+	}
+	out, err := invoke.Command(systemctl, "show", "--property=FirmwareTimestampMonotonic,LoaderTimestampMonotonic,InitRDTimestampMonotonic,UserspaceTimestampMonotonic,FinishTimestampMonotonic")
+	if err != nil {
+		return ret, nil
+	}
+	parseBootTiming(string(out), ret)
+	return ret, nil
+}
+
+// parseBootTiming fills ret from "systemctl show" output. The timestamps
+// are in microseconds: firmware and loader ones count backwards from the
+// start of the kernel, the others count from it.
+func parseBootTiming(out string, ret *BootTimingStat) {
+	ts := make(map[string]float64)
+	for _, line := range strings.Split(out, "\n") {
+		kv := strings.SplitN(strings.TrimSpace(line), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		v, err := strconv.ParseUint(kv[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		ts[strings.TrimSuffix(kv[0], "TimestampMonotonic")] = float64(v) / 1e6
+	}
+	if ts["Finish"] == 0 || ts["Userspace"] == 0 {
+		// not booted with systemd, or still booting
+		return
+	}
+
+	ret.Systemd = true
+	if ts["Firmware"] > 0 {
+		ret.Firmware = ts["Firmware"] - ts["Loader"]
+	}
+	ret.Loader = ts["Loader"]
+	if ts["InitRD"] > 0 {
+		ret.Kernel = ts["InitRD"]
+		ret.InitRD = ts["Userspace"] - ts["InitRD"]
+	} else {
+		ret.Kernel = ts["Userspace"]
+	}
+	ret.Userspace = ts["Finish"] - ts["Userspace"]
+}
+
+// InotifyLimits returns the inotify limits of each user, read from
+// /proc/sys/fs/inotify.
+func InotifyLimits() (*InotifyLimitsStat, error) {
+	ret := &InotifyLimitsStat{}
+	for _, l := range []struct {
+		name  string
+		value *uint64
+	}{
+		{"max_user_instances", &ret.MaxUserInstances},
+		{"max_user_watches", &ret.MaxUserWatches},
+		{"max_queued_events", &ret.MaxQueuedEvents},
+	} {
+		lines, err := common.ReadLines(common.HostProc("sys/fs/inotify", l.name))
+		if err != nil {
+			return nil, err
+		}
+		if len(lines) == 0 {
+			return nil, fmt.Errorf("%s is empty", l.name)
+		}
+		v, err := strconv.ParseUint(strings.TrimSpace(lines[0]), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		*l.value = v
+	}
+	return ret, nil
+}
+
+// PtraceScope returns the Yama ptrace_scope of the host, read from
+// /proc/sys/kernel/yama/ptrace_scope: 0 allows a process to trace any
+// process of the same user, 1 only its descendants, 2 requires
+// CAP_SYS_PTRACE and 3 disables ptrace. ErrNotImplementedError is returned
+// if the kernel is built without Yama.
+func PtraceScope() (int, error) {
+	lines, err := common.ReadLines(common.HostProc("sys/kernel/yama/ptrace_scope"))
+	if os.IsNotExist(err) {
+		return 0, common.ErrNotImplementedError
+	}
+	if err != nil {
+		return 0, err
+	}
+	if len(lines) == 0 {
+		return 0, fmt.Errorf("ptrace_scope is empty")
+	}
+	return strconv.Atoi(strings.TrimSpace(lines[0]))
+}
+
+// NUMANodeCPUs returns the logical CPUs of each NUMA node, read from
+// /sys/devices/system/node/node*/cpulist.
+func NUMANodeCPUs() ([]NUMANodeStat, error) {
+	nodes, err := filepath.Glob(common.HostSys("devices/system/node/node[0-9]*"))
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]NUMANodeStat, 0, len(nodes))
+	for _, nodeDir := range nodes {
+		node, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(nodeDir), "node"))
+		if err != nil {
+			continue
+		}
+		lines, err := common.ReadLines(filepath.Join(nodeDir, "cpulist"))
+		if err != nil {
+			return nil, err
+		}
+		if len(lines) == 0 {
+			return nil, fmt.Errorf("cpulist of node %d is empty", node)
+		}
+		cpus, err := common.ParseCPUList(lines[0])
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, NUMANodeStat{Node: node, CPUs: cpus})
+	}
+	return ret, nil
+}
+
+// NICIRQAffinity returns the NUMA node of the device of a network
+// interface and, for each of its interrupts, the CPUs it is pinned to,
+// read from /proc/irq/(irq)/smp_affinity_list. The interrupts are the MSI
+// vectors of the device, or its legacy interrupt line.
+func NICIRQAffinity(iface string) (*NICAffinityStat, error) {
+	device := common.HostSys("class/net", iface, "device")
+	if !common.PathExists(device) {
+		return nil, fmt.Errorf("no device for interface %s", iface)
+	}
+	ret := &NICAffinityStat{Name: iface, DeviceNode: -1, IRQs: []IRQAffinityStat{}}
+	if node, err := common.ReadInts(filepath.Join(device, "numa_node")); err == nil && len(node) > 0 {
+		ret.DeviceNode = int(node[0])
+	}
+
+	var irqs []int
+	vectors, err := ioutil.ReadDir(filepath.Join(device, "msi_irqs"))
+	if err == nil {
+		for _, v := range vectors {
+			if irq, err := strconv.Atoi(v.Name()); err == nil {
+				irqs = append(irqs, irq)
+			}
+		}
+	}
+	if len(irqs) == 0 {
+		if irq, err := common.ReadInts(filepath.Join(device, "irq")); err == nil && len(irq) > 0 && irq[0] > 0 {
+			irqs = append(irqs, int(irq[0]))
+		}
+	}
+	if len(irqs) == 0 {
+		return ret, nil
+	}
+
+	nodes, err := NUMANodeCPUs()
+	if err != nil {
+		return nil, err
+	}
+	cpuNode := make(map[int]int)
+	for _, n := range nodes {
+		for _, cpu := range n.CPUs {
+			cpuNode[cpu] = n.Node
+		}
+	}
+
+	sort.Ints(irqs)
+	for _, irq := range irqs {
+		lines, err := common.ReadLines(common.HostProc("irq", strconv.Itoa(irq), "smp_affinity_list"))
+		if err != nil || len(lines) == 0 {
+			// the irq was freed since the device was read
+			continue
+		}
+		cpus, err := common.ParseCPUList(lines[0])
+		if err != nil {
+			return nil, err
+		}
+		stat := IRQAffinityStat{IRQ: irq, CPUs: cpus, Nodes: []int{}}
+		seen := make(map[int]bool)
+		for _, cpu := range cpus {
+			node, ok := cpuNode[cpu]
+			if ok && !seen[node] {
+				seen[node] = true
+				stat.Nodes = append(stat.Nodes, node)
+			}
+		}
+		sort.Ints(stat.Nodes)
+		ret.IRQs = append(ret.IRQs, stat)
+	}
+	return ret, nil
+}
+
+// OpenFileSummary returns the allocated and maximum number of file handles
+// read from /proc/sys/fs/file-nr, along with the fds of all the processes
+// and the deleted files they hold open, found by scanning /proc/(pid)/fd.
+// Only the fds of the processes readable by the current user are counted.
+func OpenFileSummary() (*OpenFileSummaryStat, error) {
+	lines, err := common.ReadLines(common.HostProc("sys/fs/file-nr"))
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("file-nr is empty")
+	}
+	fields := strings.Fields(lines[0])
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("wrong file-nr format: %s", lines[0])
+	}
+	ret := &OpenFileSummaryStat{}
+	if ret.Allocated, err = strconv.ParseUint(fields[0], 10, 64); err != nil {
+		return nil, err
+	}
+	if ret.Max, err = strconv.ParseUint(fields[2], 10, 64); err != nil {
+		return nil, err
+	}
+
+	d, err := os.Open(common.HostProc())
+	if err != nil {
+		return nil, err
+	}
+	defer d.Close()
+	pids, err := d.Readdirnames(-1)
+	if err != nil {
+		return nil, err
+	}
+	type fileID struct {
+		dev uint64
+		ino uint64
+	}
+	deleted := make(map[fileID]bool)
+	for _, pid := range pids {
+		if _, err := strconv.ParseInt(pid, 10, 32); err != nil {
+			continue
+		}
+		// the process may be gone or not readable, just skip it
+		fdDir := common.HostProc(pid, "fd")
+		fds, err := ioutil.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		ret.ProcessFDs += uint64(len(fds))
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil || !strings.HasSuffix(target, " (deleted)") || !strings.HasPrefix(target, "/") {
+				continue
+			}
+			ret.Deleted++
+			var stat syscall.Stat_t
+			if err := syscall.Stat(filepath.Join(fdDir, fd.Name()), &stat); err != nil {
+				continue
+			}
+			id := fileID{dev: uint64(stat.Dev), ino: stat.Ino}
+			if !deleted[id] {
+				deleted[id] = true
+				ret.DeletedBytes += uint64(stat.Size)
+			}
+		}
+	}
+	return ret, nil
+}
+
+// Firmware returns the BIOS vendor, version and date and the system
+// vendor, product name and serial number, read from /sys/class/dmi/id/.
+// Files which are not readable, such as product_serial for non-root users,
+// are left empty.
+func Firmware() (*FirmwareStat, error) {
+	dmi := common.HostSys("class/dmi/id")
+	if !common.PathExists(dmi) {
+		return nil, common.ErrNotImplementedError
+	}
+	ret := &FirmwareStat{}
+	for _, f := range []struct {
+		name  string
+		value *string
+	}{
+		{"bios_vendor", &ret.BIOSVendor},
+		{"bios_version", &ret.BIOSVersion},
+		{"bios_date", &ret.BIOSDate},
+		{"sys_vendor", &ret.SysVendor},
+		{"product_name", &ret.ProductName},
+		{"product_serial", &ret.ProductSerial},
+	} {
+		lines, err := common.ReadLines(filepath.Join(dmi, f.name))
+		if err != nil || len(lines) == 0 {
+			continue
+		}
+		*f.value = strings.TrimSpace(lines[0])
+	}
+	return ret, nil
+}
+
+// SensorsTemperatures returns the temperatures of the hwmon sensors of
+// /sys/class/hwmon and of the NVMe drives, whose hwmon nodes are under
+// /sys/class/nvme/nvme*. The key of a sensor is the name of its chip, or
+// of its NVMe controller, and its label or input name, ex: nvme0_composite.
+func SensorsTemperatures() ([]TemperatureStat, error) {
+	var ret []TemperatureStat
+	// hwmon nodes of NVMe drives are also listed in /sys/class/hwmon
+	seen := make(map[string]bool)
+
+	controllers, err := filepath.Glob(common.HostSys("class/nvme/nvme*"))
+	if err != nil {
+		return nil, err
+	}
+	for _, ctrl := range controllers {
+		dirs, _ := filepath.Glob(filepath.Join(ctrl, "hwmon*"))
+		legacy, _ := filepath.Glob(filepath.Join(ctrl, "device", "hwmon*"))
+		for _, dir := range append(dirs, legacy...) {
+			if real, err := filepath.EvalSymlinks(dir); err == nil {
+				if seen[real] {
+					continue
+				}
+				seen[real] = true
+			}
+			ret = append(ret, hwmonTemperatures(dir, filepath.Base(ctrl))...)
+		}
+	}
+
+	dirs, err := filepath.Glob(common.HostSys("class/hwmon/hwmon*"))
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range dirs {
+		if real, err := filepath.EvalSymlinks(dir); err == nil && seen[real] {
+			continue
+		}
+		chip := filepath.Base(dir)
+		if lines, err := common.ReadLines(filepath.Join(dir, "name")); err == nil && len(lines) > 0 {
+			chip = strings.TrimSpace(lines[0])
+		}
+		ret = append(ret, hwmonTemperatures(dir, chip)...)
+	}
+	if len(ret) == 0 && len(dirs) == 0 && len(controllers) == 0 {
+		return nil, common.ErrNotImplementedError
+	}
+	return ret, nil
+}
+
+// hwmonTemperatures returns the temperatures of the temp*_input files of
+// a hwmon directory, which are in millidegrees Celsius. Sensors which
+// cannot be read are skipped.
+func hwmonTemperatures(dir string, chip string) []TemperatureStat {
+	inputs, _ := filepath.Glob(filepath.Join(dir, "temp*_input"))
+	if len(inputs) == 0 {
+		// older kernels have the attributes in the device directory
+		inputs, _ = filepath.Glob(filepath.Join(dir, "device", "temp*_input"))
+	}
+	var ret []TemperatureStat
+	for _, input := range inputs {
+		lines, err := common.ReadLines(input)
+		if err != nil || len(lines) == 0 {
+			continue
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(lines[0]), 64)
+		if err != nil {
+			continue
+		}
+		name := strings.TrimSuffix(filepath.Base(input), "_input")
+		if lines, err := common.ReadLines(strings.TrimSuffix(input, "_input") + "_label"); err == nil && len(lines) > 0 {
+			if label := strings.TrimSpace(lines[0]); label != "" {
+				name = label
+			}
+		}
+		key := strings.ToLower(strings.Replace(chip+"_"+name, " ", "_", -1))
+		ret = append(ret, TemperatureStat{SensorKey: key, Temperature: v / 1000.0})
+	}
+	return ret
 }
@@ -3,7 +3,17 @@
 package host
 
 import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/DataDog/gopsutil/internal/common"
 )
 
 func TestGetRedhatishVersion(t *testing.T) {
@@ -59,3 +69,469 @@ func TestGetRedhatishPlatform(t *testing.T) {
 		t.Errorf("Could not get platform with no value: %v", ret)
 	}
 }
+
+func TestParseKernelLogOOMKill(t *testing.T) {
+	line := "<3>[ 5234.567890] Out of memory: Killed process 4242 (java) total-vm:8123456kB, anon-rss:4000000kB, file-rss:0kB"
+	v, ok := parseKernelLogOOMKill(line, 1500000000)
+	if !ok {
+		t.Fatalf("could not parse OOM kill line: %v", line)
+	}
+	if v.Pid != 4242 || v.Name != "java" {
+		t.Errorf("wrong OOM kill process: %v", v)
+	}
+	if v.Timestamp != 1500005234 {
+		t.Errorf("wrong OOM kill timestamp: %v", v)
+	}
+
+	line = "<3>[ 5234.567891] Memory cgroup out of memory: Kill process 17 (my proc) score 999 or sacrifice child"
+	v, ok = parseKernelLogOOMKill(line, 0)
+	if !ok || v.Pid != 17 || v.Name != "my proc" {
+		t.Errorf("could not parse older OOM kill line: %v", v)
+	}
+
+	line = "<3>[ 5234.567893] Out of memory: Killed process 99 (kworker (x)) total-vm:0kB, anon-rss:0kB, file-rss:0kB"
+	v, ok = parseKernelLogOOMKill(line, 0)
+	if !ok || v.Pid != 99 || v.Name != "kworker (x)" {
+		t.Errorf("could not parse OOM kill line of a name with parentheses: %v", v)
+	}
+
+	// without printk.time
+	v, ok = parseKernelLogOOMKill("<3>Out of memory: Killed process 5 (a) total-vm:0kB, anon-rss:0kB", 1500000000)
+	if !ok || v.Pid != 5 || v.Timestamp != 0 {
+		t.Errorf("could not parse OOM kill line without timestamp: %v", v)
+	}
+
+	if _, ok := parseKernelLogOOMKill("<6>[ 5234.567892] eth0: link up", 0); ok {
+		t.Error("unrelated line parsed as OOM kill")
+	}
+}
+
+func TestParseKernelLogOOMKills(t *testing.T) {
+	var lines []string
+	for i := 0; i < maxOOMKills+10; i++ {
+		lines = append(lines, fmt.Sprintf("<3>[    1.000000] Out of memory: Killed process %d (p) total-vm:0kB, anon-rss:0kB", i))
+		lines = append(lines, "<6>[    1.000000] eth0: link up")
+	}
+	v := parseKernelLogOOMKills(strings.Join(lines, "\n")+"\n", 0)
+	// the most recent events are kept
+	if len(v) != maxOOMKills || v[0].Pid != 10 || v[len(v)-1].Pid != maxOOMKills+9 {
+		t.Errorf("wrong OOM kills kept: %d events, first %v", len(v), v[0])
+	}
+}
+
+func TestTSCReliable(t *testing.T) {
+	cpuinfo := []string{
+		"processor	: 0",
+		"flags		: fpu vme tsc msr constant_tsc nonstop_tsc cpuid",
+		"processor	: 1",
+	}
+	if !tscReliable(cpuinfo) {
+		t.Error("invariant TSC not reported as reliable")
+	}
+	cpuinfo[1] = "flags		: fpu vme tsc msr hypervisor tsc_reliable"
+	if !tscReliable(cpuinfo) {
+		t.Error("tsc_reliable TSC not reported as reliable")
+	}
+	cpuinfo[1] = "flags		: fpu vme tsc msr constant_tsc"
+	if tscReliable(cpuinfo) {
+		t.Error("TSC without nonstop_tsc reported as reliable")
+	}
+}
+
+func TestDNSConfig(t *testing.T) {
+	dir, err := common.FakeTree(map[string]string{
+		"resolv.conf":                 "# This is /run/systemd/resolve/stub-resolv.conf\nnameserver 127.0.0.53\noptions edns0 trust-ad\nsearch corp.example.com example.com\n",
+		"systemd/resolve/resolv.conf": "nameserver 10.0.0.2\nnameserver 10.0.0.3\nsearch corp.example.com\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.Setenv("HOST_ETC", dir)
+	defer os.Unsetenv("HOST_ETC")
+	os.Setenv("HOST_RUN", dir)
+	defer os.Unsetenv("HOST_RUN")
+
+	v, err := DNSConfig()
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if !reflect.DeepEqual(v.Nameservers, []string{"127.0.0.53"}) || !v.Stub {
+		t.Errorf("wrong nameservers: %v", v)
+	}
+	if !reflect.DeepEqual(v.Upstreams, []string{"10.0.0.2", "10.0.0.3"}) {
+		t.Errorf("wrong upstreams: %v", v)
+	}
+	if !reflect.DeepEqual(v.Search, []string{"corp.example.com", "example.com"}) {
+		t.Errorf("wrong search domains: %v", v)
+	}
+	if !reflect.DeepEqual(v.Options, []string{"edns0", "trust-ad"}) {
+		t.Errorf("wrong options: %v", v)
+	}
+}
+
+func TestThreadCount(t *testing.T) {
+	stat := "%s (my (weird) app) %s 1 %s %s 0 -1 4194560\n"
+	dir, err := common.FakeTree(map[string]string{
+		"sys/kernel/threads-max": "126907\n",
+		"1/task/1/stat":          fmt.Sprintf(stat, "1", "S", "1", "1"),
+		"42/task/42/stat":        fmt.Sprintf(stat, "42", "R", "42", "42"),
+		"42/task/43/stat":        fmt.Sprintf(stat, "43", "D", "42", "42"),
+		"42/task/44/stat":        fmt.Sprintf(stat, "44", "S", "42", "42"),
+		"1000/task/1000/stat":    fmt.Sprintf(stat, "1000", "Z", "1000", "1000"),
+		"1001/":                  "",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("HOST_PROC", dir)
+	defer os.Unsetenv("HOST_PROC")
+
+	v, err := ThreadCount()
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if v.Threads != 5 || v.ThreadsMax != 126907 {
+		t.Errorf("wrong thread count: %v", v)
+	}
+	states := map[string]uint64{"R": 1, "S": 2, "D": 1, "Z": 1}
+	if !reflect.DeepEqual(v.States, states) {
+		t.Errorf("wrong thread states: %v, expected %v", v.States, states)
+	}
+}
+
+func TestParseBootTiming(t *testing.T) {
+	out := "FirmwareTimestampMonotonic=12000000\nLoaderTimestampMonotonic=3000000\nInitRDTimestampMonotonic=2500000\nUserspaceTimestampMonotonic=6000000\nFinishTimestampMonotonic=36000000\n"
+	v := &BootTimingStat{}
+	parseBootTiming(out, v)
+	expected := BootTimingStat{Systemd: true, Firmware: 9, Loader: 3, Kernel: 2.5, InitRD: 3.5, Userspace: 30}
+	if *v != expected {
+		t.Errorf("wrong boot timing %v, expected %v", v, expected)
+	}
+
+	v = &BootTimingStat{}
+	parseBootTiming("UserspaceTimestampMonotonic=6000000\nFinishTimestampMonotonic=0\n", v)
+	if v.Systemd {
+		t.Errorf("unfinished boot reported as systemd timing %v", v)
+	}
+}
+
+func TestInotifyLimits(t *testing.T) {
+	dir, err := common.FakeTree(map[string]string{
+		"sys/fs/inotify/max_user_instances": "128\n",
+		"sys/fs/inotify/max_user_watches":   "8192\n",
+		"sys/fs/inotify/max_queued_events":  "16384\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("HOST_PROC", dir)
+	defer os.Unsetenv("HOST_PROC")
+
+	v, err := InotifyLimits()
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if *v != (InotifyLimitsStat{MaxUserInstances: 128, MaxUserWatches: 8192, MaxQueuedEvents: 16384}) {
+		t.Errorf("wrong inotify limits: %v", v)
+	}
+}
+
+func TestPtraceScope(t *testing.T) {
+	dir, err := common.FakeTree(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("HOST_PROC", dir)
+	defer os.Unsetenv("HOST_PROC")
+
+	if _, err := PtraceScope(); err != common.ErrNotImplementedError {
+		t.Errorf("expected ErrNotImplementedError without yama, got %v", err)
+	}
+
+	if err := common.WriteTree(dir, map[string]string{"sys/kernel/yama/ptrace_scope": "1\n"}); err != nil {
+		t.Fatal(err)
+	}
+	v, err := PtraceScope()
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if v != 1 {
+		t.Errorf("wrong ptrace_scope %d", v)
+	}
+}
+
+func TestNICIRQAffinity(t *testing.T) {
+	device := "sys/class/net/eth0/device/"
+	dir, err := common.FakeTree(map[string]string{
+		"sys/devices/system/node/node0/cpulist": "0-1\n",
+		"sys/devices/system/node/node1/cpulist": "2-3\n",
+		device + "msi_irqs/34/":                 "",
+		device + "msi_irqs/35/":                 "",
+		device + "numa_node":                    "0\n",
+		"proc/irq/34/smp_affinity_list":         "1\n",
+		"proc/irq/35/smp_affinity_list":         "1-2\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("HOST_SYS", filepath.Join(dir, "sys"))
+	defer os.Unsetenv("HOST_SYS")
+	os.Setenv("HOST_PROC", filepath.Join(dir, "proc"))
+	defer os.Unsetenv("HOST_PROC")
+
+	nodes, err := NUMANodeCPUs()
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	expectedNodes := []NUMANodeStat{{Node: 0, CPUs: []int{0, 1}}, {Node: 1, CPUs: []int{2, 3}}}
+	if !reflect.DeepEqual(nodes, expectedNodes) {
+		t.Errorf("wrong numa nodes %v", nodes)
+	}
+
+	v, err := NICIRQAffinity("eth0")
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	expected := &NICAffinityStat{
+		Name:       "eth0",
+		DeviceNode: 0,
+		IRQs: []IRQAffinityStat{
+			{IRQ: 34, CPUs: []int{1}, Nodes: []int{0}},
+			{IRQ: 35, CPUs: []int{1, 2}, Nodes: []int{0, 1}},
+		},
+	}
+	if !reflect.DeepEqual(v, expected) {
+		t.Errorf("wrong nic affinity %v", v)
+	}
+
+	if _, err := NICIRQAffinity("lo"); err == nil {
+		t.Error("expected error for interface without device")
+	}
+}
+
+func TestOpenFileSummary(t *testing.T) {
+	f, err := ioutil.TempFile("", "gopsutil-host")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	f.Write(make([]byte, 4096))
+	os.Remove(f.Name())
+
+	v, err := OpenFileSummary()
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if v.Allocated == 0 || v.Max == 0 || v.ProcessFDs == 0 {
+		t.Errorf("expected open files, got %v", v)
+	}
+	if v.Deleted < 1 || v.DeletedBytes < 4096 {
+		t.Errorf("expected the deleted temp file to be found, got %v", v)
+	}
+}
+
+func TestFirmware(t *testing.T) {
+	dmi := "class/dmi/id/"
+	dir, err := common.FakeTree(map[string]string{
+		dmi + "bios_vendor":    "SeaBIOS\n",
+		dmi + "bios_version":   "1.13.0-1ubuntu1.1\n",
+		dmi + "bios_date":      "04/01/2014\n",
+		dmi + "sys_vendor":     "QEMU\n",
+		dmi + "product_name":   "Standard PC (i440FX + PIIX, 1996)\n",
+		dmi + "product_serial": "secret\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// product_serial is only readable by root
+	if err := os.Chmod(filepath.Join(dir, dmi, "product_serial"), 0000); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("HOST_SYS", dir)
+	defer os.Unsetenv("HOST_SYS")
+
+	v, err := Firmware()
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	expected := FirmwareStat{
+		BIOSVendor:  "SeaBIOS",
+		BIOSVersion: "1.13.0-1ubuntu1.1",
+		BIOSDate:    "04/01/2014",
+		SysVendor:   "QEMU",
+		ProductName: "Standard PC (i440FX + PIIX, 1996)",
+	}
+	if os.Geteuid() == 0 {
+		expected.ProductSerial = "secret"
+	}
+	if *v != expected {
+		t.Errorf("wrong firmware %v", v)
+	}
+}
+
+func TestSensorsTemperatures(t *testing.T) {
+	coretemp := "devices/platform/coretemp.0/hwmon/hwmon0/"
+	nvme0 := "devices/pci0000:00/nvme/nvme0/"
+	nvme1 := "devices/pci0000:01/nvme/nvme1/"
+	dir, err := common.FakeTree(map[string]string{
+		coretemp + "name":                 "coretemp\n",
+		coretemp + "temp1_input":          "45000\n",
+		coretemp + "temp1_label":          "Core 0\n",
+		nvme0 + "hwmon1/name":             "nvme\n",
+		nvme0 + "hwmon1/temp1_input":      "38850\n",
+		nvme0 + "hwmon1/temp1_label":      "Composite\n",
+		nvme1 + "device/hwmon2/name":      "nvme\n",
+		nvme1 + "device/hwmon2/temp1_max": "84850\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// class entries are links to the device directories
+	if err := common.SymlinkTree(dir, map[string]string{
+		"class/hwmon/hwmon0": coretemp,
+		"class/hwmon/hwmon1": nvme0 + "hwmon1",
+		"class/hwmon/hwmon2": nvme1 + "device/hwmon2",
+		"class/nvme/nvme0":   nvme0,
+		"class/nvme/nvme1":   nvme1,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("HOST_SYS", dir)
+	defer os.Unsetenv("HOST_SYS")
+
+	v, err := SensorsTemperatures()
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	expected := []TemperatureStat{
+		{SensorKey: "nvme0_composite", Temperature: 38.85},
+		{SensorKey: "coretemp_core_0", Temperature: 45},
+	}
+	if !reflect.DeepEqual(v, expected) {
+		t.Errorf("wrong temperatures %v", v)
+	}
+}
+
+func TestBootTimeCache(t *testing.T) {
+	dir, err := common.FakeTree(map[string]string{"stat": "cpu  1 2 3 4\nbtime 1500000000\n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("HOST_PROC", dir)
+	defer os.Unsetenv("HOST_PROC")
+	RefreshBootTime()
+	defer RefreshBootTime()
+
+	if v, err := BootTime(); err != nil || v != 1500000000 {
+		t.Fatalf("wrong boot time %v, error %v", v, err)
+	}
+	if err := common.WriteTree(dir, map[string]string{"stat": "cpu  1 2 3 4\nbtime 1500000100\n"}); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := BootTime(); v != 1500000000 {
+		t.Errorf("boot time was not cached: %v", v)
+	}
+	if v, err := BootTimeWithContext(context.Background()); err != nil || v != 1500000100 {
+		t.Errorf("wrong boot time %v, error %v", v, err)
+	}
+	if err := common.WriteTree(dir, map[string]string{"stat": "cpu  1 2 3 4\nbtime 1500000200\n"}); err != nil {
+		t.Fatal(err)
+	}
+	RefreshBootTime()
+	if v, _ := BootTime(); v != 1500000200 {
+		t.Errorf("boot time was not refreshed: %v", v)
+	}
+}
+
+func TestParseUtmp(t *testing.T) {
+	dir, err := common.FakeTree(map[string]string{"1200/": ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("HOST_PROC", dir)
+	defer os.Unsetenv("HOST_PROC")
+
+	record := func(size int, typ int16, pid int32, line, user, host string, sec int64) []byte {
+		b := make([]byte, size)
+		binary.LittleEndian.PutUint16(b[0:], uint16(typ))
+		binary.LittleEndian.PutUint32(b[4:], uint32(pid))
+		copy(b[8:40], line)
+		copy(b[44:76], user)
+		copy(b[76:332], host)
+		if size == utmpSize32 {
+			binary.LittleEndian.PutUint32(b[340:], uint32(sec))
+		} else {
+			binary.LittleEndian.PutUint64(b[344:], uint64(sec))
+		}
+		return b
+	}
+	for _, size := range []int{utmpSize32, utmpSize64} {
+		var buf []byte
+		// boot time, runlevel, login of a live and of an exited session
+		buf = append(buf, record(size, 2, 0, "~", "reboot", "5.15.0", 1500000000)...)
+		buf = append(buf, record(size, 1, 53, "~", "runlevel", "5.15.0", 1500000001)...)
+		buf = append(buf, record(size, USER_PROCESS, 1200, "pts/0", "alice", "10.0.0.1", 1500000100)...)
+		buf = append(buf, record(size, USER_PROCESS, 1300, "pts/1", "bob", "10.0.0.2", 1500000200)...)
+
+		v := parseUtmp(buf)
+		expected := []UserStat{{User: "alice", Terminal: "pts/0", Host: "10.0.0.1", Started: 1500000100}}
+		if !reflect.DeepEqual(v, expected) {
+			t.Errorf("wrong users for %d bytes records %v, expected %v", size, v, expected)
+		}
+	}
+}
+
+func TestVirtualization(t *testing.T) {
+	dir, err := common.FakeTree(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, env := range []string{"HOST_PROC", "HOST_SYS", "HOST_ETC"} {
+		os.Setenv(env, dir)
+		defer os.Unsetenv(env)
+	}
+
+	cases := []struct {
+		file, contents string
+		system, role   string
+	}{
+		{"class/dmi/id/sys_vendor", "Firecracker\n", "firecracker", "guest"},
+		{"sys/kernel/osrelease", "5.15.90.1-microsoft-standard-WSL2\n", "wsl2", "guest"},
+		// the container takes precedence over the hypervisor
+		{"1/environ", "PATH=/sbin\x00container=lxc\x00", "lxc", "guest"},
+	}
+	for _, c := range cases {
+		if err := common.WriteTree(dir, map[string]string{c.file: c.contents}); err != nil {
+			t.Fatal(err)
+		}
+		cachedVirtualization.done = false
+		system, role, err := Virtualization()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if system != c.system || role != c.role {
+			t.Errorf("%s: got %s/%s, expected %s/%s", c.file, system, role, c.system, c.role)
+		}
+	}
+
+	// cached until the host changes
+	if err := os.Remove(filepath.Join(dir, "1", "environ")); err != nil {
+		t.Fatal(err)
+	}
+	system, _, _ := VirtualizationWithContext(context.Background())
+	if system != "lxc" {
+		t.Errorf("expected cached lxc, got %s", system)
+	}
+	cachedVirtualization.done = false
+}
@@ -153,3 +153,52 @@ func Users() ([]UserStat, error) {
 
 	return ret, nil
 }
+
+func RecentOOMKills() ([]OOMKillStat, error) {
+	return []OOMKillStat{}, common.ErrNotImplementedError
+}
+
+func ClockSource() (*ClockSourceStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+// DNSConfig returns the resolver configuration read from /etc/resolv.conf.
+func DNSConfig() (*DNSConfigStat, error) {
+	return readResolvConf(common.HostEtc("resolv.conf"))
+}
+
+func ThreadCount() (*ThreadCountStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func BootTiming() (*BootTimingStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func InotifyLimits() (*InotifyLimitsStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func PtraceScope() (int, error) {
+	return 0, common.ErrNotImplementedError
+}
+
+func NUMANodeCPUs() ([]NUMANodeStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func NICIRQAffinity(iface string) (*NICAffinityStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func OpenFileSummary() (*OpenFileSummaryStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func Firmware() (*FirmwareStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func SensorsTemperatures() ([]TemperatureStat, error) {
+	return []TemperatureStat{}, common.ErrNotImplementedError
+}
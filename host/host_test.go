@@ -2,6 +2,7 @@ package host
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -47,6 +48,19 @@ func TestBoot_time(t *testing.T) {
 	}
 }
 
+func TestFQDN(t *testing.T) {
+	v, qualified, err := FQDN()
+	if err != nil {
+		t.Errorf("error %v", err)
+	}
+	if v == "" {
+		t.Errorf("Could not get FQDN %v", v)
+	}
+	if qualified != strings.Contains(v, ".") {
+		t.Errorf("FQDN %v is not consistent with qualified flag %v", v, qualified)
+	}
+}
+
 func TestHostInfoStat_String(t *testing.T) {
 	v := InfoStat{
 		Hostname: "test",
@@ -28,6 +28,18 @@ type Win32_OperatingSystem struct {
 	LastBootUpTime time.Time
 }
 
+type Win32_BIOS struct {
+	Manufacturer      string
+	SMBIOSBIOSVersion string
+	ReleaseDate       time.Time
+}
+
+type Win32_ComputerSystemProduct struct {
+	Vendor            string
+	Name              string
+	IdentifyingNumber string
+}
+
 func Info() (*InfoStat, error) {
 	ret := &InfoStat{
 		OS:      runtime.GOOS,
@@ -91,16 +103,20 @@ func bootTime(up uint64) uint64 {
 	return uint64(time.Now().Unix()) - up
 }
 
+// BootTime returns the system boot time expressed in seconds since the epoch.
+// It is computed from the uptime and cached until RefreshBootTime is called
+// or the wall clock drifts from the monotonic clock by more than
+// bootTimeMaxDrift, as the computed value moves when the clock is set.
 func BootTime() (uint64, error) {
-	if cachedBootTime != 0 {
-		return cachedBootTime, nil
-	}
+	return cachedBootTime.get(readBootTime)
+}
+
+func readBootTime() (uint64, error) {
 	up, err := Uptime()
 	if err != nil {
 		return 0, err
 	}
-	cachedBootTime = bootTime(up)
-	return cachedBootTime, nil
+	return bootTime(up), nil
 }
 
 func PlatformInformation() (platform string, family string, version string, err error) {
@@ -130,9 +146,83 @@ func PlatformInformation() (platform string, family string, version string, err
 	return
 }
 
+func Virtualization() (string, string, error) {
+	return "", "", common.ErrNotImplementedError
+}
+
 func Users() ([]UserStat, error) {
 
 	var ret []UserStat
 
 	return ret, nil
 }
+
+func RecentOOMKills() ([]OOMKillStat, error) {
+	return []OOMKillStat{}, common.ErrNotImplementedError
+}
+
+func ClockSource() (*ClockSourceStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func DNSConfig() (*DNSConfigStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func ThreadCount() (*ThreadCountStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func BootTiming() (*BootTimingStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func InotifyLimits() (*InotifyLimitsStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func PtraceScope() (int, error) {
+	return 0, common.ErrNotImplementedError
+}
+
+func NUMANodeCPUs() ([]NUMANodeStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func NICIRQAffinity(iface string) (*NICAffinityStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func OpenFileSummary() (*OpenFileSummaryStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+// Firmware returns the BIOS vendor, version and date from Win32_BIOS, and
+// the system vendor, product name and serial number from
+// Win32_ComputerSystemProduct.
+func Firmware() (*FirmwareStat, error) {
+	var bios []Win32_BIOS
+	if err := wmi.Query(wmi.CreateQuery(&bios, ""), &bios); err != nil {
+		return nil, err
+	}
+	ret := &FirmwareStat{}
+	if len(bios) > 0 {
+		ret.BIOSVendor = bios[0].Manufacturer
+		ret.BIOSVersion = bios[0].SMBIOSBIOSVersion
+		if !bios[0].ReleaseDate.IsZero() {
+			ret.BIOSDate = bios[0].ReleaseDate.Format("01/02/2006")
+		}
+	}
+
+	var products []Win32_ComputerSystemProduct
+	if err := wmi.Query(wmi.CreateQuery(&products, ""), &products); err == nil && len(products) > 0 {
+		ret.SysVendor = products[0].Vendor
+		ret.ProductName = products[0].Name
+		ret.ProductSerial = products[0].IdentifyingNumber
+	}
+	return ret, nil
+}
+
+func SensorsTemperatures() ([]TemperatureStat, error) {
+	return []TemperatureStat{}, common.ErrNotImplementedError
+}
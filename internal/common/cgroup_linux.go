@@ -0,0 +1,105 @@
+// +build linux
+
+package common
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ReadCgroupPaths reads /proc/(pid)/cgroup, see ParseCgroupPaths. pid may
+// be "self".
+func ReadCgroupPaths(pid string) (map[string]string, error) {
+	lines, err := ReadLines(HostProc(pid, "cgroup"))
+	if err != nil {
+		return nil, err
+	}
+	return ParseCgroupPaths(lines), nil
+}
+
+// ParseCgroupPaths parses /proc/(pid)/cgroup into a map of controller to
+// cgroup path. The cgroup v2 hierarchy has an empty controller name.
+func ParseCgroupPaths(lines []string) map[string]string {
+	ret := make(map[string]string)
+	for _, line := range lines {
+		// hierarchy-ID:controller-list:cgroup-path
+		fields := strings.SplitN(line, ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		if fields[1] == "" {
+			ret[""] = fields[2]
+			continue
+		}
+		for _, controller := range strings.Split(fields[1], ",") {
+			ret[controller] = fields[2]
+		}
+	}
+	return ret
+}
+
+// CgroupMountpoint returns where the hierarchy of a cgroup v1 controller
+// is mounted, or the cgroup v2 hierarchy if controller is empty.
+func CgroupMountpoint(controller string) string {
+	return HostSys("fs/cgroup", controller)
+}
+
+// CgroupDir returns the directory of a cgroup under mountpoint. Inside a
+// container without its own cgroup namespace, the cgroup of the container
+// is mounted as the root of the hierarchy, so the root is used when the
+// full path does not exist.
+func CgroupDir(mountpoint, path string) string {
+	dir := filepath.Join(mountpoint, path)
+	if !PathExists(dir) {
+		return mountpoint
+	}
+	return dir
+}
+
+// ParseCPUMax parses a cgroup v2 cpu.max line, ex: "50000 100000" or
+// "max 100000", into a number of CPUs. limited is false if no quota is set.
+func ParseCPUMax(line string) (quota float64, limited bool, err error) {
+	fields := strings.Fields(line)
+	if len(fields) != 2 {
+		return 0, false, fmt.Errorf("wrong cpu.max format: %s", line)
+	}
+	if fields[0] == "max" {
+		return 0, false, nil
+	}
+	q, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, false, err
+	}
+	period, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return 0, false, err
+	}
+	if q <= 0 || period <= 0 {
+		return 0, false, nil
+	}
+	return q / period, true, nil
+}
+
+// ReadCgroupStat reads a flat keyed cgroup file such as cpu.stat or
+// memory.stat.
+func ReadCgroupStat(filename string) (map[string]uint64, error) {
+	lines, err := ReadLines(filename)
+	if err != nil {
+		return nil, err
+	}
+	ret := make(map[string]uint64)
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		ret[fields[0]] = v
+	}
+	return ret, nil
+}
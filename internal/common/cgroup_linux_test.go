@@ -0,0 +1,53 @@
+// +build linux
+
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseCgroupPaths(t *testing.T) {
+	paths := ParseCgroupPaths([]string{
+		"4:memory:/docker/abc",
+		"2:cpu,cpuacct:/docker/abc",
+		"0::/system.slice/docker-abc.scope",
+	})
+	if paths["cpu"] != "/docker/abc" || paths["cpuacct"] != "/docker/abc" || paths["memory"] != "/docker/abc" {
+		t.Errorf("wrong cgroup v1 paths %v", paths)
+	}
+	if paths[""] != "/system.slice/docker-abc.scope" {
+		t.Errorf("wrong cgroup v2 path %v", paths)
+	}
+}
+
+func TestCgroupDir(t *testing.T) {
+	dir, err := FakeTree(map[string]string{"docker/abc/": ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if v := CgroupDir(dir, "/docker/abc"); v != filepath.Join(dir, "docker/abc") {
+		t.Errorf("wrong cgroup dir %v", v)
+	}
+	// the cgroup of a container is the root of its own mount
+	if v := CgroupDir(dir, "/docker/def"); v != dir {
+		t.Errorf("expected the mount point, got %v", v)
+	}
+}
+
+func TestParseCPUMax(t *testing.T) {
+	quota, limited, err := ParseCPUMax("50000 100000")
+	if err != nil || !limited || quota != 0.5 {
+		t.Errorf("wrong cpu.max %v %v %v", quota, limited, err)
+	}
+	_, limited, err = ParseCPUMax("max 100000")
+	if err != nil || limited {
+		t.Errorf("expected no quota, got %v %v", limited, err)
+	}
+	if _, _, err = ParseCPUMax("max"); err == nil {
+		t.Error("expected error for a malformed cpu.max")
+	}
+}
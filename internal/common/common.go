@@ -9,7 +9,9 @@ package common
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"net/url"
@@ -21,6 +23,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -31,6 +34,7 @@ var (
 
 type Invoker interface {
 	Command(string, ...string) ([]byte, error)
+	CommandWithContext(context.Context, string, ...string) ([]byte, error)
 }
 
 type Invoke struct{}
@@ -40,6 +44,17 @@ func (i Invoke) Command(name string, arg ...string) ([]byte, error) {
 	return CombinedOutputTimeout(cmd, Timeout)
 }
 
+// CommandWithContext runs the command as Command does, killing it as soon
+// as ctx is done, in which case ctx.Err() is returned.
+func (i Invoke) CommandWithContext(ctx context.Context, name string, arg ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, arg...)
+	out, err := CombinedOutputTimeout(cmd, Timeout)
+	if ctx.Err() != nil {
+		return out, ctx.Err()
+	}
+	return out, err
+}
+
 type FakeInvoke struct {
 	CommandExpectedDir string // CommandExpectedDir specifies dir which includes expected outputs.
 	Suffix             string // Suffix species expected file name suffix such as "fail"
@@ -72,6 +87,13 @@ func (i FakeInvoke) Command(name string, arg ...string) ([]byte, error) {
 	return exec.Command(name, arg...).Output()
 }
 
+func (i FakeInvoke) CommandWithContext(ctx context.Context, name string, arg ...string) ([]byte, error) {
+	if ctx.Err() != nil {
+		return []byte{}, ctx.Err()
+	}
+	return i.Command(name, arg...)
+}
+
 var ErrNotImplementedError = errors.New("not implemented yet")
 
 // ReadLines reads contents from a file and splits them by new lines.
@@ -278,6 +300,34 @@ func attributes(m interface{}) map[string]reflect.Type {
 	return attrs
 }
 
+// ParseCPUList parses a list of CPUs or NUMA nodes in the kernel cpulist
+// format, ex: "0-7,16-23" or "0,2,4-5".
+func ParseCPUList(list string) ([]int, error) {
+	ret := []int{}
+	list = strings.TrimSpace(list)
+	if list == "" {
+		return ret, nil
+	}
+	for _, r := range strings.Split(list, ",") {
+		bounds := strings.SplitN(r, "-", 2)
+		first, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, fmt.Errorf("wrong cpulist format: %s", list)
+		}
+		last := first
+		if len(bounds) == 2 {
+			last, err = strconv.Atoi(bounds[1])
+			if err != nil || last < first {
+				return nil, fmt.Errorf("wrong cpulist format: %s", list)
+			}
+		}
+		for cpu := first; cpu <= last; cpu++ {
+			ret = append(ret, cpu)
+		}
+	}
+	return ret, nil
+}
+
 func PathExists(filename string) bool {
 	if _, err := os.Stat(filename); err == nil {
 		return true
@@ -291,7 +341,10 @@ func GetEnv(key string, dfault string, combineWith ...string) string {
 	if value == "" {
 		value = dfault
 	}
+	return combinePath(value, combineWith...)
+}
 
+func combinePath(value string, combineWith ...string) string {
 	switch len(combineWith) {
 	case 0:
 		return value
@@ -306,7 +359,27 @@ func GetEnv(key string, dfault string, combineWith ...string) string {
 	panic("invalid switch case")
 }
 
+var (
+	hostProcMu sync.RWMutex
+	hostProc   string
+)
+
+// SetHostProc sets the root of the proc filesystem returned by HostProc,
+// such as a copy of /proc from another machine. It takes precedence over
+// the HOST_PROC environment variable, an empty path restores it.
+func SetHostProc(path string) {
+	hostProcMu.Lock()
+	hostProc = path
+	hostProcMu.Unlock()
+}
+
 func HostProc(combineWith ...string) string {
+	hostProcMu.RLock()
+	root := hostProc
+	hostProcMu.RUnlock()
+	if root != "" {
+		return combinePath(root, combineWith...)
+	}
 	return GetEnv("HOST_PROC", "/proc", combineWith...)
 }
 
@@ -318,6 +391,10 @@ func HostEtc(combineWith ...string) string {
 	return GetEnv("HOST_ETC", "/etc", combineWith...)
 }
 
+func HostRun(combineWith ...string) string {
+	return GetEnv("HOST_RUN", "/run", combineWith...)
+}
+
 // CombinedOutputTimeout runs the given command with the given timeout and
 // returns the combined output of stdout and stderr.
 // If the command times out, it attempts to kill the process.
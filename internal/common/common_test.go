@@ -1,9 +1,15 @@
 package common
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestReadlines(t *testing.T) {
@@ -95,3 +101,86 @@ func TestHostEtc(t *testing.T) {
 		t.Errorf("invalid HostEtc, %s", p)
 	}
 }
+
+func TestHostRun(t *testing.T) {
+	p := HostRun("systemd", "resolve", "resolv.conf")
+	if p != "/run/systemd/resolve/resolv.conf" {
+		t.Errorf("invalid HostRun, %s", p)
+	}
+}
+
+func TestSetHostProc(t *testing.T) {
+	os.Setenv("HOST_PROC", "/host/proc")
+	defer os.Unsetenv("HOST_PROC")
+	SetHostProc("/mnt/crash/proc")
+	p := HostProc("1", "stat")
+	SetHostProc("")
+	if p != "/mnt/crash/proc/1/stat" {
+		t.Errorf("invalid HostProc, %s", p)
+	}
+	if p := HostProc("1", "stat"); p != "/host/proc/1/stat" {
+		t.Errorf("invalid HostProc, %s", p)
+	}
+}
+
+func TestParseCPUList(t *testing.T) {
+	cases := map[string][]int{
+		"0-3,8-9\n": {0, 1, 2, 3, 8, 9},
+		"0,2,4-5":   {0, 2, 4, 5},
+		"7":         {7},
+		"":          {},
+	}
+	for list, expected := range cases {
+		v, err := ParseCPUList(list)
+		if err != nil {
+			t.Fatalf("error %v", err)
+		}
+		if !reflect.DeepEqual(v, expected) {
+			t.Errorf("wrong cpus for %q: %v", list, v)
+		}
+	}
+	for _, list := range []string{"a-b", "3-1", "1,,2"} {
+		if _, err := ParseCPUList(list); err == nil {
+			t.Errorf("expected error for %q", list)
+		}
+	}
+}
+
+func TestCommandWithContext(t *testing.T) {
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep not available")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = Invoke{}.CommandWithContext(ctx, sleep, "2")
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("command not killed on deadline, took %v", d)
+	}
+}
+
+func TestFakeTree(t *testing.T) {
+	dir, err := FakeTree(map[string]string{
+		"1/stat":      "1 (init) S\n",
+		"sys/kernel/": "",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := SymlinkTree(dir, map[string]string{"self": "1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	lines, err := ReadLines(filepath.Join(dir, "self", "stat"))
+	if err != nil || !reflect.DeepEqual(lines, []string{"1 (init) S"}) {
+		t.Errorf("wrong content through the link: %v, %v", lines, err)
+	}
+	if fi, err := os.Stat(filepath.Join(dir, "sys", "kernel")); err != nil || !fi.IsDir() {
+		t.Errorf("empty directory not created: %v", err)
+	}
+}
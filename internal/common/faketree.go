@@ -0,0 +1,61 @@
+package common
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FakeTree creates the files of a fake /proc, /sys or /etc tree in a new
+// temporary directory and returns the directory, see WriteTree. It is used
+// by the tests pointing HOST_PROC, HOST_SYS or HOST_ETC at such a tree; the
+// caller removes the directory.
+func FakeTree(files map[string]string) (string, error) {
+	dir, err := ioutil.TempDir("", "gopsutil")
+	if err != nil {
+		return "", err
+	}
+	if err := WriteTree(dir, files); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
+}
+
+// WriteTree writes files under dir, keyed by their slash separated path,
+// creating their parent directories. A path ending with a slash creates an
+// empty directory.
+func WriteTree(dir string, files map[string]string) error {
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if strings.HasSuffix(name, "/") {
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SymlinkTree creates links under dir, keyed by their slash separated path,
+// to targets relative to dir, as /sys/class/block links to /sys/devices.
+func SymlinkTree(dir string, links map[string]string) error {
+	for name, target := range links {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.Symlink(filepath.Join(dir, filepath.FromSlash(target)), path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package common
+
+import "time"
+
+// Sampler gathers delta based metrics, such as CPU usage or disk and network
+// rates, which are computed from two snapshots of monotonic counters.
+// Snapshot returns the current value of the counters and Rate computes the
+// metric from two snapshots taken interval apart.
+type Sampler interface {
+	Snapshot() (interface{}, error)
+	Rate(prev, cur interface{}, interval time.Duration) (interface{}, error)
+}
+
+// Sample takes a snapshot with s, waits for interval, takes another one and
+// returns the rate between the two.
+func Sample(s Sampler, interval time.Duration) (interface{}, error) {
+	prev, err := s.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	time.Sleep(interval)
+	cur, err := s.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	return s.Rate(prev, cur, interval)
+}
+
+// CounterDelta returns the increase of a counter between prev and cur.
+// A counter which went backwards is assumed to have been reset, after an
+// interface flap or a container restart, and cur is returned. Counters are
+// 64 bits, and a 32 bit wrap can not be told from a reset with a small
+// prev, which would be reported as a spike of about 4.29e9.
+func CounterDelta(prev, cur uint64) uint64 {
+	if cur >= prev {
+		return cur - prev
+	}
+	return cur
+}
+
+// CounterRate returns the increase per second of a counter between prev
+// and cur, see CounterDelta.
+func CounterRate(prev, cur uint64, interval time.Duration) float64 {
+	if interval <= 0 {
+		return 0
+	}
+	return float64(CounterDelta(prev, cur)) / interval.Seconds()
+}
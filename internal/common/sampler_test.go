@@ -0,0 +1,67 @@
+package common
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCounterDelta(t *testing.T) {
+	cases := []struct {
+		prev, cur, expected uint64
+	}{
+		{100, 150, 50},
+		{100, 100, 0},
+		{100, 20, 20},                  // reset with a small prev
+		{math.MaxUint32 + 100, 20, 20}, // reset with a large prev
+		{math.MaxUint64 - 1, math.MaxUint64, 1},
+	}
+	for _, c := range cases {
+		if d := CounterDelta(c.prev, c.cur); d != c.expected {
+			t.Errorf("CounterDelta(%d, %d) = %d, expected %d", c.prev, c.cur, d, c.expected)
+		}
+	}
+}
+
+func TestCounterRate(t *testing.T) {
+	if r := CounterRate(100, 300, 2*time.Second); r != 100 {
+		t.Errorf("wrong rate %v", r)
+	}
+	if r := CounterRate(100, 300, 0); r != 0 {
+		t.Errorf("wrong rate for empty interval %v", r)
+	}
+}
+
+type fakeSampler struct {
+	values []uint64
+	err    error
+}
+
+func (s *fakeSampler) Snapshot() (interface{}, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	v := s.values[0]
+	s.values = s.values[1:]
+	return v, nil
+}
+
+func (s *fakeSampler) Rate(prev, cur interface{}, interval time.Duration) (interface{}, error) {
+	return CounterRate(prev.(uint64), cur.(uint64), interval), nil
+}
+
+func TestSample(t *testing.T) {
+	v, err := Sample(&fakeSampler{values: []uint64{10, 20}}, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if v.(float64) != 1000 {
+		t.Errorf("wrong sampled rate %v", v)
+	}
+
+	failure := errors.New("snapshot failed")
+	if _, err := Sample(&fakeSampler{err: failure}, 0); err != failure {
+		t.Errorf("expected snapshot error, got %v", err)
+	}
+}
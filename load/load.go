@@ -1,6 +1,7 @@
 package load
 
 import (
+	"context"
 	"encoding/json"
 
 	"github.com/DataDog/gopsutil/internal/common"
@@ -18,6 +19,14 @@ type AvgStat struct {
 	Load15 float64 `json:"load15"`
 }
 
+// AvgWithContext returns Avg unless ctx is already done.
+func AvgWithContext(ctx context.Context) (*AvgStat, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return Avg()
+}
+
 func (l AvgStat) String() string {
 	s, _ := json.Marshal(l)
 	return string(s)
@@ -33,3 +42,16 @@ func (m MiscStat) String() string {
 	s, _ := json.Marshal(m)
 	return string(s)
 }
+
+// ContributorsStat breaks the load average down into the processes
+// contributing to it.
+type ContributorsStat struct {
+	Running         int      `json:"running"`         // processes in R state
+	Uninterruptible int      `json:"uninterruptible"` // processes in D state
+	Blocked         []string `json:"blocked"`         // most frequent D state process names
+}
+
+func (c ContributorsStat) String() string {
+	s, _ := json.Marshal(c)
+	return string(s)
+}
@@ -65,3 +65,12 @@ func Misc() (*MiscStat, error) {
 
 	return &ret, nil
 }
+
+func Contributors() (*ContributorsStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+// ContainerAvg returns Avg, cgroup CPU quotas only apply on Linux.
+func ContainerAvg() (*AvgStat, error) {
+	return Avg()
+}
@@ -11,3 +11,12 @@ func Avg() (*AvgStat, error) {
 func Misc() (*MiscStat, error) {
 	return nil, common.ErrNotImplementedError
 }
+
+func Contributors() (*ContributorsStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+// ContainerAvg returns Avg, cgroup CPU quotas only apply on Linux.
+func ContainerAvg() (*AvgStat, error) {
+	return Avg()
+}
@@ -4,8 +4,14 @@ package load
 
 import (
 	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/DataDog/gopsutil/internal/common"
 )
@@ -76,3 +82,207 @@ func Misc() (*MiscStat, error) {
 
 	return ret, nil
 }
+
+// maxBlockedNames is the number of D state process names Contributors reports.
+const maxBlockedNames = 10
+
+// Contributors returns the number of runnable (R) and uninterruptible (D)
+// processes, which are both counted by the Linux load average, and the
+// names of the processes most often found in D state. A high load with an
+// idle CPU is usually explained by the latter.
+func Contributors() (*ContributorsStat, error) {
+	d, err := os.Open(common.HostProc())
+	if err != nil {
+		return nil, err
+	}
+	defer d.Close()
+	fnames, err := d.Readdirnames(-1)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &ContributorsStat{Blocked: []string{}}
+	blocked := make(map[string]int)
+	for _, fname := range fnames {
+		if _, err := strconv.ParseInt(fname, 10, 32); err != nil {
+			continue
+		}
+		// the process may be gone, just skip it
+		contents, err := ioutil.ReadFile(common.HostProc(fname, "stat"))
+		if err != nil {
+			continue
+		}
+		name, state, ok := parseStatState(string(contents))
+		if !ok {
+			continue
+		}
+		switch state {
+		case "R":
+			ret.Running++
+		case "D":
+			ret.Uninterruptible++
+			blocked[name]++
+		}
+	}
+
+	for name := range blocked {
+		ret.Blocked = append(ret.Blocked, name)
+	}
+	sort.Slice(ret.Blocked, func(i, j int) bool {
+		ni, nj := blocked[ret.Blocked[i]], blocked[ret.Blocked[j]]
+		if ni != nj {
+			return ni > nj
+		}
+		return ret.Blocked[i] < ret.Blocked[j]
+	})
+	if len(ret.Blocked) > maxBlockedNames {
+		ret.Blocked = ret.Blocked[:maxBlockedNames]
+	}
+
+	return ret, nil
+}
+
+// parseStatState returns the command name and the state of a
+// /proc/(pid)/stat line. The name may contain spaces and parentheses.
+func parseStatState(line string) (string, string, bool) {
+	start := strings.IndexByte(line, '(')
+	end := strings.LastIndexByte(line, ')')
+	if start == -1 || end < start {
+		return "", "", false
+	}
+	fields := strings.Fields(line[end+1:])
+	if len(fields) == 0 {
+		return "", "", false
+	}
+	return line[start+1 : end], fields[0], true
+}
+
+// cgroupCPUStat is the CPU quota, in CPUs, the cumulated CPU usage, in
+// nanoseconds, and the number of enforcement periods of a cgroup, along
+// with how many of them it was throttled in.
+type cgroupCPUStat struct {
+	quota       float64
+	usage       uint64
+	nrPeriods   uint64
+	nrThrottled uint64
+}
+
+// containerLoad holds the averages computed by ContainerAvg and the
+// counters they were last updated with.
+type containerLoad struct {
+	sync.Mutex
+	avg  AvgStat
+	last cgroupCPUStat
+	time time.Time
+}
+
+var selfContainerLoad containerLoad
+
+// ContainerAvg returns a load average of the cgroup of the calling process
+// relative to its CPU quota, 1 meaning that the container uses all the CPUs
+// it is allowed to. The demand is the share of the quota used plus the
+// share of the enforcement periods the cgroup was throttled in, read from
+// nr_throttled and nr_periods of cpu.stat: a container using its whole
+// quota and throttled in every period has a load of 2, like a host with
+// twice as many runnable tasks as CPUs. The throttled time of cpu.stat is
+// not used, it is wall time summed over the CPUs the cgroup waited on and
+// not bounded by the quota. The demand is averaged over 1, 5 and 15 minutes
+// between calls: ContainerAvg should be called periodically, the first call
+// returns zero averages. Without a CPU quota the host load average of Avg
+// is returned.
+func ContainerAvg() (*AvgStat, error) {
+	c, ok := cgroupCPU()
+	if !ok {
+		return Avg()
+	}
+	selfContainerLoad.Lock()
+	defer selfContainerLoad.Unlock()
+	ret := selfContainerLoad.update(c, time.Now())
+	return &ret, nil
+}
+
+// update decays the averages with the demand since the previous update,
+// like the kernel does for the host load average. The averages restart
+// when the counters went backwards, as for a new cgroup.
+func (l *containerLoad) update(c cgroupCPUStat, now time.Time) AvgStat {
+	last := l.last
+	elapsed := now.Sub(l.time).Seconds()
+	l.last = c
+	l.time = now
+	if last.quota == 0 || c.usage < last.usage || c.nrPeriods < last.nrPeriods || c.nrThrottled < last.nrThrottled {
+		l.avg = AvgStat{}
+		return l.avg
+	}
+	if elapsed <= 0 {
+		return l.avg
+	}
+	demand := float64(c.usage-last.usage) / 1e9 / elapsed / c.quota
+	if periods := c.nrPeriods - last.nrPeriods; periods > 0 {
+		demand += float64(c.nrThrottled-last.nrThrottled) / float64(periods)
+	}
+	decay := func(avg float64, period float64) float64 {
+		e := math.Exp(-elapsed / period)
+		return avg*e + demand*(1-e)
+	}
+	l.avg.Load1 = decay(l.avg.Load1, 60)
+	l.avg.Load5 = decay(l.avg.Load5, 300)
+	l.avg.Load15 = decay(l.avg.Load15, 900)
+	return l.avg
+}
+
+// cgroupCPU returns the CPU quota and counters of the cgroup of the calling
+// process. ok is false if the cgroup does not exist or has no quota.
+func cgroupCPU() (ret cgroupCPUStat, ok bool) {
+	paths, err := common.ReadCgroupPaths("self")
+	if err != nil {
+		return ret, false
+	}
+	if path, ok := paths["cpu"]; ok {
+		return readCgroupV1CPU(common.CgroupDir(common.CgroupMountpoint("cpu"), path))
+	}
+	if path, ok := paths[""]; ok {
+		return readCgroupV2CPU(common.CgroupDir(common.CgroupMountpoint(""), path))
+	}
+	return ret, false
+}
+
+// readCgroupV2CPU reads cpu.max and the usage_usec, nr_periods and
+// nr_throttled fields of cpu.stat.
+func readCgroupV2CPU(dir string) (ret cgroupCPUStat, ok bool) {
+	lines, err := common.ReadLines(filepath.Join(dir, "cpu.max"))
+	if err != nil || len(lines) == 0 {
+		return ret, false
+	}
+	quota, limited, err := common.ParseCPUMax(lines[0])
+	if err != nil || !limited {
+		return ret, false
+	}
+	ret.quota = quota
+	stat, _ := common.ReadCgroupStat(filepath.Join(dir, "cpu.stat"))
+	ret.usage = stat["usage_usec"] * 1000
+	ret.nrPeriods = stat["nr_periods"]
+	ret.nrThrottled = stat["nr_throttled"]
+	return ret, true
+}
+
+// readCgroupV1CPU reads cpu.cfs_quota_us, -1 when there is no quota, and
+// cpu.cfs_period_us, the usage from cpuacct.usage, which is mounted along
+// the cpu controller, and the nr_periods and nr_throttled fields of cpu.stat.
+func readCgroupV1CPU(dir string) (ret cgroupCPUStat, ok bool) {
+	quota, err := common.ReadInts(filepath.Join(dir, "cpu.cfs_quota_us"))
+	if err != nil || len(quota) == 0 || quota[0] <= 0 {
+		return ret, false
+	}
+	period, err := common.ReadInts(filepath.Join(dir, "cpu.cfs_period_us"))
+	if err != nil || len(period) == 0 || period[0] <= 0 {
+		return ret, false
+	}
+	ret.quota = float64(quota[0]) / float64(period[0])
+	if out, err := ioutil.ReadFile(filepath.Join(dir, "cpuacct.usage")); err == nil {
+		ret.usage, _ = strconv.ParseUint(strings.TrimSpace(string(out)), 10, 64)
+	}
+	stat, _ := common.ReadCgroupStat(filepath.Join(dir, "cpu.stat"))
+	ret.nrPeriods = stat["nr_periods"]
+	ret.nrThrottled = stat["nr_throttled"]
+	return ret, true
+}
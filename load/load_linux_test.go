@@ -0,0 +1,104 @@
+// +build linux
+
+package load
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DataDog/gopsutil/internal/common"
+)
+
+func TestContributors(t *testing.T) {
+	dir, err := common.FakeTree(map[string]string{
+		"1/stat":   "1 (systemd) S 0 1 1 0 -1 4194560",
+		"100/stat": "100 (nfsd) D 2 0 0 0 -1 2129984",
+		"101/stat": "101 (nfsd) D 2 0 0 0 -1 2129984",
+		"200/stat": "200 (my (weird) app) D 1 200 200 0 -1 4194304",
+		"300/stat": "300 (stress) R 1 300 300 0 -1 4194304",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("HOST_PROC", dir)
+	defer os.Unsetenv("HOST_PROC")
+
+	v, err := Contributors()
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if v.Running != 1 || v.Uninterruptible != 3 {
+		t.Errorf("wrong number of contributors: %v", v)
+	}
+	if !reflect.DeepEqual(v.Blocked, []string{"nfsd", "my (weird) app"}) {
+		t.Errorf("wrong blocked processes: %v", v.Blocked)
+	}
+}
+
+func TestContainerAvg(t *testing.T) {
+	cgroup := "sys/fs/cgroup/docker/abc/"
+	dir, err := common.FakeTree(map[string]string{
+		"proc/loadavg":      "0.50 0.40 0.30 1/100 42\n",
+		"proc/self/cgroup":  "0::/docker/abc\n",
+		cgroup + "cpu.max":  "max 100000\n",
+		cgroup + "cpu.stat": "usage_usec 1000000\nnr_periods 100\nnr_throttled 10\nthrottled_usec 500000\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("HOST_PROC", filepath.Join(dir, "proc"))
+	defer os.Unsetenv("HOST_PROC")
+	os.Setenv("HOST_SYS", filepath.Join(dir, "sys"))
+	defer os.Unsetenv("HOST_SYS")
+
+	// no quota, host load average
+	v, err := ContainerAvg()
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	if *v != (AvgStat{Load1: 0.5, Load5: 0.4, Load15: 0.3}) {
+		t.Errorf("wrong host load average %v", v)
+	}
+
+	if err := common.WriteTree(dir, map[string]string{cgroup + "cpu.max": "200000 100000\n"}); err != nil {
+		t.Fatal(err)
+	}
+	c, ok := cgroupCPU()
+	if !ok {
+		t.Fatal("cgroup quota not found")
+	}
+	if c != (cgroupCPUStat{quota: 2, usage: 1000000000, nrPeriods: 100, nrThrottled: 10}) {
+		t.Errorf("wrong cgroup cpu %v", c)
+	}
+
+	var l containerLoad
+	now := time.Now()
+	if v := l.update(c, now); v != (AvgStat{}) {
+		t.Errorf("wrong first load average %v", v)
+	}
+	// 1.5 cpus used out of 2 for 5 seconds, throttled in 1 of 4 periods
+	c.usage += 7500000000
+	c.nrPeriods += 48
+	c.nrThrottled += 12
+	v2 := l.update(c, now.Add(5*time.Second))
+	if math.Abs(v2.Load1-(1-math.Exp(-5.0/60))) > 1e-9 || !(v2.Load1 > v2.Load5 && v2.Load5 > v2.Load15) {
+		t.Errorf("wrong load average %v", v2)
+	}
+
+	// throttled in every period, twice the quota is demanded
+	var l2 containerLoad
+	l2.update(c, now)
+	c.usage += 10000000000
+	c.nrPeriods += 50
+	c.nrThrottled += 50
+	v3 := l2.update(c, now.Add(5*time.Second))
+	if math.Abs(v3.Load1-2*(1-math.Exp(-5.0/60))) > 1e-9 {
+		t.Errorf("wrong load average when always throttled %v", v3)
+	}
+}
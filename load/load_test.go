@@ -1,6 +1,7 @@
 package load
 
 import (
+	"context"
 	"fmt"
 	"testing"
 )
@@ -17,6 +18,14 @@ func TestLoad(t *testing.T) {
 	}
 }
 
+func TestAvgWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := AvgWithContext(ctx); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
 func TestLoadAvgStat_String(t *testing.T) {
 	v := AvgStat{
 		Load1:  10.1,
@@ -56,7 +56,14 @@ type VirtualMemoryStat struct {
 	WritebackTmp uint64 `json:"writebacktmp"`
 	Shared       uint64 `json:"shared"`
 	Slab         uint64 `json:"slab"`
+	SReclaimable uint64 `json:"sreclaimable"`
+	SUnreclaim   uint64 `json:"sunreclaim"`
 	PageTables   uint64 `json:"pagetables"`
+
+	// Hugepages of the default size, counted in pages, and that size in bytes
+	HugePagesTotal uint64 `json:"hugepagestotal"`
+	HugePagesFree  uint64 `json:"hugepagesfree"`
+	HugePageSize   uint64 `json:"hugepagesize"`
 }
 
 type SwapMemoryStat struct {
@@ -68,6 +75,76 @@ type SwapMemoryStat struct {
 	Sout        uint64  `json:"sout"`
 }
 
+// BuddyInfoStat is the number of free blocks of each order in a memory zone,
+// as reported by /proc/buddyinfo. A block of order n is 2^n pages.
+type BuddyInfoStat struct {
+	Node       int      `json:"node"`
+	Zone       string   `json:"zone"`
+	FreeBlocks []uint64 `json:"freeBlocks"` // indexed by order
+}
+
+// NUMAHugePagesStat is the number of huge pages of a given size on a NUMA
+// node.
+type NUMAHugePagesStat struct {
+	Node     int    `json:"node"`
+	PageSize uint64 `json:"pageSize"` // bytes
+	Total    uint64 `json:"total"`
+	Free     uint64 `json:"free"`
+}
+
+// SlabInfoStat is the usage of a kernel slab cache, as reported by
+// /proc/slabinfo.
+type SlabInfoStat struct {
+	Name       string `json:"name"`
+	ActiveObjs uint64 `json:"activeObjs"`
+	NumObjs    uint64 `json:"numObjs"`
+	ObjSize    uint64 `json:"objSize"` // bytes
+	Total      uint64 `json:"total"`   // bytes of memory held by the slabs
+}
+
+// SwapDevice is the usage of a swap partition or file, all sizes are in
+// bytes.
+type SwapDevice struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"` // partition or file
+	Size     uint64 `json:"size"`
+	Used     uint64 `json:"used"`
+	Priority int    `json:"priority"`
+}
+
+// CompressedSwapStat describes the compressed swap of the host: the zram
+// block devices, which are usually used as swap devices, and the zswap
+// cache compressing pages before they are written to swap. Zram is empty
+// and Zswap is nil when they are not active.
+type CompressedSwapStat struct {
+	Zram  []ZramStat `json:"zram"`
+	Zswap *ZswapStat `json:"zswap"`
+	// PageCluster is vm.page-cluster, the swap readahead is 2^PageCluster
+	// pages. It is usually set to 0 with compressed swap.
+	PageCluster int `json:"pageCluster"`
+}
+
+// ZramStat is the usage of a zram device, all sizes are in bytes.
+type ZramStat struct {
+	Name             string  `json:"name"`
+	DiskSize         uint64  `json:"diskSize"`
+	OrigDataSize     uint64  `json:"origDataSize"`
+	ComprDataSize    uint64  `json:"comprDataSize"`
+	MemUsedTotal     uint64  `json:"memUsedTotal"` // including the allocator overhead
+	CompressionRatio float64 `json:"compressionRatio"`
+}
+
+// ZswapStat is the configuration and usage of zswap. PoolTotalSize and
+// StoredPages are read from debugfs, which requires root, and are 0 if it
+// is not readable.
+type ZswapStat struct {
+	Compressor     string `json:"compressor"`
+	Zpool          string `json:"zpool"`
+	MaxPoolPercent uint64 `json:"maxPoolPercent"`
+	PoolTotalSize  uint64 `json:"poolTotalSize"` // bytes
+	StoredPages    uint64 `json:"storedPages"`
+}
+
 func (m VirtualMemoryStat) String() string {
 	s, _ := json.Marshal(m)
 	return string(s)
@@ -77,3 +154,63 @@ func (m SwapMemoryStat) String() string {
 	s, _ := json.Marshal(m)
 	return string(s)
 }
+
+func (m NUMAHugePagesStat) String() string {
+	s, _ := json.Marshal(m)
+	return string(s)
+}
+
+func (m SwapDevice) String() string {
+	s, _ := json.Marshal(m)
+	return string(s)
+}
+
+func (m CompressedSwapStat) String() string {
+	s, _ := json.Marshal(m)
+	return string(s)
+}
+
+func (m ZramStat) String() string {
+	s, _ := json.Marshal(m)
+	return string(s)
+}
+
+func (m ZswapStat) String() string {
+	s, _ := json.Marshal(m)
+	return string(s)
+}
+
+func (m SlabInfoStat) String() string {
+	s, _ := json.Marshal(m)
+	return string(s)
+}
+
+func (m BuddyInfoStat) String() string {
+	s, _ := json.Marshal(m)
+	return string(s)
+}
+
+// FreePages returns the total number of free pages in the zone.
+func (m BuddyInfoStat) FreePages() uint64 {
+	var ret uint64
+	for order, n := range m.FreeBlocks {
+		ret += n << uint(order)
+	}
+	return ret
+}
+
+// UnusableIndex returns the fraction of free pages of the zone which can not
+// satisfy an allocation of the given order, between 0 and 1. A value close
+// to 1 means that the zone is too fragmented for such allocations even
+// though it may have plenty of free memory.
+func (m BuddyInfoStat) UnusableIndex(order int) float64 {
+	total := m.FreePages()
+	if total == 0 {
+		return 0
+	}
+	var usable uint64
+	for o := order; o < len(m.FreeBlocks); o++ {
+		usable += m.FreeBlocks[o] << uint(o)
+	}
+	return float64(total-usable) / float64(total)
+}
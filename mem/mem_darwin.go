@@ -67,3 +67,28 @@ func SwapMemory() (*SwapMemoryStat, error) {
 
 	return ret, nil
 }
+
+func Fragmentation() ([]BuddyInfoStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func NUMAHugePages() ([]NUMAHugePagesStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func SlabInfo() ([]SlabInfoStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func CompressedSwap() (*CompressedSwapStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+// VirtualMemoryEx returns VirtualMemory, cgroup limits only apply on Linux.
+func VirtualMemoryEx() (*VirtualMemoryStat, error) {
+	return VirtualMemory()
+}
+
+func SwapDevices() ([]SwapDevice, error) {
+	return nil, common.ErrNotImplementedError
+}
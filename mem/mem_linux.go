@@ -3,6 +3,11 @@
 package mem
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
@@ -10,6 +15,11 @@ import (
 	"github.com/DataDog/gopsutil/internal/common"
 )
 
+// ErrSlabInfoPermission is returned by SlabInfo when /proc/slabinfo is not
+// readable, which is the case for non-root users. The Slab, SReclaimable
+// and SUnreclaim totals of VirtualMemory are readable by anyone.
+var ErrSlabInfoPermission = errors.New("permission denied reading /proc/slabinfo, root is required")
+
 func VirtualMemory() (*VirtualMemoryStat, error) {
 	filename := common.HostProc("meminfo")
 	lines, _ := common.ReadLines(filename)
@@ -56,12 +66,24 @@ func VirtualMemory() (*VirtualMemoryStat, error) {
 			ret.Shared = t * 1024
 		case "Slab":
 			ret.Slab = t * 1024
+		case "SReclaimable":
+			ret.SReclaimable = t * 1024
+		case "SUnreclaim":
+			ret.SUnreclaim = t * 1024
 		case "PageTables":
 			ret.PageTables = t * 1024
+		case "HugePages_Total":
+			ret.HugePagesTotal = t
+		case "HugePages_Free":
+			ret.HugePagesFree = t
+		case "Hugepagesize":
+			ret.HugePageSize = t * 1024
 		}
 	}
 	if !memavail {
-		ret.Available = ret.Free + ret.Buffers + ret.Cached
+		// reclaimable slab, such as the dentry and inode caches, is freed
+		// under memory pressure like the page cache
+		ret.Available = ret.Free + ret.Buffers + ret.Cached + ret.SReclaimable
 	}
 	ret.Used = ret.Total - ret.Available
 	ret.UsedPercent = float64(ret.Total-ret.Available) / float64(ret.Total) * 100.0
@@ -69,6 +91,81 @@ func VirtualMemory() (*VirtualMemoryStat, error) {
 	return ret, nil
 }
 
+// VirtualMemoryEx returns the virtual memory of VirtualMemory, bounded by
+// the memory limit of the cgroup of the calling process, read from
+// memory.limit_in_bytes on cgroup v1 or memory.max on cgroup v2. If the
+// limit is lower than the host memory, as in a container, Total is the
+// limit, Used the usage of the cgroup without its inactive page cache, and
+// Available the memory left before reaching the limit. Otherwise the host
+// memory is returned unchanged.
+func VirtualMemoryEx() (*VirtualMemoryStat, error) {
+	ret, err := VirtualMemory()
+	if err != nil {
+		return nil, err
+	}
+	limit, usage, ok := cgroupMemory()
+	if !ok || limit >= ret.Total {
+		// unlimited cgroups report a limit close to MaxInt64
+		return ret, nil
+	}
+	if usage > limit {
+		usage = limit
+	}
+	ret.Total = limit
+	ret.Used = usage
+	if limit-usage < ret.Available {
+		ret.Available = limit - usage
+	}
+	ret.UsedPercent = float64(ret.Used) / float64(ret.Total) * 100.0
+	return ret, nil
+}
+
+// cgroupMemory returns the memory limit and the usage without inactive
+// page cache of the cgroup of the calling process.
+func cgroupMemory() (limit uint64, usage uint64, ok bool) {
+	paths, err := common.ReadCgroupPaths("self")
+	if err != nil {
+		return 0, 0, false
+	}
+	if path, ok := paths["memory"]; ok {
+		dir := common.CgroupDir(common.CgroupMountpoint("memory"), path)
+		return readCgroupMemory(dir, "memory.limit_in_bytes", "memory.usage_in_bytes", "total_inactive_file")
+	}
+	if path, ok := paths[""]; ok {
+		dir := common.CgroupDir(common.CgroupMountpoint(""), path)
+		return readCgroupMemory(dir, "memory.max", "memory.current", "inactive_file")
+	}
+	return 0, 0, false
+}
+
+// readCgroupMemory reads the limit and usage files of a memory cgroup, and
+// subtracts the inactive field of memory.stat from the usage. ok is false
+// if the cgroup does not exist or has no limit.
+func readCgroupMemory(dir, limitFile, usageFile, inactiveField string) (limit uint64, usage uint64, ok bool) {
+	lines, err := common.ReadLines(filepath.Join(dir, limitFile))
+	if err != nil || len(lines) == 0 {
+		return 0, 0, false
+	}
+	limit, err = strconv.ParseUint(strings.TrimSpace(lines[0]), 10, 64)
+	if err != nil {
+		// "max" on cgroup v2
+		return 0, 0, false
+	}
+	lines, err = common.ReadLines(filepath.Join(dir, usageFile))
+	if err != nil || len(lines) == 0 {
+		return 0, 0, false
+	}
+	usage, err = strconv.ParseUint(strings.TrimSpace(lines[0]), 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	stat, _ := common.ReadCgroupStat(filepath.Join(dir, "memory.stat"))
+	if inactive := stat[inactiveField]; inactive < usage {
+		usage -= inactive
+	}
+	return limit, usage, true
+}
+
 func SwapMemory() (*SwapMemoryStat, error) {
 	sysinfo := &syscall.Sysinfo_t{}
 
@@ -110,3 +207,296 @@ func SwapMemory() (*SwapMemoryStat, error) {
 	}
 	return ret, nil
 }
+
+// Fragmentation returns the free blocks of each order for every memory
+// zone, read from /proc/buddyinfo.
+func Fragmentation() ([]BuddyInfoStat, error) {
+	lines, err := common.ReadLines(common.HostProc("buddyinfo"))
+	if err != nil {
+		return nil, err
+	}
+	return parseBuddyInfo(lines)
+}
+
+// parseBuddyInfo parses lines formatted as
+// "Node 0, zone   Normal   2867    292   1300 ...".
+func parseBuddyInfo(lines []string) ([]BuddyInfoStat, error) {
+	ret := []BuddyInfoStat{}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		if fields[0] != "Node" || fields[2] != "zone" {
+			return nil, fmt.Errorf("wrong buddyinfo format: %s", line)
+		}
+		node, err := strconv.Atoi(strings.TrimSuffix(fields[1], ","))
+		if err != nil {
+			return nil, err
+		}
+		stat := BuddyInfoStat{
+			Node:       node,
+			Zone:       fields[3],
+			FreeBlocks: make([]uint64, 0, len(fields)-4),
+		}
+		for _, field := range fields[4:] {
+			v, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			stat.FreeBlocks = append(stat.FreeBlocks, v)
+		}
+		ret = append(ret, stat)
+	}
+	return ret, nil
+}
+
+// NUMAHugePages returns the total and free huge pages of each size for
+// every NUMA node, read from
+// /sys/devices/system/node/node*/hugepages/hugepages-*kB/.
+func NUMAHugePages() ([]NUMAHugePagesStat, error) {
+	nodes, err := filepath.Glob(common.HostSys("devices/system/node/node[0-9]*"))
+	if err != nil {
+		return nil, err
+	}
+	ret := []NUMAHugePagesStat{}
+	for _, nodeDir := range nodes {
+		node, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(nodeDir), "node"))
+		if err != nil {
+			continue
+		}
+		sizes, err := filepath.Glob(filepath.Join(nodeDir, "hugepages", "hugepages-*kB"))
+		if err != nil {
+			return nil, err
+		}
+		for _, sizeDir := range sizes {
+			size := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(sizeDir), "hugepages-"), "kB")
+			kb, err := strconv.ParseUint(size, 10, 64)
+			if err != nil {
+				continue
+			}
+			stat := NUMAHugePagesStat{Node: node, PageSize: kb * 1024}
+			total, err := common.ReadInts(filepath.Join(sizeDir, "nr_hugepages"))
+			if err != nil {
+				return nil, err
+			}
+			free, err := common.ReadInts(filepath.Join(sizeDir, "free_hugepages"))
+			if err != nil {
+				return nil, err
+			}
+			stat.Total = uint64(total[0])
+			stat.Free = uint64(free[0])
+			ret = append(ret, stat)
+		}
+	}
+	return ret, nil
+}
+
+// SlabInfo returns the usage of every kernel slab cache read from
+// /proc/slabinfo, the largest consumers of memory first.
+func SlabInfo() ([]SlabInfoStat, error) {
+	lines, err := common.ReadLines(common.HostProc("slabinfo"))
+	if err != nil {
+		if os.IsPermission(err) {
+			return nil, ErrSlabInfoPermission
+		}
+		return nil, err
+	}
+	ret, err := parseSlabInfo(lines, uint64(os.Getpagesize()))
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(ret, func(i, j int) bool { return ret[i].Total > ret[j].Total })
+	return ret, nil
+}
+
+// parseSlabInfo parses the lines of a version 2.x slabinfo, formatted as
+// "name <active_objs> <num_objs> <objsize> <objperslab> <pagesperslab> :
+// tunables ... : slabdata <active_slabs> <num_slabs> <sharedavail>".
+func parseSlabInfo(lines []string, pageSize uint64) ([]SlabInfoStat, error) {
+	ret := []SlabInfoStat{}
+	for _, line := range lines {
+		if strings.HasPrefix(line, "slabinfo") || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 16 || fields[6] != ":" || fields[11] != ":" {
+			return nil, fmt.Errorf("unexpected slabinfo line: %s", line)
+		}
+		var values [5]uint64
+		for i, f := range []string{fields[1], fields[2], fields[3], fields[5], fields[14]} {
+			v, err := strconv.ParseUint(f, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+		ret = append(ret, SlabInfoStat{
+			Name:       fields[0],
+			ActiveObjs: values[0],
+			NumObjs:    values[1],
+			ObjSize:    values[2],
+			Total:      values[4] * values[3] * pageSize,
+		})
+	}
+	return ret, nil
+}
+
+// SwapDevices returns the usage of each swap partition and file, read from
+// /proc/swaps.
+func SwapDevices() ([]SwapDevice, error) {
+	lines, err := common.ReadLines(common.HostProc("swaps"))
+	if err != nil {
+		return nil, err
+	}
+	return parseSwaps(lines)
+}
+
+// parseSwaps parses the lines of /proc/swaps, after the header:
+//
+//	Filename        Type        Size     Used    Priority
+//	/swap\040file   file        1048572  0       -2
+//
+// The sizes are in KiB and the spaces of the names are escaped in octal.
+func parseSwaps(lines []string) ([]SwapDevice, error) {
+	ret := []SwapDevice{}
+	if len(lines) == 0 {
+		return ret, nil
+	}
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		if len(fields) < 5 {
+			continue
+		}
+		size, err := strconv.ParseUint(fields[2], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		used, err := strconv.ParseUint(fields[3], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		priority, err := strconv.Atoi(fields[4])
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, SwapDevice{
+			Name:     unescapeOctal(fields[0]),
+			Type:     fields[1],
+			Size:     size * 1024,
+			Used:     used * 1024,
+			Priority: priority,
+		})
+	}
+	return ret, nil
+}
+
+// unescapeOctal replaces the \ooo escapes which the kernel uses for the
+// white spaces and backslashes of paths.
+func unescapeOctal(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if v, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b = append(b, byte(v))
+				i += 3
+				continue
+			}
+		}
+		b = append(b, s[i])
+	}
+	return string(b)
+}
+
+// CompressedSwap returns the usage of the zram devices, read from
+// /sys/block/zram*/mm_stat, and the configuration and usage of zswap, read
+// from /sys/module/zswap/parameters and /sys/kernel/debug/zswap.
+func CompressedSwap() (*CompressedSwapStat, error) {
+	ret := &CompressedSwapStat{Zram: []ZramStat{}}
+
+	cluster, err := common.ReadInts(common.HostProc("sys/vm/page-cluster"))
+	if err == nil && len(cluster) > 0 {
+		ret.PageCluster = int(cluster[0])
+	}
+
+	devices, err := filepath.Glob(common.HostSys("block/zram[0-9]*"))
+	if err != nil {
+		return nil, err
+	}
+	for _, dev := range devices {
+		lines, err := common.ReadLines(filepath.Join(dev, "disksize"))
+		if err != nil || len(lines) == 0 {
+			continue
+		}
+		size, err := strconv.ParseUint(strings.TrimSpace(lines[0]), 10, 64)
+		if err != nil || size == 0 {
+			// the device is not initialized
+			continue
+		}
+		lines, err = common.ReadLines(filepath.Join(dev, "mm_stat"))
+		if err != nil || len(lines) == 0 {
+			continue
+		}
+		stat, err := parseZramMMStat(lines[0])
+		if err != nil {
+			return nil, err
+		}
+		stat.Name = filepath.Base(dev)
+		stat.DiskSize = size
+		ret.Zram = append(ret.Zram, *stat)
+	}
+
+	params := common.HostSys("module/zswap/parameters")
+	enabled, err := common.ReadLines(filepath.Join(params, "enabled"))
+	if err != nil || len(enabled) == 0 || enabled[0] != "Y" {
+		return ret, nil
+	}
+	zswap := &ZswapStat{}
+	if lines, err := common.ReadLines(filepath.Join(params, "compressor")); err == nil && len(lines) > 0 {
+		zswap.Compressor = lines[0]
+	}
+	if lines, err := common.ReadLines(filepath.Join(params, "zpool")); err == nil && len(lines) > 0 {
+		zswap.Zpool = lines[0]
+	}
+	if v, err := common.ReadInts(filepath.Join(params, "max_pool_percent")); err == nil && len(v) > 0 {
+		zswap.MaxPoolPercent = uint64(v[0])
+	}
+	debug := common.HostSys("kernel/debug/zswap")
+	if lines, err := common.ReadLines(filepath.Join(debug, "pool_total_size")); err == nil && len(lines) > 0 {
+		zswap.PoolTotalSize, _ = strconv.ParseUint(lines[0], 10, 64)
+	}
+	if lines, err := common.ReadLines(filepath.Join(debug, "stored_pages")); err == nil && len(lines) > 0 {
+		zswap.StoredPages, _ = strconv.ParseUint(lines[0], 10, 64)
+	}
+	ret.Zswap = zswap
+	return ret, nil
+}
+
+// parseZramMMStat parses the first fields of a zram mm_stat line:
+// orig_data_size compr_data_size mem_used_total ...
+func parseZramMMStat(line string) (*ZramStat, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("wrong mm_stat format: %s", line)
+	}
+	var values [3]uint64
+	for i := range values {
+		v, err := strconv.ParseUint(fields[i], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	ret := &ZramStat{
+		OrigDataSize:  values[0],
+		ComprDataSize: values[1],
+		MemUsedTotal:  values[2],
+	}
+	if ret.ComprDataSize > 0 {
+		ret.CompressionRatio = float64(ret.OrigDataSize) / float64(ret.ComprDataSize)
+	}
+	return ret, nil
+}
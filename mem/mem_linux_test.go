@@ -0,0 +1,250 @@
+// +build linux
+
+package mem
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DataDog/gopsutil/internal/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBuddyInfo(t *testing.T) {
+	lines := []string{
+		"Node 0, zone      DMA      0      0      0      0      0      0      0      0      1      1      3 ",
+		"Node 0, zone   Normal   2867    292   1300      3      2      3      3      1      2      3     12",
+		"Node 1, zone   Normal      8      0      0      0      0      0      0      0      0      0      0",
+	}
+	v, err := parseBuddyInfo(lines)
+	assert.Nil(t, err)
+	assert.Len(t, v, 3)
+
+	assert.Equal(t, 0, v[1].Node)
+	assert.Equal(t, "Normal", v[1].Zone)
+	assert.Equal(t, []uint64{2867, 292, 1300, 3, 2, 3, 3, 1, 2, 3, 12}, v[1].FreeBlocks)
+
+	assert.Equal(t, 1, v[2].Node)
+	assert.Equal(t, uint64(8), v[2].FreePages())
+	assert.Equal(t, 0.0, v[2].UnusableIndex(0))
+	assert.Equal(t, 1.0, v[2].UnusableIndex(1))
+
+	_, err = parseBuddyInfo([]string{"garbage line with fields"})
+	assert.NotNil(t, err)
+}
+
+func TestFragmentation(t *testing.T) {
+	v, err := Fragmentation()
+	assert.Nil(t, err)
+	assert.NotEmpty(t, v)
+}
+
+func TestNUMAHugePages(t *testing.T) {
+	dir, err := common.FakeTree(map[string]string{
+		"devices/system/node/node0/hugepages/hugepages-2048kB/nr_hugepages":      "512\n",
+		"devices/system/node/node0/hugepages/hugepages-2048kB/free_hugepages":    "100\n",
+		"devices/system/node/node0/hugepages/hugepages-1048576kB/nr_hugepages":   "4\n",
+		"devices/system/node/node0/hugepages/hugepages-1048576kB/free_hugepages": "0\n",
+		"devices/system/node/node1/hugepages/hugepages-2048kB/nr_hugepages":      "512\n",
+		"devices/system/node/node1/hugepages/hugepages-2048kB/free_hugepages":    "512\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("HOST_SYS", dir)
+	defer os.Unsetenv("HOST_SYS")
+
+	v, err := NUMAHugePages()
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []NUMAHugePagesStat{
+		{Node: 0, PageSize: 2 * 1024 * 1024, Total: 512, Free: 100},
+		{Node: 0, PageSize: 1024 * 1024 * 1024, Total: 4, Free: 0},
+		{Node: 1, PageSize: 2 * 1024 * 1024, Total: 512, Free: 512},
+	}, v)
+}
+
+func TestParseSlabInfo(t *testing.T) {
+	lines := []string{
+		"slabinfo - version: 2.1",
+		"# name            <active_objs> <num_objs> <objsize> <objperslab> <pagesperslab> : tunables <limit> <batchcount> <sharedfactor> : slabdata <active_slabs> <num_slabs> <sharedavail>",
+		"ext4_groupinfo_4k   2054   2054    152   26    1 : tunables    0    0    0 : slabdata     79     79      0",
+		"dentry            183414 184569    192   21    1 : tunables    0    0    0 : slabdata   8789   8789      0",
+	}
+	v, err := parseSlabInfo(lines, 4096)
+	assert.Nil(t, err)
+	assert.Len(t, v, 2)
+	assert.Equal(t, SlabInfoStat{
+		Name:       "dentry",
+		ActiveObjs: 183414,
+		NumObjs:    184569,
+		ObjSize:    192,
+		Total:      8789 * 4096,
+	}, v[1])
+
+	_, err = parseSlabInfo([]string{"dentry 1 2 3"}, 4096)
+	assert.NotNil(t, err)
+}
+
+func TestSlabInfo(t *testing.T) {
+	v, err := SlabInfo()
+	if err == ErrSlabInfoPermission {
+		t.Skip("not root")
+	}
+	assert.Nil(t, err)
+	assert.NotEmpty(t, v)
+	for i := 1; i < len(v); i++ {
+		assert.True(t, v[i-1].Total >= v[i].Total)
+	}
+}
+
+func TestCompressedSwap(t *testing.T) {
+	dir, err := common.FakeTree(map[string]string{
+		"proc/sys/vm/page-cluster": "0\n",
+		"sys/block/zram0/disksize": "4294967296\n",
+		"sys/block/zram0/mm_stat":  "  400000000   100000000   110000000        0   120000000     1000        0        0\n",
+		"sys/block/zram1/disksize": "0\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("HOST_SYS", filepath.Join(dir, "sys"))
+	defer os.Unsetenv("HOST_SYS")
+	os.Setenv("HOST_PROC", filepath.Join(dir, "proc"))
+	defer os.Unsetenv("HOST_PROC")
+
+	v, err := CompressedSwap()
+	assert.Nil(t, err)
+	assert.Nil(t, v.Zswap)
+	assert.Equal(t, 0, v.PageCluster)
+	assert.Equal(t, []ZramStat{{
+		Name:             "zram0",
+		DiskSize:         4294967296,
+		OrigDataSize:     400000000,
+		ComprDataSize:    100000000,
+		MemUsedTotal:     110000000,
+		CompressionRatio: 4,
+	}}, v.Zram)
+
+	params := "sys/module/zswap/parameters/"
+	if err := common.WriteTree(dir, map[string]string{
+		params + "enabled":          "Y\n",
+		params + "compressor":       "lzo-rle\n",
+		params + "zpool":            "zbud\n",
+		params + "max_pool_percent": "20\n",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	v, err = CompressedSwap()
+	assert.Nil(t, err)
+	assert.Equal(t, &ZswapStat{Compressor: "lzo-rle", Zpool: "zbud", MaxPoolPercent: 20}, v.Zswap)
+
+	_, err = parseZramMMStat("1 2")
+	assert.NotNil(t, err)
+}
+
+func TestVirtualMemoryHugePages(t *testing.T) {
+	// no MemAvailable before Linux 3.14
+	dir, err := common.FakeTree(map[string]string{
+		"meminfo": "MemTotal:       16384000 kB\nMemFree:         8192000 kB\nBuffers:          102400 kB\nCached:          2048000 kB\n" +
+			"SReclaimable:     409600 kB\nSUnreclaim:        51200 kB\n" +
+			"HugePages_Total:     512\nHugePages_Free:      128\nHugePages_Rsvd:        0\nHugepagesize:       2048 kB\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("HOST_PROC", dir)
+	defer os.Unsetenv("HOST_PROC")
+
+	v, err := VirtualMemory()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(512), v.HugePagesTotal)
+	assert.Equal(t, uint64(128), v.HugePagesFree)
+	assert.Equal(t, uint64(2048*1024), v.HugePageSize)
+	assert.Equal(t, uint64(51200*1024), v.SUnreclaim)
+	assert.Equal(t, uint64(8192000+102400+2048000+409600)*1024, v.Available)
+}
+
+func TestVirtualMemoryEx(t *testing.T) {
+	dir, err := common.FakeTree(map[string]string{
+		"proc/meminfo":     "MemTotal:       16384000 kB\nMemFree:         8192000 kB\nMemAvailable:   12288000 kB\nBuffers:          102400 kB\nCached:          2048000 kB\n",
+		"proc/self/cgroup": "4:memory:/docker/abc\n3:cpu,cpuacct:/docker/abc\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("HOST_PROC", filepath.Join(dir, "proc"))
+	defer os.Unsetenv("HOST_PROC")
+	os.Setenv("HOST_SYS", filepath.Join(dir, "sys"))
+	defer os.Unsetenv("HOST_SYS")
+
+	host, err := VirtualMemory()
+	assert.Nil(t, err)
+
+	// no limit
+	cg := "sys/fs/cgroup/memory/docker/abc/"
+	if err := common.WriteTree(dir, map[string]string{
+		cg + "memory.limit_in_bytes": "9223372036854771712\n",
+		cg + "memory.usage_in_bytes": "268435456\n",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	v, err := VirtualMemoryEx()
+	assert.Nil(t, err)
+	assert.Equal(t, host, v)
+
+	if err := common.WriteTree(dir, map[string]string{
+		cg + "memory.limit_in_bytes": "1073741824\n",
+		cg + "memory.stat":           "cache 134217728\ntotal_inactive_file 67108864\n",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	v, err = VirtualMemoryEx()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1073741824), v.Total)
+	assert.Equal(t, uint64(201326592), v.Used)
+	assert.Equal(t, uint64(872415232), v.Available)
+	assert.Equal(t, 18.75, v.UsedPercent)
+
+	// cgroup v2 with a cgroup namespace, the cgroup is the mount root
+	if err := common.WriteTree(dir, map[string]string{
+		"proc/self/cgroup":             "0::/\n",
+		"sys/fs/cgroup/memory.max":     "max\n",
+		"sys/fs/cgroup/memory.current": "268435456\n",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	v, err = VirtualMemoryEx()
+	assert.Nil(t, err)
+	assert.Equal(t, host, v)
+
+	if err := common.WriteTree(dir, map[string]string{"sys/fs/cgroup/memory.max": "536870912\n"}); err != nil {
+		t.Fatal(err)
+	}
+	v, err = VirtualMemoryEx()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(536870912), v.Total)
+	assert.Equal(t, uint64(268435456), v.Available)
+}
+
+func TestParseSwaps(t *testing.T) {
+	lines := []string{
+		"Filename\t\t\t\tType\t\tSize\t\tUsed\t\tPriority",
+		"/dev/sda2                               partition\t8388604\t\t1024\t\t-2",
+		"/var/lib/my\\040swap\\040file            file\t\t1048572\t\t0\t\t-3",
+	}
+	v, err := parseSwaps(lines)
+	assert.Nil(t, err)
+	assert.Equal(t, []SwapDevice{
+		{Name: "/dev/sda2", Type: "partition", Size: 8388604 * 1024, Used: 1024 * 1024, Priority: -2},
+		{Name: "/var/lib/my swap file", Type: "file", Size: 1048572 * 1024, Priority: -3},
+	}, v)
+
+	v, err = parseSwaps(lines[:1])
+	assert.Nil(t, err)
+	assert.Empty(t, v)
+}
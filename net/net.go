@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/DataDog/gopsutil/internal/common"
 )
@@ -32,6 +33,16 @@ type IOCountersStat struct {
 
 }
 
+// IORateStat is the per second rate of the IO counters of a network
+// interface.
+type IORateStat struct {
+	Name        string  `json:"name"`        // interface name
+	BytesSent   float64 `json:"bytesSent"`   // bytes sent per second
+	BytesRecv   float64 `json:"bytesRecv"`   // bytes received per second
+	PacketsSent float64 `json:"packetsSent"` // packets sent per second
+	PacketsRecv float64 `json:"packetsRecv"` // packets received per second
+}
+
 // Addr is implemented compatibility to psutil
 type Addr struct {
 	IP   string `json:"ip"`
@@ -49,6 +60,50 @@ type ConnectionStat struct {
 	Pid    int32   `json:"pid"`
 }
 
+// ConnectionAgeStat is a TCP connection with the time elapsed since it
+// was established, in seconds. The kernel does not record the
+// establishment time, so Age is only exact while no data has been
+// exchanged on the connection. Otherwise it is the time since the last
+// data was sent or received, a lower bound of the real age.
+type ConnectionAgeStat struct {
+	ConnectionStat
+	Age   float64 `json:"age"`
+	Exact bool    `json:"exact"`
+}
+
+// ConnectionPathInfoStat is a TCP connection with the characteristics of
+// its network path. A congestion window far below the bandwidth-delay
+// product means the throughput is limited by the window, not the path.
+type ConnectionPathInfoStat struct {
+	ConnectionStat
+	PMTU      uint32 `json:"pmtu"`      // path MTU, bytes
+	SndMSS    uint32 `json:"sndMss"`    // bytes
+	RTT       uint32 `json:"rtt"`       // smoothed round trip time, microseconds
+	RTTVar    uint32 `json:"rttVar"`    // microseconds
+	SndCwnd   uint32 `json:"sndCwnd"`   // congestion window, segments
+	CwndBytes uint64 `json:"cwndBytes"` // SndCwnd * SndMSS
+	// DeliveryRate is the most recent delivery rate estimate in bytes per
+	// second, and BDP the bandwidth-delay product it gives with RTT, in
+	// bytes. They are 0 before Linux 4.9.
+	DeliveryRate uint64 `json:"deliveryRate"`
+	BDP          uint64 `json:"bdp"`
+}
+
+// QdiscStat is the root queue discipline of an interface and its
+// counters. Drops or overlimits growing under load while the interface
+// itself reports no error point to a traffic shaping misconfiguration.
+type QdiscStat struct {
+	Name       string `json:"name"`
+	Kind       string `json:"kind"` // ex: fq_codel, pfifo_fast, htb, noqueue
+	Bytes      uint64 `json:"bytes"`
+	Packets    uint64 `json:"packets"`
+	Drops      uint64 `json:"drops"`
+	Overlimits uint64 `json:"overlimits"`
+	Requeues   uint64 `json:"requeues"`
+	Backlog    uint64 `json:"backlog"` // bytes
+	QLen       uint64 `json:"qlen"`    // packets
+}
+
 // System wide stats about different network protocols
 type ProtoCountersStat struct {
 	Protocol string           `json:"protocol"`
@@ -73,6 +128,44 @@ type FilterStat struct {
 	ConnTrackMax   int64 `json:"conntrackMax"`
 }
 
+// ConntrackUtilizationStat is the fill level of the conntrack table and
+// of its expectation table. New connections are dropped once Count
+// reaches Max, logging "nf_conntrack: table full, dropping packet".
+type ConntrackUtilizationStat struct {
+	Count         int64   `json:"count"`
+	Max           int64   `json:"max"`
+	Percent       float64 `json:"percent"`
+	ExpectCount   int64   `json:"expectCount"` // expected connections of helpers such as FTP or SIP
+	ExpectMax     int64   `json:"expectMax"`   // 0 if unknown
+	ExpectPercent float64 `json:"expectPercent"`
+}
+
+// ConntrackStat holds the nf_conntrack event counters of one CPU, or of all
+// CPUs summed when CPU is "cpu-total". Entries, Count and Max describe the
+// whole table and are the same on every CPU.
+type ConntrackStat struct {
+	CPU           string `json:"cpu"`
+	Count         int64  `json:"count"` // nf_conntrack_count
+	Max           int64  `json:"max"`   // nf_conntrack_max
+	Entries       uint64 `json:"entries"`
+	Searched      uint64 `json:"searched"`
+	Found         uint64 `json:"found"`
+	New           uint64 `json:"new"`
+	Invalid       uint64 `json:"invalid"`
+	Ignore        uint64 `json:"ignore"`
+	Delete        uint64 `json:"delete"`
+	DeleteList    uint64 `json:"deleteList"`
+	Insert        uint64 `json:"insert"`
+	InsertFailed  uint64 `json:"insertFailed"`
+	Drop          uint64 `json:"drop"`
+	EarlyDrop     uint64 `json:"earlyDrop"`
+	IcmpError     uint64 `json:"icmpError"`
+	ExpectNew     uint64 `json:"expectNew"`
+	ExpectCreate  uint64 `json:"expectCreate"`
+	ExpectDelete  uint64 `json:"expectDelete"`
+	SearchRestart uint64 `json:"searchRestart"`
+}
+
 var constMap = map[string]int{
 	"TCP":  syscall.SOCK_STREAM,
 	"UDP":  syscall.SOCK_DGRAM,
@@ -85,11 +178,41 @@ func (n IOCountersStat) String() string {
 	return string(s)
 }
 
+func (n IORateStat) String() string {
+	s, _ := json.Marshal(n)
+	return string(s)
+}
+
 func (n ConnectionStat) String() string {
 	s, _ := json.Marshal(n)
 	return string(s)
 }
 
+func (n ConnectionAgeStat) String() string {
+	s, _ := json.Marshal(n)
+	return string(s)
+}
+
+func (n ConnectionPathInfoStat) String() string {
+	s, _ := json.Marshal(n)
+	return string(s)
+}
+
+func (n QdiscStat) String() string {
+	s, _ := json.Marshal(n)
+	return string(s)
+}
+
+func (c ConntrackUtilizationStat) String() string {
+	s, _ := json.Marshal(c)
+	return string(s)
+}
+
+func (c ConntrackStat) String() string {
+	s, _ := json.Marshal(c)
+	return string(s)
+}
+
 func (n ProtoCountersStat) String() string {
 	s, _ := json.Marshal(n)
 	return string(s)
@@ -245,3 +368,84 @@ func parseNetAddr(line string) (laddr Addr, raddr Addr, err error) {
 
 	return laddr, raddr, err
 }
+
+// IOCountersDelta returns the increase of the counters of each interface of
+// cur since prev, paired by name. A counter which went backwards, because it
+// was reset or wrapped, counts from zero and its current value is returned,
+// the same as for an interface which is missing from prev. Interfaces which
+// are missing from cur are left out.
+func IOCountersDelta(prev, cur []IOCountersStat) []IOCountersStat {
+	last := make(map[string]IOCountersStat, len(prev))
+	for _, p := range prev {
+		last[p.Name] = p
+	}
+	delta := func(prev, cur uint64) uint64 {
+		if cur < prev {
+			return cur
+		}
+		return cur - prev
+	}
+	ret := make([]IOCountersStat, 0, len(cur))
+	for _, c := range cur {
+		p := last[c.Name]
+		ret = append(ret, IOCountersStat{
+			Name:        c.Name,
+			BytesSent:   delta(p.BytesSent, c.BytesSent),
+			BytesRecv:   delta(p.BytesRecv, c.BytesRecv),
+			PacketsSent: delta(p.PacketsSent, c.PacketsSent),
+			PacketsRecv: delta(p.PacketsRecv, c.PacketsRecv),
+			Errin:       delta(p.Errin, c.Errin),
+			Errout:      delta(p.Errout, c.Errout),
+			Dropin:      delta(p.Dropin, c.Dropin),
+			Dropout:     delta(p.Dropout, c.Dropout),
+			Fifoin:      delta(p.Fifoin, c.Fifoin),
+			Fifoout:     delta(p.Fifoout, c.Fifoout),
+		})
+	}
+	return ret
+}
+
+// IOCountersSampler is a common.Sampler of network IO rates, either per
+// interface or combined. Its Rate returns a []IORateStat.
+type IOCountersSampler struct {
+	PerNIC bool
+}
+
+var _ common.Sampler = IOCountersSampler{}
+
+// Snapshot returns the current []IOCountersStat.
+func (s IOCountersSampler) Snapshot() (interface{}, error) {
+	return IOCounters(s.PerNIC)
+}
+
+// Rate returns the per second rates between two snapshots. Interfaces which
+// are missing from the previous snapshot are skipped.
+func (s IOCountersSampler) Rate(prev, cur interface{}, interval time.Duration) (interface{}, error) {
+	c1, ok := prev.([]IOCountersStat)
+	if !ok {
+		return nil, fmt.Errorf("wrong snapshot type %T", prev)
+	}
+	c2, ok := cur.([]IOCountersStat)
+	if !ok {
+		return nil, fmt.Errorf("wrong snapshot type %T", cur)
+	}
+	last := make(map[string]IOCountersStat, len(c1))
+	for _, c := range c1 {
+		last[c.Name] = c
+	}
+	ret := make([]IORateStat, 0, len(c2))
+	for _, c := range c2 {
+		p, ok := last[c.Name]
+		if !ok {
+			continue
+		}
+		ret = append(ret, IORateStat{
+			Name:        c.Name,
+			BytesSent:   common.CounterRate(p.BytesSent, c.BytesSent, interval),
+			BytesRecv:   common.CounterRate(p.BytesRecv, c.BytesRecv, interval),
+			PacketsSent: common.CounterRate(p.PacketsSent, c.PacketsSent, interval),
+			PacketsRecv: common.CounterRate(p.PacketsRecv, c.PacketsRecv, interval),
+		})
+	}
+	return ret, nil
+}
@@ -5,6 +5,7 @@ package net
 import (
 	"errors"
 	"fmt"
+	"github.com/DataDog/gopsutil/internal/common"
 	"os/exec"
 	"regexp"
 	"strconv"
@@ -265,3 +266,15 @@ func FilterCounters() ([]FilterStat, error) {
 func ProtoCounters(protocols []string) ([]ProtoCountersStat, error) {
 	return nil, errors.New("NetProtoCounters not implemented for darwin")
 }
+
+func ConntrackUtilization() (*ConntrackUtilizationStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func ExtProtoCounters(protocols []string) ([]ProtoCountersStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func ConntrackStats(percpu bool) ([]ConntrackStat, error) {
+	return nil, common.ErrNotImplementedError
+}
@@ -23,3 +23,43 @@ func Connections(kind string) ([]ConnectionStat, error) {
 func ConnectionsMax(kind string, max int) ([]ConnectionStat, error) {
 	return []ConnectionStat{}, common.ErrNotImplementedError
 }
+
+func SocketInodeMap() (map[uint64]int32, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func ConnectionsWithSocketInodeMap(kind string, index map[uint64]int32) ([]ConnectionStat, error) {
+	return []ConnectionStat{}, common.ErrNotImplementedError
+}
+
+func InterfaceOffloads(iface string) (map[string]bool, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func ConnectionsWithAge(kind string) ([]ConnectionAgeStat, error) {
+	return []ConnectionAgeStat{}, common.ErrNotImplementedError
+}
+
+func ConnectionsWithPathInfo(kind string) ([]ConnectionPathInfoStat, error) {
+	return []ConnectionPathInfoStat{}, common.ErrNotImplementedError
+}
+
+func InterfaceQdisc(iface string) (*QdiscStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func ConntrackUtilization() (*ConntrackUtilizationStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func ConnectionsTotalMax(kind string, max int) ([]ConnectionStat, error) {
+	return []ConnectionStat{}, common.ErrNotImplementedError
+}
+
+func ExtProtoCounters(protocols []string) ([]ProtoCountersStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func ConntrackStats(percpu bool) ([]ConntrackStat, error) {
+	return nil, common.ErrNotImplementedError
+}
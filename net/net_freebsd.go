@@ -106,3 +106,15 @@ func FilterCounters() ([]FilterStat, error) {
 func ProtoCounters(protocols []string) ([]ProtoCountersStat, error) {
 	return nil, errors.New("NetProtoCounters not implemented for freebsd")
 }
+
+func ConntrackUtilization() (*ConntrackUtilizationStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func ExtProtoCounters(protocols []string) ([]ProtoCountersStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func ConntrackStats(percpu bool) ([]ConntrackStat, error) {
+	return nil, common.ErrNotImplementedError
+}
@@ -12,6 +12,7 @@ import (
 	"strconv"
 	"strings"
 	"syscall"
+	"unsafe"
 
 	"github.com/DataDog/gopsutil/internal/common"
 )
@@ -124,19 +125,36 @@ var netProtocols = []string{
 // If protocols is empty then all protocols are returned, otherwise
 // just the protocols in the list are returned.
 // Available protocols:
-//   ip,icmp,icmpmsg,tcp,udp,udplite
+//
+//	ip,icmp,icmpmsg,tcp,udp,udplite
 func ProtoCounters(protocols []string) ([]ProtoCountersStat, error) {
 	if len(protocols) == 0 {
 		protocols = netProtocols
 	}
+	return readProtoCounters(common.HostProc("net/snmp"), protocols)
+}
 
+// ExtProtoCounters returns the extended counters of /proc/net/netstat, such
+// as TCPLostRetransmit or TCPSynRetrans, in the same shape as ProtoCounters.
+// If protocols is empty then all sections are returned, otherwise just the
+// ones in the list, the others are absent from the result.
+// Available protocols:
+//
+//	tcpext,ipext
+func ExtProtoCounters(protocols []string) ([]ProtoCountersStat, error) {
+	return readProtoCounters(common.HostProc("net/netstat"), protocols)
+}
+
+// readProtoCounters parses a file of /proc/net/snmp format, where each
+// protocol has a header line and a values line. All the protocols are
+// returned if protocols is empty.
+func readProtoCounters(filename string, protocols []string) ([]ProtoCountersStat, error) {
 	stats := make([]ProtoCountersStat, 0, len(protocols))
 	protos := make(map[string]bool, len(protocols))
 	for _, p := range protocols {
 		protos[p] = true
 	}
 
-	filename := common.HostProc("net/snmp")
 	lines, err := common.ReadLines(filename)
 	if err != nil {
 		return nil, err
@@ -150,7 +168,7 @@ func ProtoCounters(protocols []string) ([]ProtoCountersStat, error) {
 			return nil, errors.New(filename + " is not fomatted correctly, expected ':'.")
 		}
 		proto := strings.ToLower(line[:r])
-		if !protos[proto] {
+		if len(protos) > 0 && !protos[proto] {
 			// skip protocol and data line
 			i++
 			continue
@@ -209,6 +227,158 @@ func FilterCounters() ([]FilterStat, error) {
 	return stats, nil
 }
 
+// ConntrackUtilization returns the conntrack table usage of FilterCounters
+// along with its fill percentage, and the usage of the expectation table,
+// counted from /proc/net/nf_conntrack_expect. The expectation fields are
+// 0 if they cannot be read.
+func ConntrackUtilization() (*ConntrackUtilizationStat, error) {
+	filters, err := FilterCounters()
+	if err != nil {
+		return nil, err
+	}
+	ret := &ConntrackUtilizationStat{
+		Count: filters[0].ConnTrackCount,
+		Max:   filters[0].ConnTrackMax,
+	}
+	if ret.Max > 0 {
+		ret.Percent = float64(ret.Count) / float64(ret.Max) * 100
+	}
+
+	if max, err := common.ReadInts(common.HostProc("sys/net/netfilter/nf_conntrack_expect_max")); err == nil && len(max) > 0 {
+		ret.ExpectMax = max[0]
+	}
+	if lines, err := common.ReadLines(common.HostProc("net/nf_conntrack_expect")); err == nil {
+		for _, line := range lines {
+			if line != "" {
+				ret.ExpectCount++
+			}
+		}
+	}
+	if ret.ExpectMax > 0 {
+		ret.ExpectPercent = float64(ret.ExpectCount) / float64(ret.ExpectMax) * 100
+	}
+	return ret, nil
+}
+
+// ConntrackStats returns the nf_conntrack event counters of
+// /proc/net/stat/nf_conntrack, one per CPU if percpu is true, or summed
+// into a single "cpu-total" entry otherwise. Count and Max are read from
+// nf_conntrack_count and nf_conntrack_max and are 0 if unavailable.
+func ConntrackStats(percpu bool) ([]ConntrackStat, error) {
+	lines, err := common.ReadLines(common.HostProc("net/stat/nf_conntrack"))
+	if err != nil {
+		return nil, err
+	}
+	stats, err := parseConntrackStats(lines)
+	if err != nil {
+		return nil, err
+	}
+
+	var count, max int64
+	if v, err := common.ReadInts(common.HostProc("sys/net/netfilter/nf_conntrack_count")); err == nil && len(v) > 0 {
+		count = v[0]
+	}
+	if v, err := common.ReadInts(common.HostProc("sys/net/netfilter/nf_conntrack_max")); err == nil && len(v) > 0 {
+		max = v[0]
+	}
+
+	if !percpu {
+		total := ConntrackStat{CPU: "cpu-total"}
+		for i, s := range stats {
+			if i == 0 {
+				total.Entries = s.Entries
+			}
+			total.Searched += s.Searched
+			total.Found += s.Found
+			total.New += s.New
+			total.Invalid += s.Invalid
+			total.Ignore += s.Ignore
+			total.Delete += s.Delete
+			total.DeleteList += s.DeleteList
+			total.Insert += s.Insert
+			total.InsertFailed += s.InsertFailed
+			total.Drop += s.Drop
+			total.EarlyDrop += s.EarlyDrop
+			total.IcmpError += s.IcmpError
+			total.ExpectNew += s.ExpectNew
+			total.ExpectCreate += s.ExpectCreate
+			total.ExpectDelete += s.ExpectDelete
+			total.SearchRestart += s.SearchRestart
+		}
+		stats = []ConntrackStat{total}
+	}
+	for i := range stats {
+		stats[i].Count = count
+		stats[i].Max = max
+	}
+	return stats, nil
+}
+
+// parseConntrackStats parses the lines of /proc/net/stat/nf_conntrack: a
+// header naming the columns, then one line of hex values per possible CPU.
+// Columns are matched by name as they differ between kernel versions.
+func parseConntrackStats(lines []string) ([]ConntrackStat, error) {
+	if len(lines) < 1 {
+		return nil, errors.New("nf_conntrack stat file is empty")
+	}
+	names := strings.Fields(lines[0])
+	stats := make([]ConntrackStat, 0, len(lines)-1)
+	for i, line := range lines[1:] {
+		values := strings.Fields(line)
+		if len(values) == 0 {
+			continue
+		}
+		if len(values) != len(names) {
+			return nil, errors.New("nf_conntrack stat file is not formatted correctly, expected same number of columns.")
+		}
+		stat := ConntrackStat{CPU: fmt.Sprintf("cpu%d", i)}
+		for j, name := range names {
+			v, err := strconv.ParseUint(values[j], 16, 64)
+			if err != nil {
+				return nil, err
+			}
+			switch name {
+			case "entries":
+				stat.Entries = v
+			case "searched":
+				stat.Searched = v
+			case "found":
+				stat.Found = v
+			case "new":
+				stat.New = v
+			case "invalid":
+				stat.Invalid = v
+			case "ignore":
+				stat.Ignore = v
+			case "delete":
+				stat.Delete = v
+			case "delete_list":
+				stat.DeleteList = v
+			case "insert":
+				stat.Insert = v
+			case "insert_failed":
+				stat.InsertFailed = v
+			case "drop":
+				stat.Drop = v
+			case "early_drop":
+				stat.EarlyDrop = v
+			case "icmp_error":
+				stat.IcmpError = v
+			case "expect_new":
+				stat.ExpectNew = v
+			case "expect_create":
+				stat.ExpectCreate = v
+			case "expect_delete":
+				stat.ExpectDelete = v
+			case "search_restart":
+				stat.SearchRestart = v
+			}
+		}
+		stats = append(stats, stat)
+	}
+	return stats, nil
+}
+
 // http://students.mimuw.edu.pl/lxr/source/include/net/tcp_states.h
 var TCPStatuses = map[string]string{
 	"01": "ESTABLISHED",
@@ -284,6 +454,7 @@ type connTmp struct {
 	pid      int32
 	boundPid int32
 	path     string
+	inode    string
 }
 
 // Return a list of network connections opened.
@@ -345,9 +516,57 @@ func ConnectionsPidMax(kind string, pid int32, max int) ([]ConnectionStat, error
 	return statsFromInodes(root, pid, tmap, inodes)
 }
 
+// SocketInodeMap returns the index of socket inodes to the pid of the
+// process holding them, built by scanning /proc/(pid)/fd once. Pass it to
+// ConnectionsWithSocketInodeMap to enumerate connections repeatedly
+// without rescanning the fds of every process.
+func SocketInodeMap() (map[uint64]int32, error) {
+	inodes, err := getProcInodesAll(common.HostProc(), 0)
+	if err != nil {
+		return nil, err
+	}
+	ret := make(map[uint64]int32, len(inodes))
+	for inode, pairs := range inodes {
+		i, err := strconv.ParseUint(inode, 10, 64)
+		if err != nil || len(pairs) == 0 {
+			continue
+		}
+		ret[i] = pairs[0].pid
+	}
+	return ret, nil
+}
+
+// ConnectionsWithSocketInodeMap returns a list of network connections
+// opened, attributing them to processes with an index built by
+// SocketInodeMap. The index holds no fd, so Fd is always 0.
+func ConnectionsWithSocketInodeMap(kind string, index map[uint64]int32) ([]ConnectionStat, error) {
+	tmap, ok := netConnectionKindMap[kind]
+	if !ok {
+		return nil, fmt.Errorf("invalid kind, %s", kind)
+	}
+	inodes := make(map[string][]inodeMap, len(index))
+	for inode, pid := range index {
+		inodes[strconv.FormatUint(inode, 10)] = []inodeMap{{pid: pid}}
+	}
+	return statsFromInodes(common.HostProc(), 0, tmap, inodes)
+}
+
 func statsFromInodes(root string, pid int32, tmap []netConnectionKindType, inodes map[string][]inodeMap) ([]ConnectionStat, error) {
-	dupCheckMap := make(map[connTmp]struct{})
+	ls, err := readConnections(root, pid, tmap, inodes)
+	if err != nil {
+		return nil, err
+	}
 	var ret []ConnectionStat
+	for _, c := range dedupConnections(ls) {
+		ret = append(ret, c.stat())
+	}
+	return ret, nil
+}
+
+// readConnections returns the connections of the /proc/net files of tmap,
+// attributed to processes with inodes.
+func readConnections(root string, pid int32, tmap []netConnectionKindType, inodes map[string][]inodeMap) ([]connTmp, error) {
+	var ret []connTmp
 
 	var err error
 	for _, t := range tmap {
@@ -365,36 +584,130 @@ func statsFromInodes(root string, pid int32, tmap []netConnectionKindType, inode
 		if err != nil {
 			return nil, err
 		}
-		for _, c := range ls {
-			if _, ok := dupCheckMap[c]; ok {
-				continue
-			}
+		ret = append(ret, ls...)
+	}
 
-			conn := ConnectionStat{
-				Fd:     c.fd,
-				Family: c.family,
-				Type:   c.sockType,
-				Laddr:  c.laddr,
-				Raddr:  c.raddr,
-				Status: c.status,
-				Pid:    c.pid,
-			}
-			if c.pid == 0 {
-				conn.Pid = c.boundPid
-			} else {
-				conn.Pid = c.pid
-			}
+	return ret, nil
+}
+
+// dedupConnections removes the duplicates of ls. The inode is not part of
+// the connection identity, so ls must be attributed to processes first, or
+// sockets only told apart by their inode, as unnamed unix sockets, would
+// be merged.
+func dedupConnections(ls []connTmp) []connTmp {
+	dupCheckMap := make(map[connTmp]struct{})
+	ret := make([]connTmp, 0, len(ls))
+	for _, c := range ls {
+		key := c
+		key.inode = ""
+		if _, ok := dupCheckMap[key]; ok {
+			continue
+		}
+		ret = append(ret, c)
+		dupCheckMap[key] = struct{}{}
+	}
+	return ret
+}
 
-			// fetch process owner Real, effective, saved set, and filesystem UIDs
-			proc := process{Pid: conn.Pid}
-			conn.Uids, _ = proc.getUids()
+// stat returns the ConnectionStat of c, with the uids of its process.
+func (c connTmp) stat() ConnectionStat {
+	conn := ConnectionStat{
+		Fd:     c.fd,
+		Family: c.family,
+		Type:   c.sockType,
+		Laddr:  c.laddr,
+		Raddr:  c.raddr,
+		Status: c.status,
+		Pid:    c.pid,
+	}
+	if c.pid == 0 {
+		conn.Pid = c.boundPid
+	} else {
+		conn.Pid = c.pid
+	}
+
+	// fetch process owner Real, effective, saved set, and filesystem UIDs
+	proc := process{Pid: conn.Pid}
+	conn.Uids, _ = proc.getUids()
+	return conn
+}
 
-			ret = append(ret, conn)
-			dupCheckMap[c] = struct{}{}
+// ConnectionsTotalMax returns at most max network connections opened,
+// while ConnectionsMax bounds the connections of each process. The
+// connections are read first, and the fds of the processes are only
+// scanned until the owners of these connections are found, which avoids
+// the full scan of Connections when they are found early. A max of 0 or
+// less returns all the connections.
+func ConnectionsTotalMax(kind string, max int) ([]ConnectionStat, error) {
+	if max <= 0 {
+		return Connections(kind)
+	}
+	tmap, ok := netConnectionKindMap[kind]
+	if !ok {
+		return nil, fmt.Errorf("invalid kind, %s", kind)
+	}
+	root := common.HostProc()
+	ls, err := readConnections(root, 0, tmap, map[string][]inodeMap{})
+	if err != nil {
+		return nil, err
+	}
+	if len(ls) > max {
+		ls = ls[:max]
+	}
+
+	wanted := make(map[string]struct{}, len(ls))
+	for _, c := range ls {
+		wanted[c.inode] = struct{}{}
+	}
+	owners, err := getProcInodesOwners(root, wanted)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, c := range ls {
+		if owner, ok := owners[c.inode]; ok {
+			ls[i].pid = owner.pid
+			ls[i].fd = owner.fd
 		}
+	}
+	ls = dedupConnections(ls)
 
+	ret := make([]ConnectionStat, 0, len(ls))
+	for _, c := range ls {
+		ret = append(ret, c.stat())
 	}
+	return ret, nil
+}
 
+// getProcInodesOwners returns the first process, and its fd, holding each
+// of the socket inodes, scanning the fds of the processes until all of
+// them are found.
+func getProcInodesOwners(root string, inodes map[string]struct{}) (map[string]inodeMap, error) {
+	ret := make(map[string]inodeMap, len(inodes))
+	if len(inodes) == 0 {
+		return ret, nil
+	}
+	pids, err := Pids()
+	if err != nil {
+		return nil, err
+	}
+	for _, pid := range pids {
+		t, err := getProcInodes(root, pid, 0)
+		if err != nil {
+			return nil, err
+		}
+		for inode, pairs := range t {
+			if _, ok := inodes[inode]; !ok || len(pairs) == 0 {
+				continue
+			}
+			if _, ok := ret[inode]; !ok {
+				ret[inode] = pairs[0]
+			}
+		}
+		if len(ret) == len(inodes) {
+			break
+		}
+	}
 	return ret, nil
 }
 
@@ -649,6 +962,7 @@ func processInet(file string, kind netConnectionKindType, inodes map[string][]in
 			raddr:    ra,
 			status:   status,
 			pid:      pid,
+			inode:    inode,
 		})
 	}
 
@@ -700,6 +1014,7 @@ func processUnix(file string, kind netConnectionKindType, inodes map[string][]in
 				pid:    pair.pid,
 				status: "NONE",
 				path:   path,
+				inode:  inode,
 			})
 		}
 	}
@@ -718,3 +1033,483 @@ func updateMap(src map[string][]inodeMap, add map[string][]inodeMap) map[string]
 	}
 	return src
 }
+
+// ethtool commands reading a single offload feature, from linux/ethtool.h
+const (
+	siocEthtool    = 0x8946
+	ethtoolGRxCsum = 0x14
+	ethtoolGTxCsum = 0x16
+	ethtoolGSG     = 0x18
+	ethtoolGTSO    = 0x1e
+	ethtoolGGSO    = 0x23
+	ethtoolGGRO    = 0x2b
+)
+
+var ethtoolOffloads = []struct {
+	name string
+	cmd  uint32
+}{
+	{"rx-checksumming", ethtoolGRxCsum},
+	{"tx-checksumming", ethtoolGTxCsum},
+	{"scatter-gather", ethtoolGSG},
+	{"tcp-segmentation-offload", ethtoolGTSO},
+	{"generic-segmentation-offload", ethtoolGGSO},
+	{"generic-receive-offload", ethtoolGGRO},
+}
+
+// ethtoolValue is struct ethtool_value.
+type ethtoolValue struct {
+	cmd  uint32
+	data uint32
+}
+
+// ifreqData is struct ifreq with the ifr_data member of the union.
+type ifreqData struct {
+	name [syscall.IFNAMSIZ]byte
+	data unsafe.Pointer
+	_    [16]byte
+}
+
+// InterfaceOffloads returns whether the checksum, scatter-gather, TSO, GSO
+// and GRO offloads of an interface are enabled, keyed by their ethtool
+// name. They are read with the SIOCETHTOOL ioctl, as most of them are not
+// exposed in sysfs. ErrNotImplementedError is returned if the interface
+// driver does not support it.
+func InterfaceOffloads(iface string) (map[string]bool, error) {
+	if len(iface) >= syscall.IFNAMSIZ {
+		return nil, fmt.Errorf("invalid interface name %s", iface)
+	}
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer syscall.Close(fd)
+
+	ret := make(map[string]bool, len(ethtoolOffloads))
+	for _, o := range ethtoolOffloads {
+		value := ethtoolValue{cmd: o.cmd}
+		ifr := ifreqData{data: unsafe.Pointer(&value)}
+		copy(ifr.name[:], iface)
+		_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), siocEthtool, uintptr(unsafe.Pointer(&ifr)))
+		if errno == syscall.EOPNOTSUPP {
+			// a single unsupported feature is reported as disabled
+			continue
+		}
+		if errno != 0 {
+			return nil, errno
+		}
+		ret[o.name] = value.data != 0
+	}
+	if len(ret) == 0 {
+		return nil, common.ErrNotImplementedError
+	}
+	return ret, nil
+}
+
+// sock_diag constants and struct tcp_info offsets, from linux/sock_diag.h,
+// linux/inet_diag.h and linux/tcp.h
+const (
+	sockDiagByFamily    = 20
+	inetDiagInfo        = 2
+	tcpListen           = 10
+	tcpInfoSndMSS       = 16
+	tcpInfoLastDataSent = 44
+	tcpInfoLastDataRecv = 52
+	tcpInfoPMTU         = 60
+	tcpInfoRTT          = 68
+	tcpInfoRTTVar       = 72
+	tcpInfoSndCwnd      = 80
+	tcpInfoDataSegsIn   = 152 // since Linux 4.6
+	tcpInfoDataSegsOut  = 156
+	tcpInfoDeliveryRate = 160 // since Linux 4.9
+)
+
+// inetDiagSockID is struct inet_diag_sockid. Ports and addresses are in
+// network byte order.
+type inetDiagSockID struct {
+	sport  [2]byte
+	dport  [2]byte
+	src    [16]byte
+	dst    [16]byte
+	iface  uint32
+	cookie [2]uint32
+}
+
+// inetDiagReqV2 is struct inet_diag_req_v2.
+type inetDiagReqV2 struct {
+	family   uint8
+	protocol uint8
+	ext      uint8
+	pad      uint8
+	states   uint32
+	id       inetDiagSockID
+}
+
+// inetDiagMsg is struct inet_diag_msg.
+type inetDiagMsg struct {
+	family  uint8
+	state   uint8
+	timer   uint8
+	retrans uint8
+	id      inetDiagSockID
+	expires uint32
+	rqueue  uint32
+	wqueue  uint32
+	uid     uint32
+	inode   uint32
+}
+
+// ConnectionsWithAge returns the TCP connections of kind "tcp", "tcp4" or
+// "tcp6" which are not listening, with their approximate age. It is read
+// from the tcp_info of the sockets dumped over a NETLINK_SOCK_DIAG socket.
+func ConnectionsWithAge(kind string) ([]ConnectionAgeStat, error) {
+	conns, err := inetDiagConnections(kind)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]ConnectionAgeStat, 0, len(conns))
+	for _, c := range conns {
+		age, exact := tcpInfoAge(c.info)
+		ret = append(ret, ConnectionAgeStat{ConnectionStat: c.conn, Age: age, Exact: exact})
+	}
+	return ret, nil
+}
+
+// ConnectionsWithPathInfo returns the TCP connections of kind "tcp",
+// "tcp4" or "tcp6" which are not listening, with the path MTU, round trip
+// time and congestion window of each, read from the tcp_info of the
+// sockets dumped over a NETLINK_SOCK_DIAG socket. It requires a kernel
+// built with CONFIG_INET_DIAG and CONFIG_INET_TCP_DIAG. DeliveryRate and
+// BDP are only reported since Linux 4.9.
+func ConnectionsWithPathInfo(kind string) ([]ConnectionPathInfoStat, error) {
+	conns, err := inetDiagConnections(kind)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]ConnectionPathInfoStat, 0, len(conns))
+	for _, c := range conns {
+		stat := tcpInfoPath(c.info)
+		stat.ConnectionStat = c.conn
+		ret = append(ret, stat)
+	}
+	return ret, nil
+}
+
+// inetDiagConnections returns the TCP connections of kind which are not
+// listening, attributed to processes, with their tcp_info.
+func inetDiagConnections(kind string) ([]inetDiagConn, error) {
+	tmap, ok := netConnectionKindMap[kind]
+	if !ok {
+		return nil, fmt.Errorf("invalid kind, %s", kind)
+	}
+	for _, t := range tmap {
+		if t.sockType != syscall.SOCK_STREAM {
+			return nil, fmt.Errorf("invalid kind, %s", kind)
+		}
+	}
+
+	inodes, err := getProcInodesAll(common.HostProc(), 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []inetDiagConn
+	for _, t := range tmap {
+		msgs, err := inetDiagDump(uint8(t.family))
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range msgs {
+			c, err := parseInetDiagMsg(m.Data)
+			if err != nil {
+				return nil, err
+			}
+			if pairs, ok := inodes[strconv.FormatUint(uint64(c.inode), 10)]; ok && len(pairs) > 0 {
+				c.conn.Fd = pairs[0].fd
+				c.conn.Pid = pairs[0].pid
+				proc := process{Pid: c.conn.Pid}
+				c.conn.Uids, _ = proc.getUids()
+			}
+			ret = append(ret, c)
+		}
+	}
+	return ret, nil
+}
+
+// inetDiagDump returns the inet_diag_msg messages of every TCP socket of
+// the family which is not listening, with their tcp_info attribute.
+func inetDiagDump(family uint8) ([]syscall.NetlinkMessage, error) {
+	req := struct {
+		hdr syscall.NlMsghdr
+		req inetDiagReqV2
+	}{
+		hdr: syscall.NlMsghdr{
+			Type:  sockDiagByFamily,
+			Flags: syscall.NLM_F_REQUEST | syscall.NLM_F_DUMP,
+			Seq:   1,
+		},
+		req: inetDiagReqV2{
+			family:   family,
+			protocol: syscall.IPPROTO_TCP,
+			ext:      1 << (inetDiagInfo - 1),
+			states:   0xffffffff &^ (1 << tcpListen),
+		},
+	}
+	req.hdr.Len = uint32(unsafe.Sizeof(req))
+	return netlinkDump(syscall.NETLINK_INET_DIAG, (*[unsafe.Sizeof(req)]byte)(unsafe.Pointer(&req))[:])
+}
+
+// netlinkDump sends a dump request over a netlink socket of the protocol
+// and returns the messages of the reply.
+func netlinkDump(proto int, req []byte) ([]syscall.NetlinkMessage, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW, proto)
+	if err != nil {
+		return nil, err
+	}
+	defer syscall.Close(fd)
+
+	if err := syscall.Sendto(fd, req, 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		return nil, err
+	}
+
+	var ret []syscall.NetlinkMessage
+	for {
+		// the parsed messages point into buf, so it cannot be reused
+		buf := make([]byte, 32*1024)
+		n, _, err := syscall.Recvfrom(fd, buf, 0)
+		if err != nil {
+			return nil, err
+		}
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range msgs {
+			switch m.Header.Type {
+			case syscall.NLMSG_DONE:
+				return ret, nil
+			case syscall.NLMSG_ERROR:
+				if len(m.Data) >= 4 {
+					if errno := *(*int32)(unsafe.Pointer(&m.Data[0])); errno != 0 {
+						return nil, syscall.Errno(-errno)
+					}
+				}
+				return ret, nil
+			}
+			ret = append(ret, m)
+		}
+	}
+}
+
+// netlinkAttrs returns the payload of the struct rtattr attributes of b,
+// keyed by type.
+func netlinkAttrs(b []byte) map[uint16][]byte {
+	ret := make(map[uint16][]byte)
+	// attributes are aligned to 4 bytes
+	for len(b) >= 4 {
+		l := int(*(*uint16)(unsafe.Pointer(&b[0])))
+		typ := *(*uint16)(unsafe.Pointer(&b[2]))
+		if l < 4 || l > len(b) {
+			break
+		}
+		ret[typ] = b[4:l]
+		next := (l + 3) &^ 3
+		if next > len(b) {
+			break
+		}
+		b = b[next:]
+	}
+	return ret
+}
+
+type inetDiagConn struct {
+	conn  ConnectionStat
+	inode uint32
+	info  []byte // struct tcp_info, nil if not reported
+}
+
+// parseInetDiagMsg decodes an inet_diag_msg and its tcp_info attribute.
+func parseInetDiagMsg(data []byte) (inetDiagConn, error) {
+	var ret inetDiagConn
+	size := int(unsafe.Sizeof(inetDiagMsg{}))
+	if len(data) < size {
+		return ret, fmt.Errorf("inet_diag_msg too short, %d bytes", len(data))
+	}
+	var msg inetDiagMsg
+	copy((*[unsafe.Sizeof(msg)]byte)(unsafe.Pointer(&msg))[:], data)
+
+	iplen := net.IPv4len
+	if msg.family == syscall.AF_INET6 {
+		iplen = net.IPv6len
+	}
+	ret.inode = msg.inode
+	ret.conn = ConnectionStat{
+		Family: uint32(msg.family),
+		Type:   syscall.SOCK_STREAM,
+		Laddr: Addr{
+			IP:   net.IP(msg.id.src[:iplen]).String(),
+			Port: uint32(msg.id.sport[0])<<8 | uint32(msg.id.sport[1]),
+		},
+		Raddr: Addr{
+			IP:   net.IP(msg.id.dst[:iplen]).String(),
+			Port: uint32(msg.id.dport[0])<<8 | uint32(msg.id.dport[1]),
+		},
+		Status: TCPStatuses[fmt.Sprintf("%02X", msg.state)],
+	}
+
+	ret.info = netlinkAttrs(data[size:])[inetDiagInfo]
+	return ret, nil
+}
+
+// tcpInfoAge approximates the age of a connection from its tcp_info. The
+// kernel sets the last send and receive times when the connection is
+// established, so the age is exact while no data segment has been
+// exchanged.
+func tcpInfoAge(info []byte) (float64, bool) {
+	if len(info) < tcpInfoLastDataRecv+4 {
+		return 0, false
+	}
+	sent := *(*uint32)(unsafe.Pointer(&info[tcpInfoLastDataSent]))
+	recv := *(*uint32)(unsafe.Pointer(&info[tcpInfoLastDataRecv]))
+	age := sent
+	if recv > age {
+		age = recv
+	}
+	exact := false
+	if len(info) >= tcpInfoDataSegsOut+4 {
+		segsIn := *(*uint32)(unsafe.Pointer(&info[tcpInfoDataSegsIn]))
+		segsOut := *(*uint32)(unsafe.Pointer(&info[tcpInfoDataSegsOut]))
+		exact = segsIn == 0 && segsOut == 0
+	}
+	return float64(age) / 1000, exact
+}
+
+// tcpInfoPath reads the path characteristics of a connection from its
+// tcp_info.
+func tcpInfoPath(info []byte) ConnectionPathInfoStat {
+	var ret ConnectionPathInfoStat
+	if len(info) < tcpInfoSndCwnd+4 {
+		return ret
+	}
+	u32 := func(off int) uint32 {
+		return *(*uint32)(unsafe.Pointer(&info[off]))
+	}
+	ret.PMTU = u32(tcpInfoPMTU)
+	ret.SndMSS = u32(tcpInfoSndMSS)
+	ret.RTT = u32(tcpInfoRTT)
+	ret.RTTVar = u32(tcpInfoRTTVar)
+	ret.SndCwnd = u32(tcpInfoSndCwnd)
+	ret.CwndBytes = uint64(ret.SndCwnd) * uint64(ret.SndMSS)
+	if len(info) >= tcpInfoDeliveryRate+8 {
+		ret.DeliveryRate = *(*uint64)(unsafe.Pointer(&info[tcpInfoDeliveryRate]))
+		ret.BDP = ret.DeliveryRate * uint64(ret.RTT) / 1000000
+	}
+	return ret
+}
+
+// rtnetlink traffic control constants, from linux/rtnetlink.h,
+// linux/pkt_sched.h and linux/gen_stats.h
+const (
+	tcHRoot        = 0xffffffff
+	tcaKind        = 1
+	tcaStats       = 3
+	tcaStats2      = 7
+	tcaStatsBasic  = 1
+	tcaStatsQueue  = 3
+	sizeofTcStats  = 36
+	sizeofGnetBase = 12
+	sizeofGnetQ    = 20
+)
+
+// tcMsg is struct tcmsg.
+type tcMsg struct {
+	family  uint8
+	pad1    uint8
+	pad2    uint16
+	ifindex int32
+	handle  uint32
+	parent  uint32
+	info    uint32
+}
+
+// InterfaceQdisc returns the root queue discipline of an interface and
+// its counters, dumped over a NETLINK_ROUTE socket. If traffic control is
+// not accessible, or the interface has no root qdisc, the kind is the
+// default qdisc of net.core.default_qdisc and the counters are zero.
+func InterfaceQdisc(iface string) (*QdiscStat, error) {
+	ifi, err := net.InterfaceByName(iface)
+	if err != nil {
+		return nil, err
+	}
+
+	req := struct {
+		hdr syscall.NlMsghdr
+		tcm tcMsg
+	}{
+		hdr: syscall.NlMsghdr{
+			Type:  syscall.RTM_GETQDISC,
+			Flags: syscall.NLM_F_REQUEST | syscall.NLM_F_DUMP,
+			Seq:   1,
+		},
+		tcm: tcMsg{family: syscall.AF_UNSPEC},
+	}
+	req.hdr.Len = uint32(unsafe.Sizeof(req))
+	msgs, err := netlinkDump(syscall.NETLINK_ROUTE, (*[unsafe.Sizeof(req)]byte)(unsafe.Pointer(&req))[:])
+	if err == nil {
+		for _, m := range msgs {
+			stat, ifindex, parent, err := parseQdiscMsg(m.Data)
+			if err != nil {
+				return nil, err
+			}
+			if ifindex == int32(ifi.Index) && parent == tcHRoot {
+				stat.Name = iface
+				return &stat, nil
+			}
+		}
+	}
+
+	ret := &QdiscStat{Name: iface, Kind: "pfifo_fast"}
+	if b, err := ioutil.ReadFile(common.HostProc("sys/net/core/default_qdisc")); err == nil {
+		ret.Kind = strings.TrimSpace(string(b))
+	}
+	return ret, nil
+}
+
+// parseQdiscMsg decodes a tcmsg and its kind and statistics attributes.
+// The counters of TCA_STATS2 are preferred to the legacy TCA_STATS ones.
+func parseQdiscMsg(data []byte) (QdiscStat, int32, uint32, error) {
+	var ret QdiscStat
+	size := int(unsafe.Sizeof(tcMsg{}))
+	if len(data) < size {
+		return ret, 0, 0, fmt.Errorf("tcmsg too short, %d bytes", len(data))
+	}
+	var msg tcMsg
+	copy((*[unsafe.Sizeof(msg)]byte)(unsafe.Pointer(&msg))[:], data)
+
+	attrs := netlinkAttrs(data[size:])
+	ret.Kind = strings.TrimRight(string(attrs[tcaKind]), "\x00")
+	if b := attrs[tcaStats]; len(b) >= sizeofTcStats {
+		// struct tc_stats
+		ret.Bytes = *(*uint64)(unsafe.Pointer(&b[0]))
+		ret.Packets = uint64(*(*uint32)(unsafe.Pointer(&b[8])))
+		ret.Drops = uint64(*(*uint32)(unsafe.Pointer(&b[12])))
+		ret.Overlimits = uint64(*(*uint32)(unsafe.Pointer(&b[16])))
+		ret.QLen = uint64(*(*uint32)(unsafe.Pointer(&b[28])))
+		ret.Backlog = uint64(*(*uint32)(unsafe.Pointer(&b[32])))
+	}
+	stats2 := netlinkAttrs(attrs[tcaStats2])
+	if b := stats2[tcaStatsBasic]; len(b) >= sizeofGnetBase {
+		// struct gnet_stats_basic
+		ret.Bytes = *(*uint64)(unsafe.Pointer(&b[0]))
+		ret.Packets = uint64(*(*uint32)(unsafe.Pointer(&b[8])))
+	}
+	if b := stats2[tcaStatsQueue]; len(b) >= sizeofGnetQ {
+		// struct gnet_stats_queue
+		ret.QLen = uint64(*(*uint32)(unsafe.Pointer(&b[0])))
+		ret.Backlog = uint64(*(*uint32)(unsafe.Pointer(&b[4])))
+		ret.Drops = uint64(*(*uint32)(unsafe.Pointer(&b[8])))
+		ret.Requeues = uint64(*(*uint32)(unsafe.Pointer(&b[12])))
+		ret.Overlimits = uint64(*(*uint32)(unsafe.Pointer(&b[16])))
+	}
+	return ret, msg.ifindex, msg.parent, nil
+}
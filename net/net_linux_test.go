@@ -1,9 +1,12 @@
 package net
 
 import (
+	"net"
 	"os"
+	"path/filepath"
 	"syscall"
 	"testing"
+	"unsafe"
 
 	"github.com/DataDog/gopsutil/internal/common"
 	"github.com/stretchr/testify/assert"
@@ -94,3 +97,349 @@ func TestReverse(t *testing.T) {
 	src := []byte{0x01, 0x02, 0x03}
 	assert.Equal(t, []byte{0x03, 0x02, 0x01}, Reverse(src))
 }
+
+func TestConnectionsWithSocketInodeMap(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer l.Close()
+	port := uint32(l.Addr().(*net.TCPAddr).Port)
+
+	index, err := SocketInodeMap()
+	assert.Nil(t, err)
+	assert.NotEmpty(t, index)
+
+	v, err := ConnectionsWithSocketInodeMap("tcp", index)
+	assert.Nil(t, err)
+	found := false
+	for _, c := range v {
+		if c.Laddr.Port == port && c.Status == "LISTEN" {
+			found = true
+			assert.Equal(t, int32(os.Getpid()), c.Pid)
+		}
+	}
+	assert.True(t, found, "listener on port %d not found", port)
+}
+
+func TestInterfaceOffloads(t *testing.T) {
+	v, err := InterfaceOffloads("lo")
+	if err == common.ErrNotImplementedError {
+		t.Skip("ethtool not supported on lo")
+	}
+	assert.Nil(t, err)
+	assert.NotEmpty(t, v)
+
+	_, err = InterfaceOffloads("nonexistent0")
+	assert.NotNil(t, err)
+}
+
+func TestTCPInfoAge(t *testing.T) {
+	info := make([]byte, tcpInfoDataSegsOut+4)
+	*(*uint32)(unsafe.Pointer(&info[tcpInfoLastDataSent])) = 1500
+	*(*uint32)(unsafe.Pointer(&info[tcpInfoLastDataRecv])) = 2500
+	age, exact := tcpInfoAge(info)
+	assert.Equal(t, 2.5, age)
+	assert.True(t, exact)
+
+	*(*uint32)(unsafe.Pointer(&info[tcpInfoDataSegsIn])) = 3
+	age, exact = tcpInfoAge(info)
+	assert.Equal(t, 2.5, age)
+	assert.False(t, exact)
+
+	// tcp_info of kernels before 4.6 has no data segment counters
+	age, exact = tcpInfoAge(info[:tcpInfoLastDataRecv+4])
+	assert.Equal(t, 2.5, age)
+	assert.False(t, exact)
+}
+
+func TestConnectionsWithAge(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer l.Close()
+	c, err := net.Dial("tcp", l.Addr().String())
+	assert.Nil(t, err)
+	defer c.Close()
+	port := uint32(c.LocalAddr().(*net.TCPAddr).Port)
+
+	v, err := ConnectionsWithAge("tcp4")
+	if err == syscall.EPROTONOSUPPORT || err == syscall.EACCES {
+		t.Skip("sock_diag not available")
+	}
+	assert.Nil(t, err)
+	found := false
+	for _, conn := range v {
+		assert.NotEqual(t, "LISTEN", conn.Status)
+		if conn.Laddr.Port == port {
+			found = true
+			assert.Equal(t, "ESTABLISHED", conn.Status)
+			assert.Equal(t, int32(os.Getpid()), conn.Pid)
+			assert.True(t, conn.Age < 60)
+		}
+	}
+	assert.True(t, found, "connection from port %d not found", port)
+
+	_, err = ConnectionsWithAge("udp")
+	assert.NotNil(t, err)
+}
+
+func TestTCPInfoPath(t *testing.T) {
+	info := make([]byte, tcpInfoDeliveryRate+8)
+	*(*uint32)(unsafe.Pointer(&info[tcpInfoSndMSS])) = 1448
+	*(*uint32)(unsafe.Pointer(&info[tcpInfoPMTU])) = 1500
+	*(*uint32)(unsafe.Pointer(&info[tcpInfoRTT])) = 20000
+	*(*uint32)(unsafe.Pointer(&info[tcpInfoRTTVar])) = 5000
+	*(*uint32)(unsafe.Pointer(&info[tcpInfoSndCwnd])) = 10
+	*(*uint64)(unsafe.Pointer(&info[tcpInfoDeliveryRate])) = 12500000
+
+	v := tcpInfoPath(info)
+	assert.Equal(t, ConnectionPathInfoStat{
+		PMTU:         1500,
+		SndMSS:       1448,
+		RTT:          20000,
+		RTTVar:       5000,
+		SndCwnd:      10,
+		CwndBytes:    14480,
+		DeliveryRate: 12500000,
+		BDP:          250000,
+	}, v)
+
+	// tcp_info of kernels before 4.9 has no delivery rate
+	v = tcpInfoPath(info[:tcpInfoDataSegsOut+4])
+	assert.Equal(t, uint64(0), v.BDP)
+	assert.Equal(t, uint64(14480), v.CwndBytes)
+}
+
+func TestConnectionsWithPathInfo(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer l.Close()
+	c, err := net.Dial("tcp", l.Addr().String())
+	assert.Nil(t, err)
+	defer c.Close()
+	port := uint32(c.LocalAddr().(*net.TCPAddr).Port)
+
+	v, err := ConnectionsWithPathInfo("tcp4")
+	if err == syscall.EPROTONOSUPPORT || err == syscall.EACCES {
+		t.Skip("sock_diag not available")
+	}
+	assert.Nil(t, err)
+	found := false
+	for _, conn := range v {
+		if conn.Laddr.Port == port {
+			found = true
+			assert.True(t, conn.PMTU > 0)
+			assert.True(t, conn.SndCwnd > 0)
+		}
+	}
+	assert.True(t, found, "connection from port %d not found", port)
+}
+
+func TestParseQdiscMsg(t *testing.T) {
+	attr := func(typ uint16, payload []byte) []byte {
+		b := make([]byte, (4+len(payload)+3)&^3)
+		*(*uint16)(unsafe.Pointer(&b[0])) = uint16(4 + len(payload))
+		*(*uint16)(unsafe.Pointer(&b[2])) = typ
+		copy(b[4:], payload)
+		return b
+	}
+	basic := make([]byte, sizeofGnetBase)
+	*(*uint64)(unsafe.Pointer(&basic[0])) = 123456
+	*(*uint32)(unsafe.Pointer(&basic[8])) = 789
+	queue := make([]byte, sizeofGnetQ)
+	for i, v := range []uint32{2, 3000, 17, 1, 42} {
+		*(*uint32)(unsafe.Pointer(&queue[i*4])) = v
+	}
+
+	data := make([]byte, unsafe.Sizeof(tcMsg{}))
+	msg := (*tcMsg)(unsafe.Pointer(&data[0]))
+	msg.ifindex = 3
+	msg.parent = tcHRoot
+	data = append(data, attr(tcaKind, []byte("fq_codel\x00"))...)
+	data = append(data, attr(tcaStats2, append(attr(tcaStatsBasic, basic), attr(tcaStatsQueue, queue)...))...)
+
+	v, ifindex, parent, err := parseQdiscMsg(data)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(3), ifindex)
+	assert.Equal(t, uint32(tcHRoot), parent)
+	assert.Equal(t, QdiscStat{
+		Kind:       "fq_codel",
+		Bytes:      123456,
+		Packets:    789,
+		Drops:      17,
+		Overlimits: 42,
+		Requeues:   1,
+		Backlog:    3000,
+		QLen:       2,
+	}, v)
+
+	_, _, _, err = parseQdiscMsg(data[:4])
+	assert.NotNil(t, err)
+}
+
+func TestInterfaceQdisc(t *testing.T) {
+	v, err := InterfaceQdisc("lo")
+	assert.Nil(t, err)
+	assert.Equal(t, "lo", v.Name)
+	assert.NotEmpty(t, v.Kind)
+
+	_, err = InterfaceQdisc("nonexistent0")
+	assert.NotNil(t, err)
+}
+
+func TestConntrackUtilization(t *testing.T) {
+	netfilter := "sys/net/netfilter/"
+	dir, err := common.FakeTree(map[string]string{
+		netfilter + "nf_conntrack_count": "196608\n",
+		netfilter + "nf_conntrack_max":   "262144\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("HOST_PROC", dir)
+	defer os.Unsetenv("HOST_PROC")
+
+	v, err := ConntrackUtilization()
+	assert.Nil(t, err)
+	assert.Equal(t, ConntrackUtilizationStat{Count: 196608, Max: 262144, Percent: 75}, *v)
+
+	if err := common.WriteTree(dir, map[string]string{
+		netfilter + "nf_conntrack_expect_max": "4\n",
+		"net/nf_conntrack_expect":             "297 l3proto = 2 proto=6 src=10.0.0.1 dst=10.0.0.2 sport=0 dport=40001 ftp\n",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	v, err = ConntrackUtilization()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), v.ExpectCount)
+	assert.Equal(t, int64(4), v.ExpectMax)
+	assert.Equal(t, 25.0, v.ExpectPercent)
+}
+
+func TestConntrackStats(t *testing.T) {
+	dir, err := common.FakeTree(map[string]string{
+		"net/stat/nf_conntrack": "entries  searched found new invalid ignore delete delete_list insert insert_failed drop early_drop icmp_error  expect_new expect_create expect_delete search_restart\n" +
+			"00000010  00000000 00000000 00000000 0000000a 00000005 00000000 00000000 00000002 00000000 00000001 00000000 00000000  00000000 00000000 00000000 00000003\n" +
+			"00000010  00000000 00000000 00000000 00000001 00000005 00000000 00000000 00000004 00000000 00000000 00000000 00000000  00000000 00000000 00000000 0000000f\n",
+		"sys/net/netfilter/nf_conntrack_count": "16\n",
+		"sys/net/netfilter/nf_conntrack_max":   "262144\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("HOST_PROC", dir)
+	defer os.Unsetenv("HOST_PROC")
+
+	v, err := ConntrackStats(true)
+	assert.Nil(t, err)
+	assert.Len(t, v, 2)
+	assert.Equal(t, "cpu1", v[1].CPU)
+	assert.Equal(t, uint64(10), v[0].Invalid)
+	assert.Equal(t, uint64(15), v[1].SearchRestart)
+	assert.Equal(t, int64(262144), v[1].Max)
+
+	v, err = ConntrackStats(false)
+	assert.Nil(t, err)
+	assert.Equal(t, []ConntrackStat{{
+		CPU:           "cpu-total",
+		Count:         16,
+		Max:           262144,
+		Entries:       16,
+		Invalid:       11,
+		Ignore:        10,
+		Insert:        6,
+		Drop:          1,
+		SearchRestart: 18,
+	}}, v)
+}
+
+func TestConnectionsTotalMax(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer l.Close()
+	c, err := net.Dial("tcp", l.Addr().String())
+	assert.Nil(t, err)
+	defer c.Close()
+	port := uint32(l.Addr().(*net.TCPAddr).Port)
+
+	v, err := ConnectionsTotalMax("tcp4", 1)
+	assert.Nil(t, err)
+	assert.Len(t, v, 1)
+
+	v, err = ConnectionsTotalMax("tcp4", 100000)
+	assert.Nil(t, err)
+	found := false
+	for _, conn := range v {
+		if conn.Laddr.Port == port && conn.Status == "LISTEN" {
+			found = true
+			assert.Equal(t, int32(os.Getpid()), conn.Pid)
+		}
+	}
+	assert.True(t, found, "listener on port %d not found", port)
+
+	all, err := ConnectionsTotalMax("tcp4", 0)
+	assert.Nil(t, err)
+	assert.True(t, len(all) >= 3)
+
+	_, err = ConnectionsTotalMax("bogus", 1)
+	assert.NotNil(t, err)
+}
+
+func TestConnectionsTotalMaxUnnamed(t *testing.T) {
+	dir, err := common.FakeTree(map[string]string{
+		"net/unix": "Num       RefCount Protocol Flags    Type St Inode Path\n" +
+			"0000000000000000: 00000002 00000000 00000000 0001 03 1001\n" +
+			"0000000000000000: 00000002 00000000 00000000 0001 03 1002\n" +
+			"0000000000000000: 00000002 00000000 00010000 0001 01 1003 /run/app.sock\n",
+		"100/fd/": "",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for fd, inode := range map[string]string{"3": "1001", "4": "1002", "5": "1003"} {
+		if err := os.Symlink("socket:["+inode+"]", filepath.Join(dir, "100", "fd", fd)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.Setenv("HOST_PROC", dir)
+	defer os.Unsetenv("HOST_PROC")
+
+	// the two unnamed sockets only differ by their inode until their fd
+	// is known
+	v, err := ConnectionsTotalMax("unix", 100)
+	assert.Nil(t, err)
+	if assert.Len(t, v, 3) {
+		for i, conn := range v {
+			assert.Equal(t, int32(100), conn.Pid)
+			assert.Equal(t, uint32(3+i), conn.Fd)
+		}
+	}
+}
+
+func TestExtProtoCounters(t *testing.T) {
+	netstat := "TcpExt: SyncookiesSent SyncookiesRecv TCPLostRetransmit TCPSynRetrans\n" +
+		"TcpExt: 0 0 12 34\n" +
+		"IpExt: InNoRoutes InOctets\n" +
+		"IpExt: 1 123456789012\n"
+	dir, err := common.FakeTree(map[string]string{"net/netstat": netstat})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("HOST_PROC", dir)
+	defer os.Unsetenv("HOST_PROC")
+
+	v, err := ExtProtoCounters([]string{"tcpext", "unknown"})
+	assert.Nil(t, err)
+	assert.Equal(t, []ProtoCountersStat{{
+		Protocol: "tcpext",
+		Stats:    map[string]int64{"SyncookiesSent": 0, "SyncookiesRecv": 0, "TCPLostRetransmit": 12, "TCPSynRetrans": 34},
+	}}, v)
+
+	v, err = ExtProtoCounters(nil)
+	assert.Nil(t, err)
+	assert.Len(t, v, 2)
+	assert.Equal(t, "ipext", v[1].Protocol)
+	assert.Equal(t, int64(123456789012), v[1].Stats["InOctets"])
+}
@@ -151,3 +151,15 @@ func ProtoCounters(protocols []string) ([]ProtoCountersStat, error) {
 func Connections(kind string) ([]ConnectionStat, error) {
 	return nil, errors.New("Connections not implemented for openbsd")
 }
+
+func ConntrackUtilization() (*ConntrackUtilizationStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func ExtProtoCounters(protocols []string) ([]ProtoCountersStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func ConntrackStats(percpu bool) ([]ConntrackStat, error) {
+	return nil, common.ErrNotImplementedError
+}
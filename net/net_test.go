@@ -3,8 +3,10 @@ package net
 import (
 	"fmt"
 	"os"
+	"reflect"
 	"runtime"
 	"testing"
+	"time"
 
 	"github.com/DataDog/gopsutil/internal/common"
 )
@@ -227,3 +229,40 @@ func TestNetFilterCounters(t *testing.T) {
 	}
 
 }
+
+func TestIOCountersDelta(t *testing.T) {
+	prev := []IOCountersStat{
+		{Name: "eth0", BytesSent: 1000, BytesRecv: 2000, PacketsSent: 10, Errin: 5},
+		{Name: "eth1", BytesSent: 1},
+	}
+	cur := []IOCountersStat{
+		{Name: "eth0", BytesSent: 3000, BytesRecv: 500, PacketsSent: 30, Errin: 5},
+		{Name: "eth2", BytesRecv: 7},
+	}
+	v := IOCountersDelta(prev, cur)
+	expected := []IOCountersStat{
+		{Name: "eth0", BytesSent: 2000, BytesRecv: 500, PacketsSent: 20},
+		{Name: "eth2", BytesRecv: 7},
+	}
+	if !reflect.DeepEqual(v, expected) {
+		t.Errorf("wrong delta %v, expected %v", v, expected)
+	}
+}
+
+func TestIOCountersSampler(t *testing.T) {
+	s := IOCountersSampler{PerNIC: true}
+	prev := []IOCountersStat{{Name: "eth0", BytesSent: 1000, BytesRecv: 2000, PacketsSent: 10, PacketsRecv: 20}}
+	cur := []IOCountersStat{
+		{Name: "eth0", BytesSent: 3000, BytesRecv: 2000, PacketsSent: 30, PacketsRecv: 40},
+		{Name: "eth1", BytesSent: 1},
+	}
+	v, err := s.Rate(prev, cur, 2*time.Second)
+	if err != nil {
+		t.Fatalf("error %v", err)
+	}
+	rates := v.([]IORateStat)
+	expected := IORateStat{Name: "eth0", BytesSent: 1000, BytesRecv: 0, PacketsSent: 10, PacketsRecv: 10}
+	if len(rates) != 1 || rates[0] != expected {
+		t.Errorf("wrong rates %v, expected %v", rates, expected)
+	}
+}
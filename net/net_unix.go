@@ -19,6 +19,14 @@ func ConnectionsMax(kind string, max int) ([]ConnectionStat, error) {
 	return []ConnectionStat{}, common.ErrNotImplementedError
 }
 
+func SocketInodeMap() (map[uint64]int32, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func ConnectionsWithSocketInodeMap(kind string, index map[uint64]int32) ([]ConnectionStat, error) {
+	return []ConnectionStat{}, common.ErrNotImplementedError
+}
+
 // Return a list of network connections opened by a process.
 func ConnectionsPid(kind string, pid int32) ([]ConnectionStat, error) {
 	var ret []ConnectionStat
@@ -77,3 +85,23 @@ func ConnectionsPid(kind string, pid int32) ([]ConnectionStat, error) {
 func ConnectionsPidMax(kind string, pid int32, max int) ([]ConnectionStat, error) {
 	return []ConnectionStat{}, common.ErrNotImplementedError
 }
+
+func InterfaceOffloads(iface string) (map[string]bool, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func ConnectionsWithAge(kind string) ([]ConnectionAgeStat, error) {
+	return []ConnectionAgeStat{}, common.ErrNotImplementedError
+}
+
+func ConnectionsWithPathInfo(kind string) ([]ConnectionPathInfoStat, error) {
+	return []ConnectionPathInfoStat{}, common.ErrNotImplementedError
+}
+
+func InterfaceQdisc(iface string) (*QdiscStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func ConnectionsTotalMax(kind string, max int) ([]ConnectionStat, error) {
+	return []ConnectionStat{}, common.ErrNotImplementedError
+}
@@ -1,8 +1,14 @@
 package process
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"os"
 	"runtime"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/DataDog/gopsutil/cpu"
@@ -12,6 +18,10 @@ import (
 
 var invoke common.Invoker
 
+// ErrorNotDeadline is returned by SchedDeadline for a process which is not
+// scheduled with SCHED_DEADLINE.
+var ErrorNotDeadline = errors.New("process is not scheduled with SCHED_DEADLINE")
+
 func init() {
 	invoke = common.Invoke{}
 }
@@ -22,11 +32,14 @@ type Process struct {
 	name           string
 	status         string
 	parent         int32
+	tracerPid      int32
 	numCtxSwitches *NumCtxSwitchesStat
 	uids           []int32
 	gids           []int32
 	numThreads     int32
 	memInfo        *MemoryInfoStat
+	memSegments    *MemorySegmentsStat
+	lockedMem      *LockedMemoryStat
 
 	lastCPUTimes *cpu.TimesStat
 	lastCPUTime  time.Time
@@ -86,10 +99,153 @@ type MemoryInfoExStat struct {
 	Dirty  uint64 `json:"dirty"`  // bytes
 }
 
+// MemorySegmentsStat breaks the virtual memory of a process down by segment.
+type MemorySegmentsStat struct {
+	Data  uint64 `json:"data"`  // bytes, heap and data
+	Stack uint64 `json:"stack"` // bytes
+	Text  uint64 `json:"text"`  // bytes
+	Lib   uint64 `json:"lib"`   // bytes, shared libraries
+	PTE   uint64 `json:"pte"`   // bytes, page table entries
+}
+
+// LockedMemoryStat is the memory of a process which cannot be swapped out
+// or reclaimed, all values are in bytes.
+type LockedMemoryStat struct {
+	Locked uint64 `json:"locked"` // locked with mlock(2), counted against RLIMIT_MEMLOCK
+	Pinned uint64 `json:"pinned"` // pinned by the kernel, ex: for RDMA or io_uring buffers
+}
+
+// PageFaultsStat is the number of page faults of a process since it
+// started. Major faults required reading a page from disk, including
+// swap, so a growing MajorFaults rate means the working set of the
+// process does not fit in memory. The child counters are cumulative over
+// the children the process waited for, not its running children.
+type PageFaultsStat struct {
+	MinorFaults      uint64 `json:"minorFaults"`
+	MajorFaults      uint64 `json:"majorFaults"`
+	ChildMinorFaults uint64 `json:"childMinorFaults"`
+	ChildMajorFaults uint64 `json:"childMajorFaults"`
+}
+
+// THPStat is whether transparent huge pages are disabled for a process,
+// along with the system wide THP modes. Huge pages are only used for a
+// process if it did not disable them and the system mode allows it.
+type THPStat struct {
+	Disabled         bool   `json:"disabled"`         // disabled with prctl(PR_SET_THP_DISABLE)
+	EligibleMappings uint64 `json:"eligibleMappings"` // mappings which can be backed by huge pages
+	SystemEnabled    string `json:"systemEnabled"`    // ex: always, madvise, never
+	SystemDefrag     string `json:"systemDefrag"`     // ex: always, defer, defer+madvise, madvise, never
+}
+
+// AutogroupStat is the scheduling autogroup of a process.
+type AutogroupStat struct {
+	ID   int64 `json:"id"`
+	Nice int32 `json:"nice"`
+}
+
+// InotifyWatchStat is the number of inotify instances a process holds and
+// the number of watches added to them.
+type InotifyWatchStat struct {
+	Instances int32 `json:"instances"`
+	Watches   int32 `json:"watches"`
+}
+
+// MountInfoStat is a mount visible to a process, as described by
+// /proc/(pid)/mountinfo.
+type MountInfoStat struct {
+	MountID        int32    `json:"mountId"`
+	ParentID       int32    `json:"parentId"`
+	Device         string   `json:"device"` // major:minor
+	Root           string   `json:"root"`
+	Mountpoint     string   `json:"mountpoint"`
+	Opts           string   `json:"opts"`
+	OptionalFields []string `json:"optionalFields"` // ex: shared:1, master:2
+	Fstype         string   `json:"fstype"`
+	Source         string   `json:"source"`
+	SuperOpts      string   `json:"superOpts"`
+}
+
+// MemoryBreakdownStat splits the resident memory of a process by kind of
+// mapping, all values are in bytes.
+type MemoryBreakdownStat struct {
+	Heap        uint64 `json:"heap"`        // [heap] and private anonymous mappings
+	Stack       uint64 `json:"stack"`       // [stack] mappings
+	Libraries   uint64 `json:"libraries"`   // private mappings of executable files: text and data of the binary and its libraries
+	MappedFiles uint64 `json:"mappedFiles"` // private mappings of other files
+	Shared      uint64 `json:"shared"`      // shared mappings, anonymous or file backed: shm, memfd, MAP_SHARED files
+	Other       uint64 `json:"other"`       // kernel provided mappings such as [vdso] and [vvar]
+}
+
+// SelfStat is the resource usage of the current process, along with Go
+// runtime statistics.
+type SelfStat struct {
+	CPUTimes     *cpu.TimesStat  `json:"cpuTimes"`
+	RSS          uint64          `json:"rss"` // bytes
+	VMS          uint64          `json:"vms"` // bytes
+	NumFDs       int32           `json:"numFds"`
+	NumThreads   int32           `json:"numThreads"`
+	IO           *IOCountersStat `json:"io"`
+	GoMaxProcs   int             `json:"goMaxProcs"`
+	NumGoroutine int             `json:"numGoroutine"`
+	GoHeapAlloc  uint64          `json:"goHeapAlloc"` // bytes
+	GoSys        uint64          `json:"goSys"`       // bytes obtained from the OS by the Go runtime
+}
+
+// SchedDeadlineStat is the CPU reservation of a SCHED_DEADLINE process:
+// it gets Runtime of CPU time within Deadline of the start of every Period.
+// All values are in nanoseconds.
+type SchedDeadlineStat struct {
+	Runtime  uint64 `json:"runtime"`
+	Deadline uint64 `json:"deadline"`
+	Period   uint64 `json:"period"`
+}
+
+// ContainerIdentityStat is the container and Kubernetes pod a process runs
+// in, as far as it can be determined. Fields which cannot be determined are
+// left empty.
+type ContainerIdentityStat struct {
+	ContainerID    string `json:"containerID"`
+	PodName        string `json:"podName"`
+	Namespace      string `json:"namespace"`
+	ServiceAccount string `json:"serviceAccount"`
+}
+
+// EnvironDiffOptions controls how EnvironDiff compares environments.
+type EnvironDiffOptions struct {
+	// IgnoreCase compares variable names case insensitively, as Windows
+	// does. Values are always compared case sensitively.
+	IgnoreCase bool
+	// ReportExtra lists the variables of the process which are not
+	// expected in Extra. As a process inherits many variables from its
+	// parent, they are not reported by default.
+	ReportExtra bool
+}
+
+// EnvironDiffStat is the difference between the environment of a process
+// and an expected one.
+type EnvironDiffStat struct {
+	Missing    []string          `json:"missing"`    // expected variables which are not set
+	Unexpected map[string]string `json:"unexpected"` // expected variables set to another value, with the actual value
+	Extra      []string          `json:"extra"`      // variables which are not expected, if ReportExtra is set
+}
+
 type RlimitStat struct {
 	Resource int32 `json:"resource"`
-	Soft     int32 `json:"soft"`
-	Hard     int32 `json:"hard"`
+	Soft     int32 `json:"soft"` // -1 if unlimited
+	Hard     int32 `json:"hard"` // -1 if unlimited
+}
+
+// RlimitUnlimited is the value used for a limit reported as "unlimited".
+const RlimitUnlimited = ^uint64(0)
+
+// RlimitUsageStat is a resource limit along with its current usage. Unlike
+// RlimitStat, the limits are not capped to fit an int32 so that they can be
+// compared to the usage.
+type RlimitUsageStat struct {
+	Resource int32  `json:"resource"`
+	Soft     uint64 `json:"soft"` // RlimitUnlimited if unlimited
+	Hard     uint64 `json:"hard"` // RlimitUnlimited if unlimited
+	Used     uint64 `json:"used"` // 0 if the usage is not tracked
 }
 
 type IOCountersStat struct {
@@ -97,6 +253,13 @@ type IOCountersStat struct {
 	WriteCount uint64 `json:"writeCount"`
 	ReadBytes  uint64 `json:"readBytes"`
 	WriteBytes uint64 `json:"writeBytes"`
+	// Linux only. ReadChars and WriteChars count all the bytes passed to
+	// read and write syscalls, including page cache hits, while ReadBytes
+	// and WriteBytes count the bytes actually fetched from or sent to the
+	// storage layer.
+	ReadChars           uint64 `json:"readChars"`
+	WriteChars          uint64 `json:"writeChars"`
+	CancelledWriteBytes uint64 `json:"cancelledWriteBytes"` // bytes written to page cache and then truncated
 }
 
 type NumCtxSwitchesStat struct {
@@ -119,11 +282,76 @@ func (m MemoryInfoStat) String() string {
 	return string(s)
 }
 
+func (m MemorySegmentsStat) String() string {
+	s, _ := json.Marshal(m)
+	return string(s)
+}
+
+func (a AutogroupStat) String() string {
+	s, _ := json.Marshal(a)
+	return string(s)
+}
+
+func (m MountInfoStat) String() string {
+	s, _ := json.Marshal(m)
+	return string(s)
+}
+
+func (m MemoryBreakdownStat) String() string {
+	s, _ := json.Marshal(m)
+	return string(s)
+}
+
+func (s SelfStat) String() string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+func (i InotifyWatchStat) String() string {
+	s, _ := json.Marshal(i)
+	return string(s)
+}
+
+func (s SchedDeadlineStat) String() string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+func (c ContainerIdentityStat) String() string {
+	s, _ := json.Marshal(c)
+	return string(s)
+}
+
+func (e EnvironDiffStat) String() string {
+	s, _ := json.Marshal(e)
+	return string(s)
+}
+
+func (l LockedMemoryStat) String() string {
+	s, _ := json.Marshal(l)
+	return string(s)
+}
+
+func (p PageFaultsStat) String() string {
+	s, _ := json.Marshal(p)
+	return string(s)
+}
+
+func (t THPStat) String() string {
+	s, _ := json.Marshal(t)
+	return string(s)
+}
+
 func (r RlimitStat) String() string {
 	s, _ := json.Marshal(r)
 	return string(s)
 }
 
+func (r RlimitUsageStat) String() string {
+	s, _ := json.Marshal(r)
+	return string(s)
+}
+
 func (i IOCountersStat) String() string {
 	s, _ := json.Marshal(i)
 	return string(s)
@@ -134,6 +362,107 @@ func (p NumCtxSwitchesStat) String() string {
 	return string(s)
 }
 
+// Self returns a Process for the current process. Unlike NewProcess it
+// does not check that the process exists. Its Pid is the one the self link
+// of HOST_PROC points to, which is the pid in the host pid namespace when
+// running in a container with the /proc of the host mounted as HOST_PROC,
+// and otherwise the one returned by os.Getpid.
+func Self() *Process {
+	if self, err := os.Readlink(common.HostProc("self")); err == nil {
+		if pid, err := strconv.ParseInt(self, 10, 32); err == nil {
+			return &Process{Pid: int32(pid)}
+		}
+	}
+	return &Process{Pid: int32(os.Getpid())}
+}
+
+// NewProcessUnchecked returns a Process for pid without checking that the
+// process exists, such as for the pids just returned by Pids. If the process
+// is actually gone, the methods of the Process return an error.
+func NewProcessUnchecked(pid int32) *Process {
+	return &Process{Pid: pid}
+}
+
+// NewProcessWithContext returns NewProcess unless ctx is already done.
+func NewProcessWithContext(ctx context.Context, pid int32) (*Process, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return NewProcess(pid)
+}
+
+// EnvironDiff compares the environment of a process, as returned by
+// Environ, with the expected variables, such as the Environment of its
+// systemd unit or the env of its container spec. Variable names are
+// compared according to opts, and a process environment matching expected
+// returns an EnvironDiffStat with no differences.
+func EnvironDiff(pid int32, expected map[string]string, opts EnvironDiffOptions) (*EnvironDiffStat, error) {
+	p, err := NewProcess(pid)
+	if err != nil {
+		return nil, err
+	}
+	environ, err := p.Environ()
+	if err != nil {
+		return nil, err
+	}
+	return diffEnviron(environ, expected, opts), nil
+}
+
+func diffEnviron(environ []string, expected map[string]string, opts EnvironDiffOptions) *EnvironDiffStat {
+	key := func(name string) string {
+		if opts.IgnoreCase {
+			return strings.ToUpper(name)
+		}
+		return name
+	}
+
+	actual := make(map[string]string, len(environ))
+	names := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		i := strings.Index(kv, "=")
+		if i <= 0 {
+			continue
+		}
+		actual[key(kv[:i])] = kv[i+1:]
+		names[key(kv[:i])] = kv[:i]
+	}
+
+	ret := &EnvironDiffStat{
+		Missing:    []string{},
+		Unexpected: make(map[string]string),
+		Extra:      []string{},
+	}
+	wanted := make(map[string]bool, len(expected))
+	for name, value := range expected {
+		wanted[key(name)] = true
+		v, ok := actual[key(name)]
+		if !ok {
+			ret.Missing = append(ret.Missing, name)
+			continue
+		}
+		if v != value {
+			ret.Unexpected[name] = v
+		}
+	}
+	if opts.ReportExtra {
+		for k, name := range names {
+			if !wanted[k] {
+				ret.Extra = append(ret.Extra, name)
+			}
+		}
+	}
+	sort.Strings(ret.Missing)
+	sort.Strings(ret.Extra)
+	return ret
+}
+
+// SetHostProc sets the root of the proc filesystem read by this and the
+// other packages, such as a copy of /proc from another machine, in place
+// of the HOST_PROC environment variable. An empty path restores it.
+func SetHostProc(path string) {
+	common.SetHostProc(path)
+}
+
 func PidExists(pid int32) (bool, error) {
 	pids, err := Pids()
 	if err != nil {
@@ -149,6 +478,32 @@ func PidExists(pid int32) (bool, error) {
 	return false, err
 }
 
+// pollExit polls PidExists until pid is gone or ctx is done, waiting
+// from 10ms up to 1s between checks. A process started with the same pid
+// in between two checks is taken for the original one.
+func pollExit(ctx context.Context, pid int32) (bool, error) {
+	delay := 10 * time.Millisecond
+	for {
+		exists, err := PidExists(pid)
+		if err != nil {
+			return false, err
+		}
+		if !exists {
+			return true, nil
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return false, ctx.Err()
+		case <-t.C:
+		}
+		if delay *= 2; delay > time.Second {
+			delay = time.Second
+		}
+	}
+}
+
 // If interval is 0, return difference from last call(non-blocking).
 // If interval > 0, wait interval sec and return diffrence between start and end.
 func (p *Process) Percent(interval time.Duration) (float64, error) {
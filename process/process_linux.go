@@ -4,16 +4,23 @@ package process
 
 import (
 	"bytes"
+	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"math"
 	"os"
+	"os/user"
 	"path/filepath"
+	"regexp"
+	"runtime"
 	"strconv"
 	"strings"
 	"syscall"
 	"time"
+	"unsafe"
 
 	log "github.com/cihub/seelog"
 
@@ -26,9 +33,87 @@ import (
 var (
 	CachedBootTime  = uint64(0)
 	ErrorNoChildren = errors.New("process does not have children")
-	PageSize        = uint64(os.Getpagesize())
+	// ErrorEnvironPermission is returned when the environment of a process
+	// owned by another user cannot be read. os.IsPermission matches it.
+	ErrorEnvironPermission error = &os.PathError{Op: "open", Path: "/proc/(pid)/environ", Err: os.ErrPermission}
+	PageSize                     = uint64(os.Getpagesize())
+	// ErrorEmptyAffinity is returned by SetCPUAffinity when given no CPU.
+	ErrorEmptyAffinity = errors.New("cpu affinity must contain at least one cpu")
 )
 
+// Resources of RlimitUsageByResource, from sys/resource.h.
+const (
+	RLIMIT_CPU        int32 = 0
+	RLIMIT_FSIZE      int32 = 1
+	RLIMIT_DATA       int32 = 2
+	RLIMIT_STACK      int32 = 3
+	RLIMIT_CORE       int32 = 4
+	RLIMIT_RSS        int32 = 5
+	RLIMIT_NPROC      int32 = 6
+	RLIMIT_NOFILE     int32 = 7
+	RLIMIT_MEMLOCK    int32 = 8
+	RLIMIT_AS         int32 = 9
+	RLIMIT_LOCKS      int32 = 10
+	RLIMIT_SIGPENDING int32 = 11
+	RLIMIT_MSGQUEUE   int32 = 12
+	RLIMIT_NICE       int32 = 13
+	RLIMIT_RTPRIO     int32 = 14
+	RLIMIT_RTTIME     int32 = 15
+)
+
+// rlimitNames are the names of the resources in /proc/(pid)/limits.
+var rlimitNames = map[int32]string{
+	RLIMIT_CPU:        "Max cpu time",
+	RLIMIT_FSIZE:      "Max file size",
+	RLIMIT_DATA:       "Max data size",
+	RLIMIT_STACK:      "Max stack size",
+	RLIMIT_CORE:       "Max core file size",
+	RLIMIT_RSS:        "Max resident set",
+	RLIMIT_NPROC:      "Max processes",
+	RLIMIT_NOFILE:     "Max open files",
+	RLIMIT_MEMLOCK:    "Max locked memory",
+	RLIMIT_AS:         "Max address space",
+	RLIMIT_LOCKS:      "Max file locks",
+	RLIMIT_SIGPENDING: "Max pending signals",
+	RLIMIT_MSGQUEUE:   "Max msgqueue size",
+	RLIMIT_NICE:       "Max nice priority",
+	RLIMIT_RTPRIO:     "Max realtime priority",
+	RLIMIT_RTTIME:     "Max realtime timeout",
+}
+
+// schedDeadline is the SCHED_DEADLINE policy, from linux/sched.h.
+const schedDeadline = 6
+
+// sysSchedGetattr is the number of the sched_getattr(2) system call, which
+// the syscall package does not define on every architecture.
+var sysSchedGetattr = map[string]uintptr{
+	"386":      352,
+	"amd64":    315,
+	"arm":      381,
+	"arm64":    275,
+	"loong64":  275,
+	"mips":     4350,
+	"mipsle":   4350,
+	"mips64":   5310,
+	"mips64le": 5310,
+	"ppc64":    356,
+	"ppc64le":  356,
+	"riscv64":  275,
+	"s390x":    346,
+}
+
+// schedAttr is the version 0 struct sched_attr.
+type schedAttr struct {
+	size     uint32
+	policy   uint32
+	flags    uint64
+	nice     int32
+	priority uint32
+	runtime  uint64
+	deadline uint64
+	period   uint64
+}
+
 const (
 	PrioProcess               = 0   // linux/resource.h
 	ClockTicks                = 100 // C.sysconf(C._SC_CLK_TCK)
@@ -68,8 +153,7 @@ func NewProcess(pid int32) (*Process, error) {
 	p := &Process{
 		Pid: int32(pid),
 	}
-	file, err := os.Open(common.HostProc(strconv.Itoa(int(p.Pid))))
-	defer file.Close()
+	_, err := os.Stat(common.HostProc(strconv.Itoa(int(p.Pid))))
 	return p, err
 }
 
@@ -82,6 +166,42 @@ func (p *Process) Ppid() (int32, error) {
 	return ppid, nil
 }
 
+// Tracer returns the pid of the process tracing the process with ptrace(2),
+// such as a debugger, or 0 if it is not traced.
+func (p *Process) Tracer() (int32, error) {
+	err := p.fillFromStatus()
+	if err != nil {
+		return 0, err
+	}
+	return p.tracerPid, nil
+}
+
+// SchedDeadline returns the runtime, deadline and period of a process
+// scheduled with SCHED_DEADLINE, read with sched_getattr(2).
+// ErrorNotDeadline is returned if the process uses another policy.
+func (p *Process) SchedDeadline() (*SchedDeadlineStat, error) {
+	trap, ok := sysSchedGetattr[runtime.GOARCH]
+	if !ok {
+		return nil, common.ErrNotImplementedError
+	}
+	attr := schedAttr{}
+	_, _, errno := syscall.Syscall6(trap, uintptr(p.Pid), uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0, 0, 0)
+	if errno == syscall.ENOSYS {
+		return nil, common.ErrNotImplementedError
+	}
+	if errno != 0 {
+		return nil, errno
+	}
+	if attr.policy != schedDeadline {
+		return nil, ErrorNotDeadline
+	}
+	return &SchedDeadlineStat{
+		Runtime:  attr.runtime,
+		Deadline: attr.deadline,
+		Period:   attr.period,
+	}, nil
+}
+
 // Name returns name of the process.
 func (p *Process) Name() (string, error) {
 	if p.name == "" {
@@ -109,6 +229,62 @@ func (p *Process) CmdlineSlice() ([]string, error) {
 	return p.fillSliceFromCmdline()
 }
 
+// Environ returns the environment variables of the process, as "key=value"
+// strings, read from /proc/(pid)/environ. It is the environment the process
+// was started with, later changes made by the process are not visible.
+func (p *Process) Environ() ([]string, error) {
+	return p.EnvironWithContext(context.Background())
+}
+
+// EnvironWithContext is like Environ but gives up when ctx is done, as the
+// read of /proc/(pid)/environ can block on a stuck process. Kernel threads
+// have an empty environment and return an empty slice.
+func (p *Process) EnvironWithContext(ctx context.Context) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	type result struct {
+		environ []string
+		err     error
+	}
+	done := make(chan result, 1)
+	go func() {
+		environ, err := p.readEnviron(getCurrentUser())
+		done <- result{environ, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.environ, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// readEnviron reads /proc/(pid)/environ, which is only readable by the
+// owner of the process, checking the permission before opening it.
+func (p *Process) readEnviron(user *currentUser) ([]string, error) {
+	environPath := common.HostProc(strconv.Itoa(int(p.Pid)), "environ")
+	err := ensurePathReadable(environPath, user)
+	var environ []byte
+	if err == nil {
+		environ, err = ioutil.ReadFile(environPath)
+	}
+	if err != nil {
+		if os.IsPermission(err) {
+			return nil, ErrorEnvironPermission
+		}
+		return nil, err
+	}
+	ret := []string{}
+	for _, kv := range bytes.Split(environ, []byte{0}) {
+		if len(kv) > 0 {
+			ret = append(ret, string(kv))
+		}
+	}
+	return ret, nil
+}
+
 // CreateTime returns created time of the process in seconds since the epoch, in UTC.
 func (p *Process) CreateTime() (int64, error) {
 	_, _, _, createTime, _, err := p.fillFromStat()
@@ -190,9 +366,96 @@ func (p *Process) IOnice() (int32, error) {
 	return 0, common.ErrNotImplementedError
 }
 
-// Rlimit returns Resource Limits.
+// Rlimit returns Resource Limits, read from /proc/(pid)/limits and ordered
+// by resource. See RlimitUsageByResource for the limits of a resource
+// along with its usage.
 func (p *Process) Rlimit() ([]RlimitStat, error) {
-	return nil, common.ErrNotImplementedError
+	limits, err := p.fillFromLimits()
+	if err != nil {
+		return nil, err
+	}
+	var ret []RlimitStat
+	for resource := RLIMIT_CPU; resource <= RLIMIT_RTTIME; resource++ {
+		limit, ok := limits[rlimitNames[resource]]
+		if !ok {
+			continue
+		}
+		ret = append(ret, RlimitStat{
+			Resource: resource,
+			Soft:     rlimitValue(limit[0]),
+			Hard:     rlimitValue(limit[1]),
+		})
+	}
+	return ret, nil
+}
+
+// rlimitValue converts a limit of /proc/(pid)/limits to the int32 of
+// RlimitStat: unlimited becomes -1 and limits which do not fit are capped
+// to math.MaxInt32.
+func rlimitValue(v uint64) int32 {
+	if v == RlimitUnlimited {
+		return -1
+	}
+	if v > math.MaxInt32 {
+		return math.MaxInt32
+	}
+	return int32(v)
+}
+
+// RlimitUsageByResource returns the soft and hard limits of a single
+// resource, such as RLIMIT_NOFILE, read from /proc/(pid)/limits, and its
+// current usage: the number of entries of /proc/(pid)/fd for RLIMIT_NOFILE,
+// the user and system CPU seconds for RLIMIT_CPU, the resident and virtual
+// memory for RLIMIT_RSS and RLIMIT_AS and VmData of /proc/(pid)/status for
+// RLIMIT_DATA. Used is 0 for the other resources.
+func (p *Process) RlimitUsageByResource(resource int32) (RlimitUsageStat, error) {
+	ret := RlimitUsageStat{Resource: resource}
+	name, ok := rlimitNames[resource]
+	if !ok {
+		return ret, fmt.Errorf("unknown resource %d", resource)
+	}
+	limits, err := p.fillFromLimits()
+	if err != nil {
+		return ret, err
+	}
+	limit, ok := limits[name]
+	if !ok {
+		return ret, fmt.Errorf("could not find %s limit for pid %d", strings.ToLower(name), p.Pid)
+	}
+	ret.Soft, ret.Hard = limit[0], limit[1]
+
+	switch resource {
+	case RLIMIT_NOFILE:
+		_, fnames, err := p.fillFromfdList(getCurrentUser())
+		if err != nil {
+			return ret, err
+		}
+		ret.Used = uint64(len(fnames))
+	case RLIMIT_CPU:
+		times, err := p.Times()
+		if err != nil {
+			return ret, err
+		}
+		ret.Used = uint64(times.User + times.System)
+	case RLIMIT_RSS, RLIMIT_AS:
+		mem, _, err := p.readFromStatm()
+		if err != nil {
+			return ret, err
+		}
+		if resource == RLIMIT_RSS {
+			ret.Used = mem.RSS
+		} else {
+			ret.Used = mem.VMS
+		}
+	case RLIMIT_DATA:
+		// the data field of statm is not filled in MemoryInfoExStat
+		segments, err := p.MemorySegments()
+		if err != nil {
+			return ret, err
+		}
+		ret.Used = segments.Data
+	}
+	return ret, nil
 }
 
 // IOCounters returns IO Counters.
@@ -200,6 +463,254 @@ func (p *Process) IOCounters() (*IOCountersStat, error) {
 	return p.fillFromIO(getCurrentUser())
 }
 
+// MemorySegments returns the size of the data, stack, text, shared library
+// and page table segments of the process, read from /proc/(pid)/status.
+func (p *Process) MemorySegments() (*MemorySegmentsStat, error) {
+	err := p.fillFromStatus()
+	if err != nil {
+		return nil, err
+	}
+	return p.memSegments, nil
+}
+
+// LockedMemory returns the memory of the process locked with mlock(2) and
+// the pages pinned by the kernel, read from the VmLck and VmPin fields of
+// /proc/(pid)/status.
+func (p *Process) LockedMemory() (*LockedMemoryStat, error) {
+	err := p.fillFromStatus()
+	if err != nil {
+		return nil, err
+	}
+	return p.lockedMem, nil
+}
+
+// PageFaults returns the minor and major page faults of the process and
+// of its waited for children, read from /proc/(pid)/stat.
+func (p *Process) PageFaults() (*PageFaultsStat, error) {
+	contents, err := ioutil.ReadFile(common.HostProc(strconv.Itoa(int(p.Pid)), "stat"))
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(string(contents))
+
+	// the command name may contain spaces
+	i := 1
+	for i < len(fields) && !strings.HasSuffix(fields[i], ")") {
+		i++
+	}
+	if i+11 >= len(fields) {
+		return nil, fmt.Errorf("wrong stat format for pid %d", p.Pid)
+	}
+
+	var faults [4]uint64
+	for j := range faults {
+		// minflt, cminflt, majflt and cmajflt follow the flags field
+		faults[j], err = strconv.ParseUint(fields[i+8+j], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &PageFaultsStat{
+		MinorFaults:      faults[0],
+		ChildMinorFaults: faults[1],
+		MajorFaults:      faults[2],
+		ChildMajorFaults: faults[3],
+	}, nil
+}
+
+// THPDisabled returns whether transparent huge pages are disabled for the
+// process with prctl(PR_SET_THP_DISABLE), read from the THP_enabled field
+// of /proc/(pid)/status, and the number of its mappings eligible to huge
+// pages, from the THPeligible markers of /proc/(pid)/smaps. Both were added
+// in Linux 5.0; on older kernels, ErrNotImplementedError is returned unless
+// p is the calling process. The system wide modes are empty if the kernel
+// has no THP support.
+func (p *Process) THPDisabled() (*THPStat, error) {
+	pid := strconv.Itoa(int(p.Pid))
+	lines, err := common.ReadLines(common.HostProc(pid, "status"))
+	if err != nil {
+		return nil, err
+	}
+	ret := &THPStat{}
+	found := false
+	for _, line := range lines {
+		if strings.HasPrefix(line, "THP_enabled:") {
+			ret.Disabled = strings.TrimSpace(strings.TrimPrefix(line, "THP_enabled:")) == "0"
+			found = true
+			break
+		}
+	}
+	if !found {
+		if int(p.Pid) != os.Getpid() {
+			return nil, common.ErrNotImplementedError
+		}
+		disabled, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prGetTHPDisable, 0, 0)
+		if errno != 0 {
+			return nil, errno
+		}
+		ret.Disabled = disabled != 0
+	}
+
+	lines, err = common.ReadLines(common.HostProc(pid, "smaps"))
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range lines {
+		if strings.HasPrefix(line, "THPeligible:") && strings.TrimSpace(strings.TrimPrefix(line, "THPeligible:")) == "1" {
+			ret.EligibleMappings++
+		}
+	}
+
+	ret.SystemEnabled = readTHPMode(common.HostSys("kernel/mm/transparent_hugepage/enabled"))
+	ret.SystemDefrag = readTHPMode(common.HostSys("kernel/mm/transparent_hugepage/defrag"))
+	return ret, nil
+}
+
+// prGetTHPDisable is PR_GET_THP_DISABLE, from linux/prctl.h
+const prGetTHPDisable = 42
+
+// readTHPMode returns the selected mode of a transparent_hugepage sysfs
+// file, the one in brackets as in "always [madvise] never", or an empty
+// string if it cannot be read.
+func readTHPMode(filename string) string {
+	lines, err := common.ReadLines(filename)
+	if err != nil || len(lines) == 0 {
+		return ""
+	}
+	for _, mode := range strings.Fields(lines[0]) {
+		if strings.HasPrefix(mode, "[") && strings.HasSuffix(mode, "]") {
+			return strings.Trim(mode, "[]")
+		}
+	}
+	return ""
+}
+
+// CPUPercentVsCgroupQuota returns the CPU usage of the process over interval
+// as a percentage of the CPU quota of its cgroup rather than of a single
+// host CPU, so a process using all of a 0.5 CPU quota reports 100.
+// If the cgroup has no quota, the percentage is relative to the capacity
+// of the host and limited is false.
+func (p *Process) CPUPercentVsCgroupQuota(interval time.Duration) (percent float64, limited bool, err error) {
+	quota, limited, err := p.cgroupCPUQuota()
+	if err != nil {
+		return 0, false, err
+	}
+	if !limited {
+		quota = float64(runtime.NumCPU())
+	}
+	percent, err = p.Percent(interval)
+	if err != nil {
+		return 0, false, err
+	}
+	return percent / quota, limited, nil
+}
+
+// EffectiveCPUWeight returns the share of the CPU time the cgroup v2 of
+// the process is entitled to when every cgroup is busy, between 0 and 1.
+// It is the product, at each level of the hierarchy, of the cpu.weight of
+// the cgroup divided by the sum of the cpu.weight of its siblings.
+// ErrNotImplementedError is returned on cgroup v1.
+func (p *Process) EffectiveCPUWeight() (float64, error) {
+	paths, err := common.ReadCgroupPaths(strconv.Itoa(int(p.Pid)))
+	if err != nil {
+		return 0, err
+	}
+	if _, ok := paths["cpu"]; ok {
+		return 0, common.ErrNotImplementedError
+	}
+	path, ok := paths[""]
+	if !ok {
+		return 0, common.ErrNotImplementedError
+	}
+	return cgroupEffectiveWeight(common.CgroupMountpoint(""), path)
+}
+
+// ContainerIdentity returns the container and Kubernetes pod the process
+// runs in. Each field is read from:
+//   - ContainerID: the 64 hex digits id in /proc/(pid)/cgroup, as written by
+//     docker, containerd and cri-o, or else the HOSTNAME environment variable
+//     if it looks like the 12 digits short id docker uses as hostname
+//   - PodName: the POD_NAME environment variable, or else HOSTNAME if
+//     KUBERNETES_SERVICE_HOST is set, as the hostname of a pod is its name
+//   - Namespace: the POD_NAMESPACE environment variable, or else the
+//     namespace file of the service account mounted in the pod
+//   - ServiceAccount: the subject of the service account token mounted in
+//     the pod
+//
+// The service account files are read through /proc/(pid)/root, which
+// requires the permission to read the root of the process. The environment
+// and the service account files are skipped if they are not readable.
+func (p *Process) ContainerIdentity() (*ContainerIdentityStat, error) {
+	return p.containerIdentity(getCurrentUser())
+}
+
+func (p *Process) containerIdentity(user *currentUser) (*ContainerIdentityStat, error) {
+	pid := strconv.Itoa(int(p.Pid))
+	ret := &ContainerIdentityStat{}
+
+	lines, err := common.ReadLines(common.HostProc(pid, "cgroup"))
+	if err != nil {
+		return nil, err
+	}
+	ret.ContainerID = parseCgroupContainerID(lines)
+
+	environ, err := p.readEnviron(user)
+	if err != nil && !os.IsPermission(err) {
+		return nil, err
+	}
+	env := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		if i := strings.Index(kv, "="); i > 0 {
+			env[kv[:i]] = kv[i+1:]
+		}
+	}
+	if ret.ContainerID == "" && shortContainerID.MatchString(env["HOSTNAME"]) {
+		ret.ContainerID = env["HOSTNAME"]
+	}
+	ret.PodName = env["POD_NAME"]
+	if ret.PodName == "" && env["KUBERNETES_SERVICE_HOST"] != "" {
+		ret.PodName = env["HOSTNAME"]
+	}
+
+	secrets := common.HostProc(pid, "root", "var/run/secrets/kubernetes.io/serviceaccount")
+	ret.Namespace = env["POD_NAMESPACE"]
+	if ret.Namespace == "" {
+		// the namespace file has no trailing newline
+		if namespace, err := readRestricted(filepath.Join(secrets, "namespace"), user); err == nil {
+			ret.Namespace = strings.TrimSpace(string(namespace))
+		}
+	}
+	if token, err := readRestricted(filepath.Join(secrets, "token"), user); err == nil {
+		ret.ServiceAccount = parseServiceAccountToken(string(token))
+	}
+	return ret, nil
+}
+
+// readRestricted reads a file which may not be readable by the current
+// user, checking the permission with ensurePathReadable before opening it.
+func readRestricted(path string, user *currentUser) ([]byte, error) {
+	if err := ensurePathReadable(path, user); err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(path)
+}
+
+// Autogroup returns the scheduling autogroup of the process and its nice
+// value, read from /proc/(pid)/autogroup. The autogroup nice value weights
+// the CPU share of the whole group, independently of the nice value of the
+// process. ErrNotImplementedError is returned if the kernel is built without
+// CONFIG_SCHED_AUTOGROUP.
+func (p *Process) Autogroup() (*AutogroupStat, error) {
+	contents, err := ioutil.ReadFile(common.HostProc(strconv.Itoa(int(p.Pid)), "autogroup"))
+	if os.IsNotExist(err) {
+		return nil, common.ErrNotImplementedError
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseAutogroup(string(contents))
+}
+
 // NumCtxSwitches returns the number of the context switches of the process.
 func (p *Process) NumCtxSwitches() (*NumCtxSwitchesStat, error) {
 	err := p.fillFromStatus()
@@ -215,6 +726,31 @@ func (p *Process) NumFDs() (int32, error) {
 	return int32(len(fds)), err
 }
 
+// FDUsageRatio returns the number of File Descriptors used by the process
+// divided by its soft RLIMIT_NOFILE, read from /proc/(pid)/limits.
+// If the limit is unlimited, 0 is returned and unlimited is true.
+func (p *Process) FDUsageRatio() (ratio float64, unlimited bool, err error) {
+	limits, err := p.fillFromLimits()
+	if err != nil {
+		return 0, false, err
+	}
+	limit, ok := limits["Max open files"]
+	if !ok {
+		return 0, false, fmt.Errorf("could not find open files limit for pid %d", p.Pid)
+	}
+	if limit[0] == RlimitUnlimited {
+		return 0, true, nil
+	}
+	if limit[0] == 0 {
+		return 0, false, nil
+	}
+	numFDs, err := p.NumFDs()
+	if err != nil {
+		return 0, false, err
+	}
+	return float64(numFDs) / float64(limit[0]), false, nil
+}
+
 // NumThreads returns the number of threads used by the process.
 func (p *Process) NumThreads() (int32, error) {
 	err := p.fillFromStatus()
@@ -224,14 +760,68 @@ func (p *Process) NumThreads() (int32, error) {
 	return p.numThreads, nil
 }
 
-// Threads returns a map of threads
-//
-// Notice: Not implemented yet. always returns empty map.
-func (p *Process) Threads() (map[string]string, error) {
-	ret := make(map[string]string, 0)
+// Threads returns the CPU times of each thread of the process, keyed by
+// thread id, read from /proc/(pid)/task/(tid)/stat. Threads which exit
+// while they are read are left out.
+func (p *Process) Threads() (map[int32]*cpu.TimesStat, error) {
+	pid := strconv.Itoa(int(p.Pid))
+	d, err := os.Open(common.HostProc(pid, "task"))
+	if err != nil {
+		return nil, err
+	}
+	defer d.Close()
+	fnames, err := d.Readdirnames(-1)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[int32]*cpu.TimesStat, len(fnames))
+	for _, fname := range fnames {
+		tid, err := strconv.ParseInt(fname, 10, 32)
+		if err != nil {
+			continue
+		}
+		contents, err := ioutil.ReadFile(common.HostProc(pid, "task", fname, "stat"))
+		if err != nil {
+			// the thread exited
+			continue
+		}
+		times, err := parseStatTimes(string(contents))
+		if err != nil {
+			return nil, err
+		}
+		ret[int32(tid)] = times
+	}
 	return ret, nil
 }
 
+// parseStatTimes returns the user and system times of a stat file. The
+// fields are counted from the end of the command name, which can contain
+// spaces and parentheses.
+func parseStatTimes(contents string) (*cpu.TimesStat, error) {
+	i := strings.LastIndex(contents, ")")
+	if i == -1 {
+		return nil, fmt.Errorf("wrong format stat: %s", contents)
+	}
+	fields := strings.Fields(contents[i+1:])
+	if len(fields) < 13 {
+		return nil, fmt.Errorf("wrong format stat: %s", contents)
+	}
+	utime, err := strconv.ParseFloat(fields[11], 64)
+	if err != nil {
+		return nil, err
+	}
+	stime, err := strconv.ParseFloat(fields[12], 64)
+	if err != nil {
+		return nil, err
+	}
+	return &cpu.TimesStat{
+		CPU:    "cpu",
+		User:   utime / ClockTicks,
+		System: stime / ClockTicks,
+	}, nil
+}
+
 // Times returns CPU times of the process.
 func (p *Process) Times() (*cpu.TimesStat, error) {
 	_, _, cpuTimes, _, _, err := p.fillFromStat()
@@ -241,11 +831,108 @@ func (p *Process) Times() (*cpu.TimesStat, error) {
 	return cpuTimes, nil
 }
 
-// CPUAffinity returns CPU affinity of the process.
-//
-// Notice: Not implemented yet.
+// sysPidfdOpen is the number of pidfd_open(2) on the architectures using the
+// unified syscall table. mips and mips64 offset it, 4434 and 5434, and get
+// ENOSYS for 434, so WaitForExit falls back to polling there.
+const sysPidfdOpen = 434
+
+// WaitForExit blocks until the process exits or ctx is done, and reports
+// whether it exited. On Linux 5.3 and later it waits on a pidfd, which
+// keeps referring to the process even if its pid is reused afterwards.
+// Otherwise it polls PidExists, and can not detect a pid reused by a new
+// process in between two polls.
+func (p *Process) WaitForExit(ctx context.Context) (bool, error) {
+	fd, _, errno := syscall.RawSyscall(sysPidfdOpen, uintptr(p.Pid), 0, 0)
+	switch errno {
+	case 0:
+	case syscall.ESRCH:
+		return true, nil
+	default:
+		// ENOSYS before Linux 5.3, or EPERM under some seccomp profiles
+		return pollExit(ctx, p.Pid)
+	}
+	defer syscall.Close(int(fd))
+	return waitPidfd(ctx, int(fd))
+}
+
+// waitPidfd waits for the pidfd to become readable, which happens when
+// the process exits, checking ctx every 100ms.
+func waitPidfd(ctx context.Context, fd int) (bool, error) {
+	epfd, err := syscall.EpollCreate1(syscall.EPOLL_CLOEXEC)
+	if err != nil {
+		return false, err
+	}
+	defer syscall.Close(epfd)
+	ev := syscall.EpollEvent{Events: syscall.EPOLLIN, Fd: int32(fd)}
+	if err := syscall.EpollCtl(epfd, syscall.EPOLL_CTL_ADD, fd, &ev); err != nil {
+		return false, err
+	}
+	events := make([]syscall.EpollEvent, 1)
+	for {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		n, err := syscall.EpollWait(epfd, events, 100)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		if n > 0 {
+			return true, nil
+		}
+	}
+}
+
+// CPUAffinity returns the logical CPUs the process is allowed to run on,
+// read with sched_getaffinity(2).
 func (p *Process) CPUAffinity() ([]int32, error) {
-	return nil, common.ErrNotImplementedError
+	// the kernel rejects masks smaller than its own, so grow until it fits
+	for words := 16; words <= 1<<14; words *= 2 {
+		mask := make([]uint64, words)
+		n, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_GETAFFINITY, uintptr(p.Pid), uintptr(words*8), uintptr(unsafe.Pointer(&mask[0])))
+		if errno == syscall.EINVAL {
+			continue
+		}
+		if errno != 0 {
+			return nil, errno
+		}
+		var cpus []int32
+		for i := 0; i < int(n)*8; i++ {
+			if mask[i/64]&(1<<uint(i%64)) != 0 {
+				cpus = append(cpus, int32(i))
+			}
+		}
+		return cpus, nil
+	}
+	return nil, syscall.EINVAL
+}
+
+// SetCPUAffinity restricts the process to the given logical CPUs with
+// sched_setaffinity(2). The affinity of its other threads is unchanged.
+func (p *Process) SetCPUAffinity(cpus []int32) error {
+	if len(cpus) == 0 {
+		return ErrorEmptyAffinity
+	}
+	max := int32(0)
+	for _, c := range cpus {
+		if c < 0 {
+			return fmt.Errorf("invalid cpu %d", c)
+		}
+		if c > max {
+			max = c
+		}
+	}
+	mask := make([]uint64, max/64+1)
+	for _, c := range cpus {
+		mask[c/64] |= 1 << uint(c%64)
+	}
+	_, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_SETAFFINITY, uintptr(p.Pid), uintptr(len(mask)*8), uintptr(unsafe.Pointer(&mask[0])))
+	if errno != 0 {
+		return errno
+	}
+	return nil
 }
 
 // MemoryInfo returns platform in-dependend memory information, such as RSS, VMS and Swap
@@ -301,6 +988,91 @@ func (p *Process) OpenFiles() ([]OpenFilesStat, error) {
 	return ret, nil
 }
 
+// OpenFilesCount returns the number of files opened by the process, as
+// len(OpenFiles()) does, by counting the entries of /proc/(pid)/fd without
+// resolving their paths.
+func (p *Process) OpenFilesCount() (int32, error) {
+	_, fnames, err := p.fillFromfdList(getCurrentUser())
+	if err != nil {
+		return 0, err
+	}
+	return int32(len(fnames)), nil
+}
+
+// AnonInodeFDCounts returns the number of anonymous inode file descriptors
+// opened by the process, keyed by subtype (eventpoll, eventfd, timerfd,
+// signalfd, ...). Leaking event loops show up as a growing count here.
+func (p *Process) AnonInodeFDCounts() (map[string]int32, error) {
+	statPath, fnames, err := p.fillFromfdList(getCurrentUser())
+	if err != nil {
+		return nil, err
+	}
+	ret := make(map[string]int32)
+	for _, fd := range fnames {
+		target, err := os.Readlink(filepath.Join(statPath, fd))
+		if err != nil {
+			continue
+		}
+		if t, ok := parseAnonInode(target); ok {
+			ret[t]++
+		}
+	}
+	return ret, nil
+}
+
+// InotifyWatchCount returns the number of inotify instances of the process
+// and their watches. An fd is an inotify instance if it links to
+// anon_inode:inotify, and /proc/(pid)/fdinfo/(fd) lists one "inotify wd:"
+// line per watch. Watches count against fs.inotify.max_user_watches.
+func (p *Process) InotifyWatchCount() (*InotifyWatchStat, error) {
+	statPath, fnames, err := p.fillFromfdList(getCurrentUser())
+	if err != nil {
+		return nil, err
+	}
+	ret := &InotifyWatchStat{}
+	for _, fd := range fnames {
+		target, err := os.Readlink(filepath.Join(statPath, fd))
+		if err != nil {
+			continue
+		}
+		if t, ok := parseAnonInode(target); !ok || t != "inotify" {
+			continue
+		}
+		ret.Instances++
+		lines, err := common.ReadLines(common.HostProc(strconv.Itoa(int(p.Pid)), "fdinfo", fd))
+		if err != nil {
+			continue
+		}
+		for _, line := range lines {
+			if strings.HasPrefix(line, "inotify wd:") {
+				ret.Watches++
+			}
+		}
+	}
+	return ret, nil
+}
+
+// Mounts returns the mounts visible from the mount namespace of the process,
+// read from /proc/(pid)/mountinfo.
+func (p *Process) Mounts() ([]MountInfoStat, error) {
+	lines, err := common.ReadLines(common.HostProc(strconv.Itoa(int(p.Pid)), "mountinfo"))
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]MountInfoStat, 0, len(lines))
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		m, err := parseMountInfo(line)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, m)
+	}
+	return ret, nil
+}
+
 // Connections returns a slice of net.ConnectionStat used by the process.
 // This returns all kind of the connection. This measn TCP, UDP or UNIX.
 func (p *Process) Connections() ([]net.ConnectionStat, error) {
@@ -397,10 +1169,140 @@ func (p *Process) MemoryMaps(grouped bool) (*[]MemoryMapsStat, error) {
 	return &ret, nil
 }
 
+// MemoryMapsGroupedByPath returns the memory of the mappings of the process
+// summed by pathname, so a library mapped at several addresses has a single
+// entry. Anonymous mappings are summed under "[anon]". The sizes are in kB,
+// as read from /proc/(pid)/smaps.
+func (p *Process) MemoryMapsGroupedByPath() (map[string]MemoryMapsStat, error) {
+	lines, err := common.ReadLines(common.HostProc(strconv.Itoa(int(p.Pid)), "smaps"))
+	if err != nil {
+		return nil, err
+	}
+	return parseSmapsByPath(lines)
+}
+
+func parseSmapsByPath(lines []string) (map[string]MemoryMapsStat, error) {
+	ret := make(map[string]MemoryMapsStat)
+	var path string
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if !strings.HasSuffix(fields[0], ":") {
+			// mapping header: address perms offset dev inode [path]
+			if len(fields) < 5 {
+				return nil, fmt.Errorf("wrong smaps format: %s", line)
+			}
+			path = strings.Join(fields[5:], " ")
+			if path == "" {
+				path = "[anon]"
+			}
+			if _, ok := ret[path]; !ok {
+				ret[path] = MemoryMapsStat{Path: path}
+			}
+			continue
+		}
+		if path == "" || len(fields) < 2 || fields[0] == "VmFlags:" {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		m := ret[path]
+		switch fields[0] {
+		case "Size:":
+			m.Size += v
+		case "Rss:":
+			m.Rss += v
+		case "Pss:":
+			m.Pss += v
+		case "Shared_Clean:":
+			m.SharedClean += v
+		case "Shared_Dirty:":
+			m.SharedDirty += v
+		case "Private_Clean:":
+			m.PrivateClean += v
+		case "Private_Dirty:":
+			m.PrivateDirty += v
+		case "Referenced:":
+			m.Referenced += v
+		case "Anonymous:":
+			m.Anonymous += v
+		case "Swap:":
+			m.Swap += v
+		}
+		ret[path] = m
+	}
+	return ret, nil
+}
+
+// MemoryBreakdown returns the resident memory of the process split by kind
+// of mapping, computed from the Rss of each mapping of /proc/(pid)/smaps.
+// A mapping is classified, in order, as:
+//   - Stack if it is a [stack] mapping
+//   - Shared if it is mapped shared (s permission), whether anonymous or not
+//   - Heap if it is [heap] or a private anonymous mapping
+//   - Other if it is another kernel mapping such as [vdso]
+//   - Libraries if it is a private mapping of a file which is mapped
+//     executable at least once, so the data segments of a library count
+//     along with its text
+//   - MappedFiles otherwise
+func (p *Process) MemoryBreakdown() (*MemoryBreakdownStat, error) {
+	lines, err := common.ReadLines(common.HostProc(strconv.Itoa(int(p.Pid)), "smaps"))
+	if err != nil {
+		return nil, err
+	}
+	return parseSmapsBreakdown(lines)
+}
+
+// SelfStats returns the CPU times, memory, number of fds and threads and I/O
+// of the current process, as returned by Self, read from
+// /proc/(pid)/{stat,status,fd,io} once each, along with Go runtime
+// statistics. The I/O counters are nil if /proc/(pid)/io can't be read.
+func SelfStats() (*SelfStat, error) {
+	p := Self()
+	user := getCurrentUser()
+	_, _, cpuTimes, _, _, err := p.fillFromStat()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.fillFromStatus(); err != nil {
+		return nil, err
+	}
+	_, fds, err := p.fillFromfdList(user)
+	if err != nil {
+		return nil, err
+	}
+	ret := &SelfStat{
+		CPUTimes:   cpuTimes,
+		RSS:        p.memInfo.RSS,
+		VMS:        p.memInfo.VMS,
+		NumFDs:     int32(len(fds)),
+		NumThreads: p.numThreads,
+	}
+	// io may be missing if the kernel is built without task I/O accounting
+	ret.IO, _ = p.fillFromIO(user)
+	fillGoRuntime(ret)
+
+	return ret, nil
+}
+
 /**
 ** Internal functions
 **/
 
+// fillGoRuntime adds the Go runtime statistics to s.
+func fillGoRuntime(s *SelfStat) {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	s.GoMaxProcs = runtime.GOMAXPROCS(0)
+	s.NumGoroutine = runtime.NumGoroutine()
+	s.GoHeapAlloc = m.HeapAlloc
+	s.GoSys = m.Sys
+}
+
 // Get list of /proc/(pid)/fd files
 func (p *Process) fillFromfdList(user *currentUser) (string, []string, error) {
 	pid := p.Pid
@@ -448,6 +1350,323 @@ func (p *Process) fillFromfd(user *currentUser) (int32, []*OpenFilesStat, error)
 	return numFDs, openfiles, nil
 }
 
+// parseAnonInode returns the subtype of an anon_inode fd link target,
+// ex: "anon_inode:[eventpoll]" or "anon_inode:inotify".
+func parseAnonInode(target string) (string, bool) {
+	if !strings.HasPrefix(target, "anon_inode:") {
+		return "", false
+	}
+	t := strings.TrimPrefix(target, "anon_inode:")
+	t = strings.TrimSuffix(strings.TrimPrefix(t, "["), "]")
+	return t, t != ""
+}
+
+// cgroupCPUQuota returns the number of CPUs the cgroup of the process may
+// use, from cpu.max on cgroup v2 or cpu.cfs_quota_us and cpu.cfs_period_us
+// on cgroup v1. limited is false if no quota is set.
+func (p *Process) cgroupCPUQuota() (quota float64, limited bool, err error) {
+	paths, err := common.ReadCgroupPaths(strconv.Itoa(int(p.Pid)))
+	if err != nil {
+		return 0, false, err
+	}
+
+	if path, ok := paths["cpu"]; ok {
+		dir := common.CgroupDir(common.CgroupMountpoint("cpu"), path)
+		q, err := common.ReadInts(filepath.Join(dir, "cpu.cfs_quota_us"))
+		if err != nil || len(q) == 0 {
+			return 0, false, err
+		}
+		period, err := common.ReadInts(filepath.Join(dir, "cpu.cfs_period_us"))
+		if err != nil || len(period) == 0 {
+			return 0, false, err
+		}
+		if q[0] <= 0 || period[0] <= 0 {
+			return 0, false, nil
+		}
+		return float64(q[0]) / float64(period[0]), true, nil
+	}
+
+	path, ok := paths[""]
+	if !ok {
+		return 0, false, fmt.Errorf("could not find cpu cgroup for pid %d", p.Pid)
+	}
+	dir := common.CgroupDir(common.CgroupMountpoint(""), path)
+	lines, err := common.ReadLines(filepath.Join(dir, "cpu.max"))
+	if err != nil || len(lines) == 0 {
+		// the cpu controller is not enabled for the cgroup
+		return 0, false, nil
+	}
+	return common.ParseCPUMax(lines[0])
+}
+
+// cgroupEffectiveWeight walks up from the cgroup at path to the root of
+// the cgroup v2 hierarchy mounted at mountpoint, multiplying the relative
+// cpu.weight of each level. Levels without the cpu controller enabled do
+// not split the CPU time of their parent.
+func cgroupEffectiveWeight(mountpoint, path string) (float64, error) {
+	ret := 1.0
+	mountpoint = filepath.Clean(mountpoint)
+	for dir := common.CgroupDir(mountpoint, path); dir != mountpoint; dir = filepath.Dir(dir) {
+		weight, err := common.ReadInts(filepath.Join(dir, "cpu.weight"))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil || len(weight) == 0 {
+			return 0, err
+		}
+		siblings, err := filepath.Glob(filepath.Join(filepath.Dir(dir), "*", "cpu.weight"))
+		if err != nil {
+			return 0, err
+		}
+		var total int64
+		for _, sibling := range siblings {
+			w, err := common.ReadInts(sibling)
+			if err != nil || len(w) == 0 {
+				continue
+			}
+			total += w[0]
+		}
+		if total > 0 {
+			ret *= float64(weight[0]) / float64(total)
+		}
+	}
+	return ret, nil
+}
+
+var (
+	cgroupContainerID = regexp.MustCompile(`[0-9a-f]{64}`)
+	shortContainerID  = regexp.MustCompile(`^[0-9a-f]{12}$`)
+)
+
+// parseCgroupContainerID returns the container id found in the cgroup
+// paths of /proc/(pid)/cgroup, ex: "/docker/(id)",
+// "/kubepods/burstable/pod(uid)/(id)" or
+// "/system.slice/cri-containerd-(id).scope".
+func parseCgroupContainerID(lines []string) string {
+	for _, path := range common.ParseCgroupPaths(lines) {
+		if id := cgroupContainerID.FindString(filepath.Base(path)); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// parseServiceAccountToken returns the service account name from the
+// subject of a Kubernetes service account token, a JWT whose subject is
+// "system:serviceaccount:(namespace):(name)". The signature is not checked.
+func parseServiceAccountToken(token string) string {
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return ""
+	}
+	var claims struct {
+		Sub string `json:"sub"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return ""
+	}
+	fields := strings.Split(claims.Sub, ":")
+	if len(fields) != 4 || fields[0] != "system" || fields[1] != "serviceaccount" {
+		return ""
+	}
+	return fields[3]
+}
+
+// parseAutogroup parses an autogroup line, ex: "/autogroup-123 nice 0".
+func parseAutogroup(line string) (*AutogroupStat, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 3 || !strings.HasPrefix(fields[0], "/autogroup-") || fields[1] != "nice" {
+		return nil, fmt.Errorf("wrong autogroup format: %s", line)
+	}
+	id, err := strconv.ParseInt(strings.TrimPrefix(fields[0], "/autogroup-"), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	nice, err := strconv.ParseInt(fields[2], 10, 32)
+	if err != nil {
+		return nil, err
+	}
+	return &AutogroupStat{ID: id, Nice: int32(nice)}, nil
+}
+
+// parseMountInfo parses a mountinfo line, ex:
+// "36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue".
+// The optional fields are terminated by a single hyphen.
+func parseMountInfo(line string) (MountInfoStat, error) {
+	fields := strings.Fields(line)
+	sep := -1
+	for i := 6; i < len(fields); i++ {
+		if fields[i] == "-" {
+			sep = i
+			break
+		}
+	}
+	if sep == -1 || len(fields) < sep+3 {
+		return MountInfoStat{}, fmt.Errorf("wrong mountinfo format: %s", line)
+	}
+	mountID, err := strconv.ParseInt(fields[0], 10, 32)
+	if err != nil {
+		return MountInfoStat{}, err
+	}
+	parentID, err := strconv.ParseInt(fields[1], 10, 32)
+	if err != nil {
+		return MountInfoStat{}, err
+	}
+	ret := MountInfoStat{
+		MountID:        int32(mountID),
+		ParentID:       int32(parentID),
+		Device:         fields[2],
+		Root:           unescapeMountPath(fields[3]),
+		Mountpoint:     unescapeMountPath(fields[4]),
+		Opts:           fields[5],
+		OptionalFields: append([]string{}, fields[6:sep]...),
+		Fstype:         fields[sep+1],
+		Source:         unescapeMountPath(fields[sep+2]),
+	}
+	if len(fields) > sep+3 {
+		ret.SuperOpts = fields[sep+3]
+	}
+	return ret, nil
+}
+
+// unescapeMountPath decodes the octal escapes (ex: "\040" for a space)
+// used by the kernel for paths in mountinfo.
+func unescapeMountPath(path string) string {
+	if !strings.Contains(path, "\\") {
+		return path
+	}
+	var buf bytes.Buffer
+	for i := 0; i < len(path); i++ {
+		if path[i] == '\\' && i+3 < len(path) {
+			if v, err := strconv.ParseUint(path[i+1:i+4], 8, 8); err == nil {
+				buf.WriteByte(byte(v))
+				i += 3
+				continue
+			}
+		}
+		buf.WriteByte(path[i])
+	}
+	return buf.String()
+}
+
+// parseSmapsBreakdown classifies the mappings of smaps, see MemoryBreakdown.
+func parseSmapsBreakdown(lines []string) (*MemoryBreakdownStat, error) {
+	ret := &MemoryBreakdownStat{}
+	// private file mappings are classified once all the executable files are known
+	files := make(map[string]uint64)
+	executables := make(map[string]bool)
+
+	var target *uint64
+	var path string
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if !strings.HasSuffix(fields[0], ":") {
+			// mapping header: address perms offset dev inode [path]
+			if len(fields) < 5 || len(fields[1]) != 4 {
+				return nil, fmt.Errorf("wrong smaps format: %s", line)
+			}
+			perms := fields[1]
+			path = strings.Join(fields[5:], " ")
+			target = nil
+			switch {
+			case path == "[stack]" || strings.HasPrefix(path, "[stack:"):
+				target = &ret.Stack
+			case perms[3] == 's':
+				target = &ret.Shared
+			case path == "" || path == "[heap]":
+				target = &ret.Heap
+			case strings.HasPrefix(path, "["):
+				target = &ret.Other
+			default:
+				if perms[2] == 'x' {
+					executables[path] = true
+				}
+			}
+			continue
+		}
+		if fields[0] != "Rss:" || len(fields) < 2 {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		if target != nil {
+			*target += v * 1024
+		} else {
+			files[path] += v * 1024
+		}
+	}
+
+	for path, rss := range files {
+		if executables[path] {
+			ret.Libraries += rss
+		} else {
+			ret.MappedFiles += rss
+		}
+	}
+	return ret, nil
+}
+
+// Get resource limits from /proc/(pid)/limits, keyed by limit name.
+// Each value holds the soft and the hard limit.
+func (p *Process) fillFromLimits() (map[string][2]uint64, error) {
+	pid := p.Pid
+	limitsPath := common.HostProc(strconv.Itoa(int(pid)), "limits")
+	lines, err := common.ReadLines(limitsPath)
+	if err != nil {
+		return nil, err
+	}
+	return parseLimits(lines)
+}
+
+// parseLimits parses the contents of a /proc/(pid)/limits file. The limit
+// names contain spaces, so the header is used to locate the value columns.
+func parseLimits(lines []string) (map[string][2]uint64, error) {
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("empty limits file")
+	}
+	idx := strings.Index(lines[0], "Soft Limit")
+	if idx == -1 {
+		return nil, fmt.Errorf("wrong format limits header: %s", lines[0])
+	}
+
+	parse := func(v string) (uint64, error) {
+		if v == "unlimited" {
+			return RlimitUnlimited, nil
+		}
+		return strconv.ParseUint(v, 10, 64)
+	}
+
+	ret := make(map[string][2]uint64, len(lines)-1)
+	for _, line := range lines[1:] {
+		if len(line) <= idx {
+			continue
+		}
+		fields := strings.Fields(line[idx:])
+		if len(fields) < 2 {
+			continue
+		}
+		soft, err := parse(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		hard, err := parse(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		ret[strings.TrimSpace(line[:idx])] = [2]uint64{soft, hard}
+	}
+	return ret, nil
+}
+
 // Get cwd from /proc/(pid)/cwd
 func (p *Process) fillFromCwd(user *currentUser) (string, error) {
 	pid := p.Pid
@@ -554,6 +1773,12 @@ func (p *Process) fillFromIO(user *currentUser) (*IOCountersStat, error) {
 			ret.ReadBytes = t
 		case "write_bytes":
 			ret.WriteBytes = t
+		case "rchar":
+			ret.ReadChars = t
+		case "wchar":
+			ret.WriteChars = t
+		case "cancelled_write_bytes":
+			ret.CancelledWriteBytes = t
 		}
 	}
 
@@ -623,6 +1848,12 @@ func (p *Process) fillFromStatus() error {
 	if p.memInfo == nil {
 		p.memInfo = &MemoryInfoStat{}
 	}
+	if p.memSegments == nil {
+		p.memSegments = &MemorySegmentsStat{}
+	}
+	if p.lockedMem == nil {
+		p.lockedMem = &LockedMemoryStat{}
+	}
 
 	statPath := common.HostProc(strconv.Itoa(int(pid)), "status")
 	contents, err := ioutil.ReadFile(statPath)
@@ -648,6 +1879,12 @@ func (p *Process) fillFromStatus() error {
 				return err
 			}
 			p.parent = int32(pval)
+		case "TracerPid":
+			pval, err := strconv.ParseInt(value, 10, 32)
+			if err != nil {
+				return err
+			}
+			p.tracerPid = int32(pval)
 		case "Uid":
 			p.uids = make([]int32, 0, 4)
 			for _, i := range strings.Split(value, "\t") {
@@ -705,6 +1942,55 @@ func (p *Process) fillFromStatus() error {
 				return err
 			}
 			p.memInfo.Swap = v * 1024
+		case "VmData":
+			value := strings.Trim(value, " kB") // remove last "kB"
+			v, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return err
+			}
+			p.memSegments.Data = v * 1024
+		case "VmStk":
+			value := strings.Trim(value, " kB") // remove last "kB"
+			v, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return err
+			}
+			p.memSegments.Stack = v * 1024
+		case "VmExe":
+			value := strings.Trim(value, " kB") // remove last "kB"
+			v, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return err
+			}
+			p.memSegments.Text = v * 1024
+		case "VmLib":
+			value := strings.Trim(value, " kB") // remove last "kB"
+			v, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return err
+			}
+			p.memSegments.Lib = v * 1024
+		case "VmPTE":
+			value := strings.Trim(value, " kB") // remove last "kB"
+			v, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return err
+			}
+			p.memSegments.PTE = v * 1024
+		case "VmLck":
+			value := strings.Trim(value, " kB") // remove last "kB"
+			v, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return err
+			}
+			p.lockedMem.Locked = v * 1024
+		case "VmPin":
+			value := strings.Trim(value, " kB") // remove last "kB"
+			v, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return err
+			}
+			p.lockedMem.Pinned = v * 1024
 
 		case "NSpid":
 			values := strings.Split(value, "\t")
@@ -832,6 +2118,38 @@ func Pids() ([]int32, error) {
 	return ret, nil
 }
 
+// ProcessesByUser returns the processes whose real user id is the one of
+// username. The uid is read from /proc/(pid)/status, so only the matching
+// processes are created. An unknown user returns an empty slice and an error.
+func ProcessesByUser(username string) ([]*Process, error) {
+	ret := []*Process{}
+	u, err := user.Lookup(username)
+	if err != nil {
+		return ret, fmt.Errorf("could not resolve user %s: %s", username, err)
+	}
+
+	pids, err := Pids()
+	if err != nil {
+		return ret, err
+	}
+	for _, pid := range pids {
+		lines, err := common.ReadLines(common.HostProc(strconv.Itoa(int(pid)), "status"))
+		if err != nil {
+			// the process exited
+			continue
+		}
+		for _, line := range lines {
+			if fields := strings.Fields(line); len(fields) > 1 && fields[0] == "Uid:" {
+				if fields[1] == u.Uid {
+					ret = append(ret, &Process{Pid: pid})
+				}
+				break
+			}
+		}
+	}
+	return ret, nil
+}
+
 func AllProcesses() (map[int32]*FilledProcess, error) {
 	pids, err := Pids()
 	if err != nil {
@@ -3,9 +3,19 @@
 package process
 
 import (
+	"context"
+	"io/ioutil"
+	"math"
 	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"syscall"
 	"testing"
+	"time"
 
+	"github.com/DataDog/gopsutil/internal/common"
 	log "github.com/cihub/seelog"
 	"github.com/stretchr/testify/assert"
 )
@@ -126,3 +136,695 @@ func TestFillNsPidFromStatus(t *testing.T) {
 		assert.Equal(t, int32(0), p3.NsPid)
 	})
 }
+
+func TestParseLimits(t *testing.T) {
+	lines, err := common.ReadLines("resources/linux_limits/proc/42/limits")
+	assert.Nil(t, err)
+	limits, err := parseLimits(lines)
+	assert.Nil(t, err)
+	assert.Len(t, limits, 16)
+	assert.Equal(t, [2]uint64{1024, 524288}, limits["Max open files"])
+	assert.Equal(t, [2]uint64{8388608, RlimitUnlimited}, limits["Max stack size"])
+	assert.Equal(t, [2]uint64{8589934592, 8589934592}, limits["Max locked memory"])
+	assert.Equal(t, [2]uint64{0, 0}, limits["Max nice priority"])
+	assert.Equal(t, [2]uint64{RlimitUnlimited, RlimitUnlimited}, limits["Max realtime timeout"])
+
+	_, err = parseLimits([]string{"Max open files 1024 524288 files"})
+	assert.NotNil(t, err)
+}
+
+func TestFDUsageRatio(t *testing.T) {
+	os.Setenv("HOST_PROC", "resources/linux_limits/proc")
+	defer os.Unsetenv("HOST_PROC")
+
+	p := &Process{Pid: 42}
+	ratio, unlimited, err := p.FDUsageRatio()
+	assert.Nil(t, err)
+	assert.False(t, unlimited)
+	assert.Equal(t, float64(3)/1024, ratio)
+
+	p = &Process{Pid: 43}
+	ratio, unlimited, err = p.FDUsageRatio()
+	assert.Nil(t, err)
+	assert.True(t, unlimited)
+	assert.Equal(t, float64(0), ratio)
+}
+
+func TestParseAnonInode(t *testing.T) {
+	for target, expected := range map[string]string{
+		"anon_inode:[eventpoll]": "eventpoll",
+		"anon_inode:[timerfd]":   "timerfd",
+		"anon_inode:inotify":     "inotify",
+		"socket:[12345]":         "",
+		"/dev/null":              "",
+	} {
+		v, ok := parseAnonInode(target)
+		assert.Equal(t, expected != "", ok, target)
+		assert.Equal(t, expected, v, target)
+	}
+}
+
+func TestAnonInodeFDCounts(t *testing.T) {
+	epfd, err := syscall.EpollCreate1(0)
+	assert.Nil(t, err)
+	defer syscall.Close(epfd)
+
+	p, err := NewProcess(int32(os.Getpid()))
+	assert.Nil(t, err)
+	counts, err := p.AnonInodeFDCounts()
+	assert.Nil(t, err)
+	assert.True(t, counts["eventpoll"] >= 1, "missing eventpoll fd: %v", counts)
+}
+
+func TestMemorySegments(t *testing.T) {
+	err := os.Setenv("HOST_PROC", "./resources/linux_postgres/proc")
+	defer os.Unsetenv("HOST_PROC")
+	assert.Nil(t, err)
+
+	p, err := NewProcess(10440)
+	assert.Nil(t, err)
+	v, err := p.MemorySegments()
+	assert.Nil(t, err)
+	assert.Equal(t, MemorySegmentsStat{
+		Data:  2932 * 1024,
+		Stack: 132 * 1024,
+		Text:  6476 * 1024,
+		Lib:   45948 * 1024,
+		PTE:   336 * 1024,
+	}, *v)
+}
+
+func TestIOCountersChars(t *testing.T) {
+	err := os.Setenv("HOST_PROC", "./resources/linux_postgres/proc")
+	defer os.Unsetenv("HOST_PROC")
+	assert.Nil(t, err)
+
+	p, err := NewProcess(10440)
+	assert.Nil(t, err)
+	v, err := p.IOCounters()
+	assert.Nil(t, err)
+	assert.Equal(t, IOCountersStat{
+		ReadCount:  18857927,
+		WriteCount: 3672675,
+		ReadChars:  46349132832,
+		WriteChars: 3672675,
+	}, *v)
+}
+
+func TestParseAutogroup(t *testing.T) {
+	v, err := parseAutogroup("/autogroup-123 nice -5\n")
+	assert.Nil(t, err)
+	assert.Equal(t, AutogroupStat{ID: 123, Nice: -5}, *v)
+
+	_, err = parseAutogroup("/autogroup-abc nice 0")
+	assert.NotNil(t, err)
+	_, err = parseAutogroup("")
+	assert.NotNil(t, err)
+}
+
+func TestParseMountInfo(t *testing.T) {
+	v, err := parseMountInfo(`36 35 98:0 /mnt1 /mnt\040two rw,noatime master:1 shared:2 - ext3 /dev/root rw,errors=continue`)
+	assert.Nil(t, err)
+	assert.Equal(t, MountInfoStat{
+		MountID:        36,
+		ParentID:       35,
+		Device:         "98:0",
+		Root:           "/mnt1",
+		Mountpoint:     "/mnt two",
+		Opts:           "rw,noatime",
+		OptionalFields: []string{"master:1", "shared:2"},
+		Fstype:         "ext3",
+		Source:         "/dev/root",
+		SuperOpts:      "rw,errors=continue",
+	}, v)
+
+	v, err = parseMountInfo("23 28 0:22 / /proc rw,relatime - proc proc rw")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{}, v.OptionalFields)
+	assert.Equal(t, "proc", v.Fstype)
+
+	_, err = parseMountInfo("23 28 0:22 / /proc rw,relatime proc proc rw")
+	assert.NotNil(t, err)
+}
+
+func TestMounts(t *testing.T) {
+	p, err := NewProcess(int32(os.Getpid()))
+	assert.Nil(t, err)
+	v, err := p.Mounts()
+	assert.Nil(t, err)
+	found := false
+	for _, m := range v {
+		if m.Mountpoint == "/" {
+			found = true
+		}
+	}
+	assert.True(t, found, "root mount not found in %v", v)
+}
+
+func TestParseSmapsBreakdown(t *testing.T) {
+	smaps := `55d4c8a00000-55d4c8a21000 r-xp 00000000 08:01 1048601                    /usr/bin/app
+Size:                132 kB
+Rss:                 100 kB
+55d4c8c21000-55d4c8c22000 rw-p 00021000 08:01 1048601                    /usr/bin/app
+Rss:                   4 kB
+55d4c9b5e000-55d4c9b7f000 rw-p 00000000 00:00 0                          [heap]
+Rss:                  20 kB
+7f2b1c000000-7f2b1c021000 rw-p 00000000 00:00 0 
+Rss:                  40 kB
+7f2b20000000-7f2b20100000 rw-s 00000000 00:05 32769                      /SYSV00000000 (deleted)
+Rss:                  64 kB
+7f2b21000000-7f2b21100000 r--p 00000000 08:01 2000                       /var/lib/data file.db
+Rss:                 256 kB
+7ffd5f5e0000-7ffd5f601000 rw-p 00000000 00:00 0                          [stack]
+Rss:                  12 kB
+7ffd5f7f2000-7ffd5f7f4000 r-xp 00000000 00:00 0                          [vdso]
+Rss:                   8 kB
+VmFlags: rd ex mr mw me de sd`
+	v, err := parseSmapsBreakdown(strings.Split(smaps, "\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, MemoryBreakdownStat{
+		Heap:        60 * 1024,
+		Stack:       12 * 1024,
+		Libraries:   104 * 1024,
+		MappedFiles: 256 * 1024,
+		Shared:      64 * 1024,
+		Other:       8 * 1024,
+	}, *v)
+}
+
+func TestSelfStats(t *testing.T) {
+	assert.Equal(t, int32(os.Getpid()), Self().Pid)
+
+	v, err := SelfStats()
+	assert.Nil(t, err)
+	assert.NotNil(t, v.CPUTimes)
+	assert.True(t, v.RSS > 0, "empty rss %v", v)
+	assert.True(t, v.NumFDs > 0, "no fds %v", v)
+	assert.True(t, v.NumThreads > 0, "no threads %v", v)
+	assert.True(t, v.GoMaxProcs > 0, "empty GOMAXPROCS %v", v)
+	assert.True(t, v.NumGoroutine > 0, "no goroutines %v", v)
+}
+
+func TestSelfStatsHostProc(t *testing.T) {
+	// the pid of the agent in the host pid namespace is found through the
+	// self link of the /proc of the host
+	os.Setenv("HOST_PROC", "resources/linux_container/proc")
+	defer os.Unsetenv("HOST_PROC")
+
+	assert.Equal(t, int32(10440), Self().Pid)
+	v, err := SelfStats()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(12456*1024), v.RSS)
+	assert.Equal(t, int32(3), v.NumFDs)
+	assert.Equal(t, int32(1), v.NumThreads)
+	assert.Equal(t, uint64(46349132832), v.IO.ReadChars)
+}
+
+func TestCgroupEffectiveWeight(t *testing.T) {
+	root, err := common.FakeTree(map[string]string{
+		"system.slice/cpu.weight":           "100\n",
+		"user.slice/cpu.weight":             "300\n",
+		"system.slice/a.service/cpu.weight": "100\n",
+		"system.slice/b.service/cpu.weight": "300\n",
+		"system.slice/b.service/c/":         "",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	v, err := cgroupEffectiveWeight(root, "/system.slice/b.service/c")
+	assert.Nil(t, err)
+	assert.InDelta(t, 0.25*0.75, v, 1e-9)
+
+	v, err = cgroupEffectiveWeight(root, "/")
+	assert.Nil(t, err)
+	assert.Equal(t, 1.0, v)
+}
+
+func TestInotifyWatchCount(t *testing.T) {
+	fd, err := syscall.InotifyInit()
+	assert.Nil(t, err)
+	defer syscall.Close(fd)
+	dir, err := ioutil.TempDir("", "inotify")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	_, err = syscall.InotifyAddWatch(fd, dir, syscall.IN_CREATE)
+	assert.Nil(t, err)
+	_, err = syscall.InotifyAddWatch(fd, os.TempDir(), syscall.IN_DELETE)
+	assert.Nil(t, err)
+
+	p, err := NewProcess(int32(os.Getpid()))
+	assert.Nil(t, err)
+	v, err := p.InotifyWatchCount()
+	assert.Nil(t, err)
+	assert.True(t, v.Instances >= 1)
+	assert.True(t, v.Watches >= 2)
+}
+
+func TestTracer(t *testing.T) {
+	err := os.Setenv("HOST_PROC", "./resources/linux_postgres/proc")
+	defer os.Unsetenv("HOST_PROC")
+	assert.Nil(t, err)
+
+	p, err := NewProcess(10440)
+	assert.Nil(t, err)
+	v, err := p.Tracer()
+	assert.Nil(t, err)
+	assert.Equal(t, int32(0), v)
+}
+
+func TestSchedDeadline(t *testing.T) {
+	p, err := NewProcess(int32(os.Getpid()))
+	assert.Nil(t, err)
+	_, err = p.SchedDeadline()
+	if err == common.ErrNotImplementedError {
+		t.Skip("sched_getattr not available")
+	}
+	assert.Equal(t, ErrorNotDeadline, err)
+}
+
+func TestParseCgroupContainerID(t *testing.T) {
+	id := "3f1ac8ff0b3c2e0e7a3a4c2a1a1d4bbd5bd2e7e0b0fd5f0e69e6c20e1db1e0f5"
+	for _, lines := range [][]string{
+		{"4:memory:/docker/" + id, "0::/"},
+		{"0::/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod1234.slice/cri-containerd-" + id + ".scope"},
+	} {
+		assert.Equal(t, id, parseCgroupContainerID(lines))
+	}
+	assert.Equal(t, "", parseCgroupContainerID([]string{"0::/user.slice/user-1000.slice/session-2.scope"}))
+}
+
+func TestContainerIdentity(t *testing.T) {
+	secrets := "42/root/var/run/secrets/kubernetes.io/serviceaccount/"
+	dir, err := common.FakeTree(map[string]string{
+		"42/cgroup":           "0::/kubepods/besteffort/pod1234/0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef\n",
+		"42/environ":          "PATH=/bin\x00HOSTNAME=web-6d4cf56db6-x2x5z\x00KUBERNETES_SERVICE_HOST=10.0.0.1\x00",
+		secrets + "namespace": "shop",
+		// {"alg":"none"}.{"sub":"system:serviceaccount:shop:web"}.
+		secrets + "token": "eyJhbGciOiJub25lIn0.eyJzdWIiOiJzeXN0ZW06c2VydmljZWFjY291bnQ6c2hvcDp3ZWIifQ.sig",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("HOST_PROC", dir)
+	defer os.Unsetenv("HOST_PROC")
+
+	p := &Process{Pid: 42}
+	environ, err := p.Environ()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"PATH=/bin", "HOSTNAME=web-6d4cf56db6-x2x5z", "KUBERNETES_SERVICE_HOST=10.0.0.1"}, environ)
+
+	v, err := p.ContainerIdentity()
+	assert.Nil(t, err)
+	assert.Equal(t, ContainerIdentityStat{
+		ContainerID:    "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+		PodName:        "web-6d4cf56db6-x2x5z",
+		Namespace:      "shop",
+		ServiceAccount: "web",
+	}, *v)
+}
+
+func TestContainerIdentityUnreadable(t *testing.T) {
+	secrets := "42/root/var/run/secrets/kubernetes.io/serviceaccount/"
+	dir, err := common.FakeTree(map[string]string{
+		"42/cgroup":           "0::/kubepods/besteffort/pod1234/0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef\n",
+		"42/environ":          "POD_NAME=web\x00",
+		secrets + "namespace": "shop",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, mode := range map[string]os.FileMode{"42/environ": 0400, secrets + "namespace": 0600} {
+		if err := os.Chmod(filepath.Join(dir, filepath.FromSlash(name)), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.Setenv("HOST_PROC", dir)
+	defer os.Unsetenv("HOST_PROC")
+
+	// only the cgroup is readable by another user
+	p := &Process{Pid: 42}
+	v, err := p.containerIdentity(&currentUser{uid: 12345, euid: 12345})
+	assert.Nil(t, err)
+	assert.Equal(t, ContainerIdentityStat{
+		ContainerID: "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+	}, *v)
+}
+
+func TestLockedMemory(t *testing.T) {
+	dir, err := common.FakeTree(map[string]string{
+		"42/status": "Name:\tpostgres\nState:\tS (sleeping)\nVmPeak:\t  256000 kB\nVmLck:\t    2048 kB\nVmPin:\t     512 kB\nVmHWM:\t   10240 kB\nThreads:\t1\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("HOST_PROC", dir)
+	defer os.Unsetenv("HOST_PROC")
+
+	p := &Process{Pid: 42}
+	v, err := p.LockedMemory()
+	assert.Nil(t, err)
+	assert.Equal(t, LockedMemoryStat{Locked: 2048 * 1024, Pinned: 512 * 1024}, *v)
+}
+
+func TestTHPDisabled(t *testing.T) {
+	thp := "sys/kernel/mm/transparent_hugepage/"
+	dir, err := common.FakeTree(map[string]string{
+		"proc/42/status": "Name:\tredis-server\nThreads:\t4\nTHP_enabled:\t0\n",
+		"proc/42/smaps": "55d1c0000000-55d1c0200000 rw-p 00000000 00:00 0 \nRss:                 512 kB\nTHPeligible:    0\n" +
+			"7f0000000000-7f0000400000 rw-p 00000000 00:00 0 \nRss:                2048 kB\nTHPeligible:    1\n",
+		thp + "enabled": "always [madvise] never\n",
+		thp + "defrag":  "always defer [defer+madvise] madvise never\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("HOST_PROC", filepath.Join(dir, "proc"))
+	defer os.Unsetenv("HOST_PROC")
+	os.Setenv("HOST_SYS", filepath.Join(dir, "sys"))
+	defer os.Unsetenv("HOST_SYS")
+
+	p := &Process{Pid: 42}
+	v, err := p.THPDisabled()
+	assert.Nil(t, err)
+	assert.Equal(t, THPStat{
+		Disabled:         true,
+		EligibleMappings: 1,
+		SystemEnabled:    "madvise",
+		SystemDefrag:     "defer+madvise",
+	}, *v)
+
+	// kernels before 5.0 have no THP_enabled field
+	if err := common.WriteTree(dir, map[string]string{"proc/42/status": "Name:\tredis-server\nThreads:\t4\n"}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.THPDisabled()
+	assert.Equal(t, common.ErrNotImplementedError, err)
+}
+
+func TestPageFaults(t *testing.T) {
+	os.Setenv("HOST_PROC", "resources/linux_postgres/proc")
+	defer os.Unsetenv("HOST_PROC")
+
+	p := &Process{Pid: 1}
+	v, err := p.PageFaults()
+	assert.Nil(t, err)
+	assert.Equal(t, PageFaultsStat{
+		MinorFaults:      425768,
+		MajorFaults:      70,
+		ChildMinorFaults: 306165945,
+		ChildMajorFaults: 4299,
+	}, *v)
+}
+
+func TestOpenFilesCount(t *testing.T) {
+	dir, err := common.FakeTree(map[string]string{"42/fd/": ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for fd, target := range map[string]string{"0": "/dev/null", "1": "/dev/pts/0", "3": "socket:[12345]", "7": "/var/log/app.log"} {
+		if err := os.Symlink(target, filepath.Join(dir, "42", "fd", fd)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.Setenv("HOST_PROC", dir)
+	defer os.Unsetenv("HOST_PROC")
+
+	p := &Process{Pid: 42}
+	v, err := p.OpenFilesCount()
+	assert.Nil(t, err)
+	assert.Equal(t, int32(4), v)
+	files, err := p.OpenFiles()
+	assert.Nil(t, err)
+	assert.Equal(t, len(files), int(v))
+}
+
+func Test_Process_EnvironWithContext(t *testing.T) {
+	dir, err := common.FakeTree(map[string]string{"2/environ": ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("HOST_PROC", dir)
+	defer os.Unsetenv("HOST_PROC")
+
+	p := &Process{Pid: 2}
+	environ, err := p.EnvironWithContext(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{}, environ)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = p.EnvironWithContext(ctx)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func Test_Process_EnvironPermission(t *testing.T) {
+	dir, err := common.FakeTree(map[string]string{"42/environ": "PATH=/bin\x00"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Chmod(filepath.Join(dir, "42", "environ"), 0400); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("HOST_PROC", dir)
+	defer os.Unsetenv("HOST_PROC")
+
+	// the environ of a process owned by another user
+	p := &Process{Pid: 42}
+	_, err = p.readEnviron(&currentUser{uid: 12345, euid: 12345})
+	assert.Equal(t, ErrorEnvironPermission, err)
+	assert.True(t, os.IsPermission(err))
+}
+
+func Test_ProcessesByUser(t *testing.T) {
+	u, err := user.Current()
+	assert.Nil(t, err)
+
+	status := func(uid string) string {
+		return "Name:\ttest\nUid:\t" + uid + "\t" + uid + "\t" + uid + "\t" + uid + "\n"
+	}
+	dir, err := common.FakeTree(map[string]string{
+		"1/status": status(u.Uid),
+		"2/status": status("4242424"),
+		"3/status": status(u.Uid),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("HOST_PROC", dir)
+	defer os.Unsetenv("HOST_PROC")
+
+	v, err := ProcessesByUser(u.Username)
+	assert.Nil(t, err)
+	pids := []int32{}
+	for _, p := range v {
+		pids = append(pids, p.Pid)
+	}
+	assert.ElementsMatch(t, []int32{1, 3}, pids)
+
+	v, err = ProcessesByUser("gopsutil-no-such-user")
+	assert.NotNil(t, err)
+	assert.Equal(t, []*Process{}, v)
+}
+
+func Test_SetHostProc(t *testing.T) {
+	SetHostProc("resources/linux_postgres/proc")
+	defer SetHostProc("")
+
+	p := &Process{Pid: 1}
+	cmdline, err := p.Cmdline()
+	assert.Nil(t, err)
+	assert.Equal(t, "/lib/systemd/systemd --system --deserialize 25", cmdline)
+	status, err := p.Status()
+	assert.Nil(t, err)
+	assert.Equal(t, "S", status)
+	ppid, err := p.Ppid()
+	assert.Nil(t, err)
+	assert.Equal(t, int32(0), ppid)
+	mem, err := p.MemoryInfo()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1541)*PageSize, mem.RSS)
+}
+
+func Test_Process_RlimitUsageByResource(t *testing.T) {
+	os.Setenv("HOST_PROC", "resources/linux_limits/proc")
+	defer os.Unsetenv("HOST_PROC")
+
+	p := &Process{Pid: 42}
+	v, err := p.RlimitUsageByResource(RLIMIT_NOFILE)
+	assert.Nil(t, err)
+	assert.Equal(t, RlimitUsageStat{Resource: RLIMIT_NOFILE, Soft: 1024, Hard: 524288, Used: 3}, v)
+
+	v, err = p.RlimitUsageByResource(RLIMIT_RSS)
+	assert.Nil(t, err)
+	assert.Equal(t, RlimitUsageStat{Resource: RLIMIT_RSS, Soft: RlimitUnlimited, Hard: RlimitUnlimited, Used: 1541 * PageSize}, v)
+
+	// a limit above math.MaxInt32, on the scale of the usage
+	v, err = p.RlimitUsageByResource(RLIMIT_DATA)
+	assert.Nil(t, err)
+	assert.Equal(t, RlimitUsageStat{Resource: RLIMIT_DATA, Soft: 4294967296, Hard: RlimitUnlimited, Used: 149244 * 1024}, v)
+	assert.True(t, v.Used < v.Soft)
+
+	v, err = p.RlimitUsageByResource(RLIMIT_CORE)
+	assert.Nil(t, err)
+	assert.Equal(t, RlimitUsageStat{Resource: RLIMIT_CORE, Soft: 0, Hard: RlimitUnlimited}, v)
+
+	_, err = p.RlimitUsageByResource(int32(16))
+	assert.NotNil(t, err)
+}
+
+func Test_Process_Rlimit(t *testing.T) {
+	os.Setenv("HOST_PROC", "resources/linux_limits/proc")
+	defer os.Unsetenv("HOST_PROC")
+
+	p := &Process{Pid: 42}
+	v, err := p.Rlimit()
+	assert.Nil(t, err)
+	assert.Len(t, v, 16)
+	for i, r := range v {
+		assert.Equal(t, int32(i), r.Resource)
+	}
+	assert.Equal(t, RlimitStat{Resource: RLIMIT_STACK, Soft: 8388608, Hard: -1}, v[RLIMIT_STACK])
+	assert.Equal(t, RlimitStat{Resource: RLIMIT_NOFILE, Soft: 1024, Hard: 524288}, v[RLIMIT_NOFILE])
+	assert.Equal(t, RlimitStat{Resource: RLIMIT_MEMLOCK, Soft: math.MaxInt32, Hard: math.MaxInt32}, v[RLIMIT_MEMLOCK])
+	assert.Equal(t, RlimitStat{Resource: RLIMIT_RTTIME, Soft: -1, Hard: -1}, v[RLIMIT_RTTIME])
+}
+
+func Test_Process_Threads(t *testing.T) {
+	dir, err := common.FakeTree(map[string]string{
+		"42/task/42/stat": "42 (server) S 1 42 42 0 -1 4194560 1000 0 0 0 150 25 0 0 20 0 3 0 100 0 0",
+		"42/task/43/stat": "43 (worker (1)) R 1 42 42 0 -1 4194624 10 0 0 0 900 100 0 0 20 0 3 0 101 0 0",
+		// a thread which exited
+		"42/task/44/": "",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("HOST_PROC", dir)
+	defer os.Unsetenv("HOST_PROC")
+
+	p := &Process{Pid: 42}
+	v, err := p.Threads()
+	assert.Nil(t, err)
+	assert.Len(t, v, 2)
+	assert.Equal(t, 1.5, v[42].User)
+	assert.Equal(t, 0.25, v[42].System)
+	assert.Equal(t, 9.0, v[43].User)
+	assert.Equal(t, 1.0, v[43].System)
+}
+
+func TestParseSmapsByPath(t *testing.T) {
+	smaps := `7f2b1d000000-7f2b1d1c0000 r-xp 00000000 08:01 3000                       /lib/x86_64-linux-gnu/libc.so.6
+Size:               1792 kB
+Rss:                1200 kB
+Pss:                  60 kB
+Shared_Clean:       1200 kB
+7f2b1d3c0000-7f2b1d3c4000 rw-p 001c0000 08:01 3000                       /lib/x86_64-linux-gnu/libc.so.6
+Size:                 16 kB
+Rss:                  16 kB
+Pss:                  16 kB
+Private_Dirty:        16 kB
+Anonymous:            16 kB
+7f2b1c000000-7f2b1c021000 rw-p 00000000 00:00 0 
+Rss:                  40 kB
+Pss:                  40 kB
+7f2b1e000000-7f2b1e021000 rw-p 00000000 00:00 0 
+Rss:                   8 kB
+Pss:                   8 kB
+Swap:                  4 kB
+VmFlags: rd wr mr mw me ac sd`
+	v, err := parseSmapsByPath(strings.Split(smaps, "\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]MemoryMapsStat{
+		"/lib/x86_64-linux-gnu/libc.so.6": {
+			Path:         "/lib/x86_64-linux-gnu/libc.so.6",
+			Size:         1808,
+			Rss:          1216,
+			Pss:          76,
+			SharedClean:  1200,
+			PrivateDirty: 16,
+			Anonymous:    16,
+		},
+		"[anon]": {Path: "[anon]", Rss: 48, Pss: 48, Swap: 4},
+	}, v)
+}
+
+func Test_NewProcessUnchecked(t *testing.T) {
+	dir, err := common.FakeTree(map[string]string{"42/cmdline": "sleep\x00100\x00"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("HOST_PROC", dir)
+	defer os.Unsetenv("HOST_PROC")
+
+	p := NewProcessUnchecked(42)
+	cmdline, err := p.Cmdline()
+	assert.Nil(t, err)
+	assert.Equal(t, "sleep 100", cmdline)
+
+	// the process is gone
+	_, err = NewProcessUnchecked(43).Cmdline()
+	assert.NotNil(t, err)
+	_, err = NewProcess(43)
+	assert.NotNil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p, err = NewProcessWithContext(ctx, 42)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(42), p.Pid)
+	cancel()
+	_, err = NewProcessWithContext(ctx, 42)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func Test_Process_CPUAffinity(t *testing.T) {
+	p := testGetProcess()
+
+	cpus, err := p.CPUAffinity()
+	assert.Nil(t, err)
+	assert.NotEmpty(t, cpus)
+
+	assert.Nil(t, p.SetCPUAffinity(cpus[:1]))
+	got, err := p.CPUAffinity()
+	assert.Nil(t, err)
+	assert.Equal(t, cpus[:1], got)
+	assert.Nil(t, p.SetCPUAffinity(cpus))
+
+	assert.Equal(t, ErrorEmptyAffinity, p.SetCPUAffinity(nil))
+}
+
+func Test_Process_WaitForExit(t *testing.T) {
+	cmd := exec.Command("sleep", "10")
+	assert.Nil(t, cmd.Start())
+	p := NewProcessUnchecked(int32(cmd.Process.Pid))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	exited, err := p.WaitForExit(ctx)
+	assert.False(t, exited)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+	exited, err = p.WaitForExit(context.Background())
+	assert.Nil(t, err)
+	assert.True(t, exited)
+
+	exited, err = pollExit(context.Background(), p.Pid)
+	assert.Nil(t, err)
+	assert.True(t, exited)
+}
@@ -5,9 +5,11 @@ package process
 import (
 	"C"
 	"bytes"
+	"context"
 	"encoding/binary"
 	"strings"
 	"syscall"
+	"time"
 	"unsafe"
 
 	cpu "github.com/DataDog/gopsutil/cpu"
@@ -186,8 +188,8 @@ func (p *Process) NumThreads() (int32, error) {
 	/* not supported, just return 1 */
 	return 1, nil
 }
-func (p *Process) Threads() (map[string]string, error) {
-	ret := make(map[string]string, 0)
+func (p *Process) Threads() (map[int32]*cpu.TimesStat, error) {
+	ret := make(map[int32]*cpu.TimesStat)
 	return ret, common.ErrNotImplementedError
 }
 func (p *Process) Times() (*cpu.TimesStat, error) {
@@ -204,6 +206,9 @@ func (p *Process) Times() (*cpu.TimesStat, error) {
 func (p *Process) CPUAffinity() ([]int32, error) {
 	return nil, common.ErrNotImplementedError
 }
+func (p *Process) SetCPUAffinity(cpus []int32) error {
+	return common.ErrNotImplementedError
+}
 func (p *Process) MemoryInfo() (*MemoryInfoStat, error) {
 	k, err := p.getKProc()
 	if err != nil {
@@ -356,3 +361,98 @@ func CallKernProcSyscall(op int32, arg int32) ([]byte, uint64, error) {
 
 	return buf, length, nil
 }
+
+func (p *Process) FDUsageRatio() (float64, bool, error) {
+	return 0, false, common.ErrNotImplementedError
+}
+
+func (p *Process) AnonInodeFDCounts() (map[string]int32, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func (p *Process) MemorySegments() (*MemorySegmentsStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func (p *Process) CPUPercentVsCgroupQuota(interval time.Duration) (float64, bool, error) {
+	return 0, false, common.ErrNotImplementedError
+}
+
+func (p *Process) Autogroup() (*AutogroupStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func (p *Process) Mounts() ([]MountInfoStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func (p *Process) MemoryBreakdown() (*MemoryBreakdownStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func SelfStats() (*SelfStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func (p *Process) EffectiveCPUWeight() (float64, error) {
+	return 0, common.ErrNotImplementedError
+}
+
+func (p *Process) InotifyWatchCount() (*InotifyWatchStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func (p *Process) Tracer() (int32, error) {
+	return 0, common.ErrNotImplementedError
+}
+
+func (p *Process) SchedDeadline() (*SchedDeadlineStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func (p *Process) Environ() ([]string, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func (p *Process) ContainerIdentity() (*ContainerIdentityStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func (p *Process) LockedMemory() (*LockedMemoryStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func (p *Process) THPDisabled() (*THPStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func (p *Process) PageFaults() (*PageFaultsStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func (p *Process) OpenFilesCount() (int32, error) {
+	return 0, common.ErrNotImplementedError
+}
+
+func (p *Process) EnvironWithContext(ctx context.Context) ([]string, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+func ProcessesByUser(username string) ([]*Process, error) {
+	return []*Process{}, common.ErrNotImplementedError
+}
+
+func (p *Process) RlimitUsageByResource(resource int32) (RlimitUsageStat, error) {
+	return RlimitUsageStat{}, common.ErrNotImplementedError
+}
+
+func (p *Process) MemoryMapsGroupedByPath() (map[string]MemoryMapsStat, error) {
+	return nil, common.ErrNotImplementedError
+}
+
+// WaitForExit blocks until the process exits or ctx is done, and reports
+// whether it exited. It polls PidExists, and can not detect a pid reused
+// by a new process in between two polls.
+func (p *Process) WaitForExit(ctx context.Context) (bool, error) {
+	return pollExit(ctx, p.Pid)
+}
@@ -383,3 +383,28 @@ func Test_OpenFiles(t *testing.T) {
 	}
 
 }
+
+func Test_diffEnviron(t *testing.T) {
+	environ := []string{"PATH=/usr/bin", "LANG=C", "Home=/root", "EMPTY="}
+	expected := map[string]string{
+		"PATH":  "/usr/bin",
+		"LANG":  "en_US.UTF-8",
+		"EMPTY": "",
+		"DEBUG": "0",
+		"HOME":  "/root",
+	}
+
+	v := diffEnviron(environ, expected, EnvironDiffOptions{})
+	assert.Equal(t, []string{"DEBUG", "HOME"}, v.Missing)
+	assert.Equal(t, map[string]string{"LANG": "C"}, v.Unexpected)
+	assert.Empty(t, v.Extra)
+
+	v = diffEnviron(environ, expected, EnvironDiffOptions{IgnoreCase: true, ReportExtra: true})
+	assert.Equal(t, []string{"DEBUG"}, v.Missing)
+	assert.Equal(t, map[string]string{"LANG": "C"}, v.Unexpected)
+	assert.Empty(t, v.Extra)
+
+	delete(expected, "PATH")
+	v = diffEnviron(environ, expected, EnvironDiffOptions{ReportExtra: true})
+	assert.Equal(t, []string{"Home", "PATH"}, v.Extra)
+}